@@ -0,0 +1,26 @@
+package main
+
+import "net/http"
+
+// chain is an ordered list of middleware. The first one listed is the
+// outermost, so it sees the request first and the response last. A chain
+// can be passed straight to a chi router with Use(c...).
+type chain []func(http.Handler) http.Handler
+
+func newChain(middleware ...func(http.Handler) http.Handler) chain {
+	return append(chain(nil), middleware...)
+}
+
+// Append returns a new chain with middleware run after c's. c itself is
+// unchanged, so several groups can extend a shared base.
+func (c chain) Append(middleware ...func(http.Handler) http.Handler) chain {
+	return append(newChain(c...), middleware...)
+}
+
+// Then wraps h in the chain.
+func (c chain) Then(h http.Handler) http.Handler {
+	for i := len(c) - 1; i >= 0; i-- {
+		h = c[i](h)
+	}
+	return h
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name+" in")
+				next.ServeHTTP(w, r)
+				calls = append(calls, name+" out")
+			})
+		}
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	})
+
+	base := newChain(record("a"), record("b"))
+	public := base.Append(record("c"))
+	authed := base.Append(record("d"))
+
+	tests := []struct {
+		name  string
+		chain chain
+		want  []string
+	}{
+		{"base", base, []string{"a in", "b in", "handler", "b out", "a out"}},
+		{"public", public, []string{"a in", "b in", "c in", "handler", "c out", "b out", "a out"}},
+		{"authed", authed, []string{"a in", "b in", "d in", "handler", "d out", "b out", "a out"}},
+	}
+	for _, tt := range tests {
+		calls = nil
+		tt.chain.Then(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		if !slices.Equal(calls, tt.want) {
+			t.Errorf("%s: calls = %v, want %v", tt.name, calls, tt.want)
+		}
+	}
+}
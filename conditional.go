@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// notModified sets Last-Modified to lastModified and, if the request's
+// If-Modified-Since shows the client already has that version, writes a 304
+// and reports true. A zero lastModified, as for an empty list, sets no
+// header and never matches. HTTP dates have whole second precision, as do
+// our stored timestamps.
+func notModified(w http.ResponseWriter, r *http.Request, lastModified time.Time) bool {
+	if !setLastModified(w, lastModified) || !unchangedSince(r, lastModified) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// setLastModified sets the Last-Modified header unless lastModified is zero,
+// and reports whether it did.
+func setLastModified(w http.ResponseWriter, lastModified time.Time) bool {
+	if lastModified.IsZero() {
+		return false
+	}
+	w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	return true
+}
+
+// unchangedSince reports whether the request's If-Modified-Since is no
+// older than lastModified. Without the header it is false.
+func unchangedSince(r *http.Request, lastModified time.Time) bool {
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !lastModified.Truncate(time.Second).After(since)
+}
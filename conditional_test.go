@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/testutil"
+)
+
+func TestNotesListLastModified(t *testing.T) {
+	h, db := newTestRouter(t)
+	user := testutil.SeedUser(t, db, "alice")
+
+	get := func(path, ifModifiedSince string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+		if ifModifiedSince != "" {
+			req.Header.Set("If-Modified-Since", ifModifiedSince)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/v1/notes", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Last-Modified") != "" {
+		t.Fatalf("empty list: status = %d, Last-Modified = %q, want 200 without one", rec.Code, rec.Header().Get("Last-Modified"))
+	}
+
+	testutil.SeedNotes(t, db, user.ID, 3)
+	rec = get("/v1/notes", "")
+	lastModified := rec.Header().Get("Last-Modified")
+	if want := decodeResponse[[]Note](t, rec)[0].UpdatedAt.Format(http.TimeFormat); lastModified != want {
+		t.Fatalf("Last-Modified = %q, want the newest note's updated_at %q", lastModified, want)
+	}
+
+	rec = get("/v1/notes", lastModified)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("unchanged list: status = %d with %d byte body, want %d and none", rec.Code, rec.Body.Len(), http.StatusNotModified)
+	}
+	for _, tt := range []struct {
+		name, path, ifModifiedSince string
+	}{
+		{"older date", "/v1/notes", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)},
+		{"malformed date", "/v1/notes", "yesterday"},
+		{"hasTags filter", "/v1/notes?hasTags=false", lastModified},
+	} {
+		if rec := get(tt.path, tt.ifModifiedSince); rec.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, http.StatusOK)
+		}
+	}
+
+	// A note stamped a minute ahead, as a later request would be.
+	later := time.Now().Add(time.Minute).UTC()
+	err := db.CreateNote(context.Background(), database.CreateNoteParams{
+		ID:        "later",
+		CreatedAt: later.Format(time.RFC3339),
+		UpdatedAt: later.Format(time.RFC3339),
+		Note:      "new",
+		UserID:    user.ID,
+		Metadata:  emptyNoteMetadata,
+	})
+	if err != nil {
+		t.Fatalf("creating note: %v", err)
+	}
+	rec = get("/v1/notes", lastModified)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("after a new note: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got, want := rec.Header().Get("Last-Modified"), later.Format(http.TimeFormat); got != want {
+		t.Errorf("Last-Modified after a new note = %q, want %q", got, want)
+	}
+	if notes := decodeResponse[[]Note](t, rec); len(notes) != 4 {
+		t.Errorf("got %d notes, want 4", len(notes))
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redactedValue replaces the value of secret settings in GET /admin/config.
+const redactedValue = "[redacted]"
+
+// setting is one environment variable as the server resolved it.
+type setting struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Default reports that the variable was unset and the fallback applied.
+	Default bool `json:"default"`
+}
+
+// settingLog records every variable read through the env helpers below, so
+// GET /admin/config can show the configuration actually in effect. A
+// variable that startup never consulted, such as USER_CACHE_SIZE without
+// USER_CACHE_TTL, doesn't appear.
+type settingLog struct {
+	mu     sync.Mutex
+	byName map[string]setting
+}
+
+var settings = &settingLog{byName: make(map[string]setting)}
+
+func (l *settingLog) record(name, raw, effective string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.byName[name] = setting{Name: name, Value: effective, Default: raw == ""}
+}
+
+// redacted returns the recorded settings sorted by name, with the values of
+// secrets masked.
+func (l *settingLog) redacted() []setting {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]setting, 0, len(l.byName))
+	for _, s := range l.byName {
+		if s.Value != "" && isSecretSetting(s.Name) {
+			s.Value = redactedValue
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// isSecretSetting reports whether the variable name holds a credential.
+// Database URLs count: libSQL takes its auth token as a query parameter.
+func isSecretSetting(name string) bool {
+	for _, suffix := range []string{"_KEY", "_SECRET", "_TOKEN", "_PASSWORD", "_URL"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// envString reads a string from the environment, returning fallback when
+// the variable is unset or empty.
+func envString(name, fallback string) string {
+	v := os.Getenv(name)
+	s := v
+	if s == "" {
+		s = fallback
+	}
+	settings.record(name, v, s)
+	return s
+}
+
+// envPositiveInt reads a positive integer from the environment, returning
+// fallback when the variable is unset. Invalid values are fatal at startup.
+func envPositiveInt(name string, fallback int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		settings.record(name, v, strconv.Itoa(fallback))
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		log.Fatalf("%s must be a positive integer, got %q", name, v)
+	}
+	settings.record(name, v, strconv.Itoa(n))
+	return n
+}
+
+// envBool reads a boolean from the environment, returning fallback when the
+// variable is unset. Invalid values are fatal at startup.
+func envBool(name string, fallback bool) bool {
+	v := os.Getenv(name)
+	if v == "" {
+		settings.record(name, v, strconv.FormatBool(fallback))
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Fatalf("%s must be a boolean, got %q", name, v)
+	}
+	settings.record(name, v, strconv.FormatBool(b))
+	return b
+}
+
+// envDuration reads a non-negative time.ParseDuration value from the
+// environment, returning fallback when the variable is unset. Invalid values
+// are fatal at startup.
+func envDuration(name string, fallback time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		settings.record(name, v, fallback.String())
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		log.Fatalf("%s must be a non-negative duration, got %q", name, v)
+	}
+	settings.record(name, v, d.String())
+	return d
+}
+
+// envList reads a comma-separated list from the environment, dropping blank
+// entries. It returns nil when the variable is unset or empty.
+func envList(name string) []string {
+	v := os.Getenv(name)
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	settings.record(name, v, strings.Join(items, ","))
+	return items
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// maxTimestamp sorts after every RFC3339 UTC timestamp we store, standing in
+// for an open upper bound in created_at comparisons.
+const maxTimestamp = "9999-12-31T23:59:59Z"
+
+type dateRange struct {
+	After  string
+	Before string
+}
+
+// parseDateRange reads the optional createdAfter and createdBefore RFC3339
+// query parameters and returns them as inclusive bounds in the stored UTC
+// format. Missing bounds are open; repeated ones are rejected.
+//
+// created_at is stored to the whole second, so a fractional bound can't be
+// compared as sent. createdAfter is rounded up and createdBefore down to the
+// second, which selects exactly the notes whose stored time falls within
+// the requested range.
+func parseDateRange(r *http.Request) (dateRange, error) {
+	rng := dateRange{Before: maxTimestamp}
+	query := r.URL.Query()
+
+	var after, before time.Time
+	v, err := queryValue(query, "createdAfter")
+	if err != nil {
+		return dateRange{}, err
+	}
+	if v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return dateRange{}, fmt.Errorf("createdAfter must be an RFC3339 timestamp, got %q", v)
+		}
+		after = t
+		if ceil := t.Truncate(time.Second); !ceil.Equal(t) {
+			t = ceil.Add(time.Second)
+		}
+		rng.After = t.UTC().Format(time.RFC3339)
+	}
+	if v, err = queryValue(query, "createdBefore"); err != nil {
+		return dateRange{}, err
+	}
+	if v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return dateRange{}, fmt.Errorf("createdBefore must be an RFC3339 timestamp, got %q", v)
+		}
+		before = t
+		rng.Before = t.UTC().Format(time.RFC3339)
+	}
+	if !after.IsZero() && !before.IsZero() && after.After(before) {
+		return dateRange{}, errors.New("createdAfter must not be later than createdBefore")
+	}
+	return rng, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func TestNotesListDateRange(t *testing.T) {
+	h, db := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	for i, createdAt := range []string{
+		"2024-03-03T12:00:00Z",
+		"2024-03-10T12:00:00Z",
+		"2024-03-14T23:59:59Z",
+		"2024-03-20T12:00:00Z",
+	} {
+		err := db.CreateNote(context.Background(), database.CreateNoteParams{
+			ID:        string(rune('a' + i)),
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+			Note:      createdAt,
+			UserID:    user.ID,
+			Metadata:  emptyNoteMetadata,
+		})
+		if err != nil {
+			t.Fatalf("seeding note: %v", err)
+		}
+	}
+
+	list := func(query url.Values) *struct {
+		code  int
+		notes []Note
+	} {
+		rec := doRequest(t, h, http.MethodGet, "/v1/notes?"+query.Encode(), user.ApiKey, nil)
+		result := &struct {
+			code  int
+			notes []Note
+		}{code: rec.Code}
+		if rec.Code == http.StatusOK {
+			result.notes = decodeResponse[[]Note](t, rec)
+		}
+		return result
+	}
+
+	t.Run("valid range", func(t *testing.T) {
+		got := list(url.Values{
+			"createdAfter":  {"2024-03-10T00:00:00Z"},
+			"createdBefore": {"2024-03-15T00:59:59+01:00"},
+		})
+		if got.code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", got.code, http.StatusOK)
+		}
+		if len(got.notes) != 2 || got.notes[0].Note != "2024-03-14T23:59:59Z" || got.notes[1].Note != "2024-03-10T12:00:00Z" {
+			t.Errorf("notes = %+v, want the 14th and 10th", got.notes)
+		}
+	})
+
+	t.Run("combined with pagination", func(t *testing.T) {
+		got := list(url.Values{"createdAfter": {"2024-03-05T00:00:00Z"}, "limit": {"1"}, "offset": {"1"}})
+		if len(got.notes) != 1 || got.notes[0].Note != "2024-03-14T23:59:59Z" {
+			t.Errorf("notes = %+v, want only the 14th", got.notes)
+		}
+	})
+
+	t.Run("sub-second bounds", func(t *testing.T) {
+		for _, tt := range []struct {
+			after, before string
+			want          int
+		}{
+			{"2024-03-14T23:59:58.5Z", "", 2},
+			{"2024-03-14T23:59:59.5Z", "", 1},
+			{"2024-03-14T00:00:00Z", "2024-03-14T23:59:59.5Z", 1},
+			{"2024-03-14T00:00:00Z", "2024-03-14T23:59:58.999Z", 0},
+		} {
+			query := url.Values{"createdAfter": {tt.after}}
+			if tt.before != "" {
+				query.Set("createdBefore", tt.before)
+			}
+			if got := list(query); got.code != http.StatusOK || len(got.notes) != tt.want {
+				t.Errorf("%s: status %d with %d notes, want %d notes", query.Encode(), got.code, len(got.notes), tt.want)
+			}
+		}
+	})
+
+	t.Run("inverted range", func(t *testing.T) {
+		got := list(url.Values{
+			"createdAfter":  {"2024-03-15T00:00:00Z"},
+			"createdBefore": {"2024-03-10T00:00:00Z"},
+		})
+		if got.code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", got.code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("bad format", func(t *testing.T) {
+		got := list(url.Values{"createdAfter": {"2024-03-10"}})
+		if got.code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", got.code, http.StatusBadRequest)
+		}
+	})
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+// dbFor picks the store for r: safe methods read from ReadDB when a replica
+// is configured, everything else goes to the primary DB.
+//
+// Replicas can lag, so writes that read their own rows back must go through
+// the primary. They do here because they never arrive as GET or HEAD.
+func (cfg *apiConfig) dbFor(r *http.Request) store.Store {
+	if cfg.ReadDB != nil && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+		return cfg.ReadDB
+	}
+	return cfg.DB
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store/memstore"
+)
+
+// replicaStore stands in for a read replica that is fully caught up: it
+// shares the primary's data and counts the calls routed to it.
+type replicaStore struct {
+	store.Store
+	reads, writes int
+}
+
+func (s *replicaStore) GetUser(ctx context.Context, apiKey string) (database.User, error) {
+	s.reads++
+	return s.Store.GetUser(ctx, apiKey)
+}
+
+func (s *replicaStore) GetNotesForUser(ctx context.Context, arg database.GetNotesForUserParams) ([]database.Note, error) {
+	s.reads++
+	return s.Store.GetNotesForUser(ctx, arg)
+}
+
+func (s *replicaStore) CreateNote(ctx context.Context, arg database.CreateNoteParams) error {
+	s.writes++
+	return s.Store.CreateNote(ctx, arg)
+}
+
+func TestReadsUseReplica(t *testing.T) {
+	var replica *replicaStore
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		replica = &replicaStore{Store: cfg.DB}
+		cfg.ReadDB = replica
+	})
+	user := createTestUser(t, h, "alice")
+
+	createTestNote(t, h, user.ApiKey, "hello")
+	if replica.reads != 0 || replica.writes != 0 {
+		t.Fatalf("POST used replica: %d reads, %d writes", replica.reads, replica.writes)
+	}
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes", user.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := decodeResponse[[]Note](t, rec); len(got) != 1 {
+		t.Errorf("list returned %d notes, want 1", len(got))
+	}
+	// One read to authenticate, one to list.
+	if replica.reads != 2 {
+		t.Errorf("replica reads = %d, want 2", replica.reads)
+	}
+}
+
+func TestDBForWithoutReplica(t *testing.T) {
+	primary := memstore.New()
+	cfg := &apiConfig{DB: primary}
+
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodPost} {
+		if got := cfg.dbFor(httptest.NewRequest(method, "/", nil)); got != store.Store(primary) {
+			t.Errorf("dbFor(%s) = %T, want the primary", method, got)
+		}
+	}
+}
+
+func TestDBForWithReplica(t *testing.T) {
+	primary, replica := memstore.New(), memstore.New()
+	cfg := &apiConfig{DB: primary, ReadDB: replica}
+
+	tests := []struct {
+		method string
+		want   *memstore.Store
+	}{
+		{http.MethodGet, replica},
+		{http.MethodHead, replica},
+		{http.MethodPost, primary},
+		{http.MethodPatch, primary},
+		{http.MethodDelete, primary},
+	}
+	for _, tt := range tests {
+		if got := cfg.dbFor(httptest.NewRequest(tt.method, "/", nil)); got != store.Store(tt.want) {
+			t.Errorf("dbFor(%s) picked the wrong store", tt.method)
+		}
+	}
+}
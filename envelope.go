@@ -0,0 +1,101 @@
+package main
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+)
+
+// envelope is the opt-in response shape: successful payloads go under data,
+// error messages under error, and request metadata under meta.
+type envelope struct {
+	Data   any             `json:"data,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Code   string          `json:"code,omitempty"`
+	Fields validate.Errors `json:"fields,omitempty"`
+	Meta   map[string]any  `json:"meta"`
+}
+
+// envelopeWriter marks a response as enveloped and collects the metadata
+// handlers attach to it before respondWithJSON writes the body.
+type envelopeWriter struct {
+	http.ResponseWriter
+	meta map[string]any
+}
+
+func (w *envelopeWriter) wrap(payload any) envelope {
+	if e, ok := payload.(errorResponse); ok {
+		return envelope{Error: e.Error, Code: e.Code, Fields: e.Fields, Meta: w.meta}
+	}
+	return envelope{Data: payload, Meta: w.meta}
+}
+
+func (w *envelopeWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *envelopeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// envelopeWriterFor finds the envelopeWriter under w, looking through any
+// writer that unwraps.
+func envelopeWriterFor(w http.ResponseWriter) (*envelopeWriter, bool) {
+	for {
+		switch v := w.(type) {
+		case *envelopeWriter:
+			return v, true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
+
+// setResponseMeta attaches metadata to an enveloped response. It is a no-op
+// for bare responses.
+func setResponseMeta(w http.ResponseWriter, key string, value any) {
+	if ew, ok := w.(*envelopeWriter); ok {
+		ew.meta[key] = value
+	}
+}
+
+// middlewareEnvelope wraps responses in an envelope when ResponseEnvelope is
+// set or the client asks with an envelope parameter on the JSON media type,
+// e.g. "Accept: application/json; envelope=true". The parameter also lets a
+// client opt out when the envelope is on by default.
+func (cfg *apiConfig) middlewareEnvelope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !wantsEnvelope(r, cfg.ResponseEnvelope) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&envelopeWriter{
+			ResponseWriter: w,
+			meta: map[string]any{
+				"request_id": requestIDFromContext(r.Context()),
+			},
+		}, r)
+	})
+}
+
+func wantsEnvelope(r *http.Request, fallback bool) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(accept)
+		if err != nil || mediaType != "application/json" {
+			continue
+		}
+		if v, ok := params["envelope"]; ok {
+			if enabled, err := strconv.ParseBool(v); err == nil {
+				return enabled
+			}
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResponseEnvelope(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	createTestNote(t, h, user.ApiKey, "hello")
+
+	get := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/notes?limit=5", nil)
+		req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("bare by default", func(t *testing.T) {
+		notes := decodeResponse[[]Note](t, get(""))
+		if len(notes) != 1 || notes[0].Note != "hello" {
+			t.Errorf("bare response = %+v, want one note", notes)
+		}
+	})
+
+	t.Run("enveloped on request", func(t *testing.T) {
+		rec := get("application/json; envelope=true")
+		body := decodeResponse[struct {
+			Data []Note `json:"data"`
+			Meta struct {
+				RequestID  string     `json:"request_id"`
+				Pagination pagination `json:"pagination"`
+			} `json:"meta"`
+		}](t, rec)
+		if len(body.Data) != 1 || body.Data[0].Note != "hello" {
+			t.Errorf("data = %+v, want one note", body.Data)
+		}
+		if body.Meta.RequestID == "" || body.Meta.RequestID != rec.Header().Get("X-Request-ID") {
+			t.Errorf("meta.request_id = %q, want X-Request-ID %q", body.Meta.RequestID, rec.Header().Get("X-Request-ID"))
+		}
+		if body.Meta.Pagination != (pagination{Limit: 5, Offset: 0}) {
+			t.Errorf("meta.pagination = %+v, want limit 5 offset 0", body.Meta.Pagination)
+		}
+	})
+
+	t.Run("errors are enveloped", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/notes?limit=-1", nil)
+		req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+		req.Header.Set("Accept", "application/json; envelope=true")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		body := decodeResponse[map[string]json.RawMessage](t, rec)
+		if _, ok := body["error"]; !ok {
+			t.Errorf("error envelope = %v, want an error field", body)
+		}
+		if _, ok := body["data"]; ok {
+			t.Errorf("error envelope = %v, want no data field", body)
+		}
+	})
+}
+
+func TestResponseEnvelopeConfigDefault(t *testing.T) {
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.ResponseEnvelope = true
+	})
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/healthz", "", nil)
+	body := decodeResponse[map[string]map[string]any](t, rec)
+	if body["data"]["status"] != "ok" {
+		t.Errorf("enveloped healthz = %v, want data.status ok", body)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/healthz", nil)
+	req.Header.Set("Accept", "application/json; envelope=false")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got := decodeResponse[map[string]string](t, rec); got["status"] != "ok" {
+		t.Errorf("opted-out healthz = %v, want bare status ok", got)
+	}
+}
+
+// The default config puts handlers behind http.TimeoutHandler, whose writer
+// the envelope must see through. Both settings take the production
+// middleware order from newRouter.
+func TestResponseEnvelopeRequestTimeout(t *testing.T) {
+	for _, timeout := range []time.Duration{0, 30 * time.Second} {
+		t.Run(timeout.String(), func(t *testing.T) {
+			h, _ := newTestRouter(t, func(cfg *apiConfig) {
+				cfg.RequestTimeout = timeout
+			})
+			user := createTestUser(t, h, "alice")
+			createTestNote(t, h, user.ApiKey, "hello")
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/notes?limit=5", nil)
+			req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+			req.Header.Set("Accept", "application/json; envelope=true")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			body := decodeResponse[struct {
+				Data []Note `json:"data"`
+				Meta struct {
+					Pagination pagination `json:"pagination"`
+				} `json:"meta"`
+			}](t, rec)
+			if len(body.Data) != 1 || body.Meta.Pagination != (pagination{Limit: 5}) {
+				t.Errorf("body = %+v, want one note with limit 5 pagination meta", body)
+			}
+		})
+	}
+}
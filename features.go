@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/flags"
+	"github.com/bootdotdev/learn-cicd-starter/internal/lifecycle"
+)
+
+// Feature flags, set with FEATURE_FLAGS and optionally FEATURE_FLAGS_FILE.
+const (
+	flagNotesBatch = "notes_batch"
+	flagNotesPin   = "notes_pin"
+)
+
+// featureDefaults lists every known flag with its state when nothing
+// overrides it.
+var featureDefaults = map[string]bool{
+	flagNotesBatch: true,
+	flagNotesPin:   true,
+}
+
+// featureFlagsFromEnv loads FEATURE_FLAGS and FEATURE_FLAGS_FILE, whose
+// entries win, and logs the result. With a file, lc re-reads both every
+// FEATURE_FLAGS_REFRESH while the server runs. Invalid flags are fatal at
+// startup and only logged on refresh.
+func featureFlagsFromEnv(lc *lifecycle.Manager) *flags.Set {
+	set := flags.New(featureDefaults)
+	path := envString("FEATURE_FLAGS_FILE", "")
+	read := func() (string, error) {
+		if path == "" {
+			return envString("FEATURE_FLAGS", ""), nil
+		}
+		dat, err := os.ReadFile(path)
+		return envString("FEATURE_FLAGS", "") + "," + string(dat), err
+	}
+
+	spec, err := read()
+	if err == nil {
+		err = set.Load(spec)
+	}
+	if err != nil {
+		log.Fatalf("Feature flags: %v", err)
+	}
+	log.Printf("Feature flags: %s", set)
+
+	if path != "" {
+		interval := envDuration("FEATURE_FLAGS_REFRESH", 30*time.Second)
+		ctx, cancel := context.WithCancel(context.Background())
+		lc.Register("feature flags", func(context.Context) error {
+			go set.Watch(ctx, interval, read)
+			return nil
+		}, func(context.Context) error {
+			cancel()
+			return nil
+		})
+	}
+	return set
+}
+
+// requireFeature hides next behind the named flag. While the flag is off
+// the route answers exactly like an unknown one, before authentication, so
+// it isn't discoverable. Without cfg.Flags every route is served.
+func (cfg *apiConfig) requireFeature(name string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if cfg.Flags != nil && !cfg.Flags.IsEnabled(name) {
+			http.NotFound(w, r)
+			return
+		}
+		next(w, r)
+	}
+}
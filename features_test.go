@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/flags"
+)
+
+func TestFeatureFlagsHideEndpoints(t *testing.T) {
+	set := flags.New(featureDefaults)
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.Flags = set
+	})
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "hello")
+	batch := map[string]any{"notes": []map[string]string{{"note": "one"}}}
+
+	tests := []struct {
+		flag   string
+		method string
+		path   string
+		body   any
+		onCode int
+	}{
+		{flagNotesBatch, http.MethodPost, "/v1/notes/batch", batch, http.StatusCreated},
+		{flagNotesPin, http.MethodPost, "/v1/notes/" + note.ID + "/pin", nil, http.StatusOK},
+		{flagNotesPin, http.MethodPost, "/v1/notes/" + note.ID + "/unpin", nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if err := set.Load(tt.flag + "=false"); err != nil {
+			t.Fatal(err)
+		}
+		for _, apiKey := range []string{user.ApiKey, ""} {
+			if rec := doRequest(t, h, tt.method, tt.path, apiKey, tt.body); rec.Code != http.StatusNotFound {
+				t.Errorf("%s %s with %s off: status = %d, want %d", tt.method, tt.path, tt.flag, rec.Code, http.StatusNotFound)
+			}
+		}
+
+		if err := set.Load(""); err != nil {
+			t.Fatal(err)
+		}
+		if rec := doRequest(t, h, tt.method, tt.path, user.ApiKey, tt.body); rec.Code != tt.onCode {
+			t.Errorf("%s %s with %s on: status = %d, want %d", tt.method, tt.path, tt.flag, rec.Code, tt.onCode)
+		}
+	}
+
+	// Ungated routes don't depend on any flag.
+	if err := set.Load("notes_batch=false,notes_pin=false"); err != nil {
+		t.Fatal(err)
+	}
+	if rec := doRequest(t, h, http.MethodGet, "/v1/notes/"+note.ID, user.ApiKey, nil); rec.Code != http.StatusOK {
+		t.Errorf("GET note with every flag off: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// noteFields lists the Note JSON fields clients may select with ?fields=.
+var noteFields = []string{"id", "created_at", "updated_at", "note", "user_id", "public", "metadata", "pinned", "char_count", "word_count"}
+
+// parseFields reads the comma separated fields query parameter, validating
+// each entry against allowed. A nil result means all fields were requested.
+func parseFields(r *http.Request, allowed []string) ([]string, error) {
+	raw, err := queryValue(r.URL.Query(), "fields")
+	if raw == "" || err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if !slices.Contains(allowed, field) {
+			return nil, fmt.Errorf("Invalid fields parameter: unknown field %q", field)
+		}
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// selectFields re-encodes each item keeping only the given JSON fields.
+func selectFields[T any](items []T, fields []string) ([]map[string]json.RawMessage, error) {
+	result := make([]map[string]json.RawMessage, len(items))
+	for i, item := range items {
+		dat, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		all := map[string]json.RawMessage{}
+		if err := json.Unmarshal(dat, &all); err != nil {
+			return nil, err
+		}
+		result[i] = make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			result[i][field] = all[field]
+		}
+	}
+	return result, nil
+}
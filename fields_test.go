@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestNotesListFieldSelection(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "a long body mobile clients skip")
+
+	t.Run("valid subset", func(t *testing.T) {
+		rec := doRequest(t, h, http.MethodGet, "/v1/notes?fields=id,created_at", user.ApiKey, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		notes := decodeResponse[[]map[string]any](t, rec)
+		if len(notes) != 1 {
+			t.Fatalf("got %d notes, want 1", len(notes))
+		}
+		var keys []string
+		for key := range notes[0] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if want := []string{"created_at", "id"}; !reflect.DeepEqual(keys, want) {
+			t.Errorf("fields = %v, want %v", keys, want)
+		}
+		if notes[0]["id"] != note.ID {
+			t.Errorf("id = %v, want %v", notes[0]["id"], note.ID)
+		}
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		rec := doRequest(t, h, http.MethodGet, "/v1/notes?fields=id,api_key", user.ApiKey, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("default returns all fields", func(t *testing.T) {
+		rec := doRequest(t, h, http.MethodGet, "/v1/notes", user.ApiKey, nil)
+		notes := decodeResponse[[]map[string]any](t, rec)
+		if len(notes) != 1 || len(notes[0]) != len(noteFields) {
+			t.Errorf("GET /v1/notes = %v, want one note with %d fields", notes, len(noteFields))
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// handlerActivityGet lists the caller's note activity, newest first: a
+// "created" event per note and an "updated" one for each note changed since.
+// Only a note's latest update is known, so earlier edits don't show up
+// here; GET /v1/notes/{noteID}/history has those.
+func (cfg *apiConfig) handlerActivityGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	page, err := cfg.parsePagination(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	loc, err := parseTimezone(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.dbFor(r).GetActivityForUser(r.Context(), database.GetActivityForUserParams{
+		UserID: user.ID,
+		Limit:  int64(page.Limit),
+		Offset: int64(page.Offset),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get activity", err)
+		return
+	}
+
+	events, err := databaseActivityToActivity(rows, loc)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert activity", err)
+		return
+	}
+	setPaginationHeaders(w, page)
+	respondWithJSON(w, http.StatusOK, events)
+}
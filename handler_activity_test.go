@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/testutil"
+)
+
+func TestActivityGet(t *testing.T) {
+	h, db := newTestRouter(t)
+	alice := createTestUser(t, h, "alice")
+	bob := createTestUser(t, h, "bob")
+	// SeedNotes backdates the note, so the update lands on a later second.
+	noteID := testutil.SeedNotes(t, db, alice.ID, 2)[0]
+	testutil.SeedNotes(t, db, bob.ID, 1)
+
+	rec := doRequest(t, h, http.MethodPatch, "/v1/notes/"+noteID, alice.ApiKey, map[string]string{"note": "edited"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PATCH status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/v1/activity", alice.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	events := decodeResponse[[]ActivityEvent](t, rec)
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %+v", len(events), events)
+	}
+	if events[0].Type != "updated" || events[0].NoteID != noteID {
+		t.Errorf("newest event = %+v, want the update of %s", events[0], noteID)
+	}
+	if events[2].Type != "created" || events[2].NoteID != noteID {
+		t.Errorf("oldest event = %+v, want the creation of %s", events[2], noteID)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].Timestamp.After(events[i-1].Timestamp) {
+			t.Errorf("events not newest first: %+v", events)
+		}
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/v1/activity?limit=1&offset=2&tz=Asia/Tokyo", alice.ApiKey, nil)
+	page := decodeResponse[[]ActivityEvent](t, rec)
+	if len(page) != 1 || page[0].Type != "created" || page[0].NoteID != noteID {
+		t.Fatalf("limit=1&offset=2 = %+v, want the creation of %s", page, noteID)
+	}
+	if _, offset := page[0].Timestamp.Zone(); offset != 9*int(time.Hour/time.Second) {
+		t.Errorf("timestamp %s not rendered in tz", page[0].Timestamp)
+	}
+	if got := rec.Header().Get("X-Pagination-Offset"); got != "2" {
+		t.Errorf("X-Pagination-Offset = %q, want %q", got, "2")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// handlerAdminVacuum runs VACUUM and ANALYZE through cfg.Maintenance. Without
+// one, as over libSQL, it does nothing and answers 501.
+func (cfg *apiConfig) handlerAdminVacuum(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Vacuumed   bool  `json:"vacuumed"`
+		SizeBefore int64 `json:"size_before"`
+		SizeAfter  int64 `json:"size_after"`
+		DurationMS int64 `json:"duration_ms"`
+	}
+
+	if cfg.Maintenance == nil {
+		respondWithError(w, http.StatusNotImplemented, "Vacuum is only supported on a local SQLite file", nil)
+		return
+	}
+	if !cfg.maintenanceMu.TryLock() {
+		respondWithError(w, http.StatusConflict, "Maintenance already running", nil)
+		return
+	}
+	defer cfg.maintenanceMu.Unlock()
+
+	start := time.Now()
+	stats, err := cfg.Maintenance.Vacuum(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't vacuum database", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response{
+		Vacuumed:   true,
+		SizeBefore: stats.SizeBefore,
+		SizeAfter:  stats.SizeAfter,
+		DurationMS: time.Since(start).Milliseconds(),
+	})
+}
+
+// adminUser is a User without its API key, which support staff never need.
+type adminUser struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+}
+
+// handlerAdminUsersSearch lists users whose name starts with the prefix query
+// parameter, ignoring ASCII case. An empty prefix lists everyone.
+func (cfg *apiConfig) handlerAdminUsersSearch(w http.ResponseWriter, r *http.Request) {
+	page, err := cfg.parsePagination(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	users, err := cfg.dbFor(r).SearchUsersByNamePrefix(r.Context(), database.SearchUsersByNamePrefixParams{
+		Pattern: likeEscaper.Replace(r.URL.Query().Get("prefix")) + "%",
+		Limit:   int64(page.Limit),
+		Offset:  int64(page.Offset),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't search users", err)
+		return
+	}
+
+	resp := make([]adminUser, len(users))
+	for i, dbUser := range users {
+		user, err := databaseUserToUser(dbUser)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't convert user", err)
+			return
+		}
+		resp[i] = adminUser{
+			ID:        user.ID,
+			CreatedAt: user.CreatedAt,
+			UpdatedAt: user.UpdatedAt,
+			Name:      user.Name,
+		}
+	}
+	setPaginationHeaders(w, page)
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// handlerAdminAuthFailures lists recent failed authentications, newest first.
+// They are kept in memory only, so the list starts empty after a restart.
+func (cfg *apiConfig) handlerAdminAuthFailures(w http.ResponseWriter, r *http.Request) {
+	failures := []authFailure{}
+	if cfg.AuthFailures != nil {
+		failures = cfg.AuthFailures.Recent()
+	}
+	respondWithJSON(w, http.StatusOK, failures)
+}
+
+// handlerAdminDBStats reports the primary database's connection pool, as
+// returned by sql.DB.Stats, for tuning its limits.
+func (cfg *apiConfig) handlerAdminDBStats(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		MaxOpenConnections int   `json:"max_open_connections"`
+		OpenConnections    int   `json:"open_connections"`
+		InUse              int   `json:"in_use"`
+		Idle               int   `json:"idle"`
+		WaitCount          int64 `json:"wait_count"`
+		WaitDurationMS     int64 `json:"wait_duration_ms"`
+		MaxIdleClosed      int64 `json:"max_idle_closed"`
+		MaxIdleTimeClosed  int64 `json:"max_idle_time_closed"`
+		MaxLifetimeClosed  int64 `json:"max_lifetime_closed"`
+	}
+
+	stats := cfg.DBStats()
+	respondWithJSON(w, http.StatusOK, response{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMS:     stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	})
+}
+
+// handlerAdminConfig lists the environment variables the server resolved at
+// startup with their effective values, for spotting misconfiguration. Keys,
+// tokens, secrets, passwords and URLs are masked.
+func handlerAdminConfig(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, settings.redacted())
+}
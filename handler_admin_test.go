@@ -0,0 +1,419 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/nonce"
+	"github.com/bootdotdev/learn-cicd-starter/internal/ringbuf"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+const testAdminKey = "admin-secret"
+
+// fakeMaintainer stands in for a file backed SQLite store.
+type fakeMaintainer struct {
+	calls int
+}
+
+func (m *fakeMaintainer) Vacuum(ctx context.Context) (store.VacuumStats, error) {
+	m.calls++
+	return store.VacuumStats{SizeBefore: 8192, SizeAfter: 4096}, nil
+}
+
+func TestAdminVacuum(t *testing.T) {
+	maintainer := &fakeMaintainer{}
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.AdminAPIKey = testAdminKey
+		cfg.Maintenance = maintainer
+	})
+	user := createTestUser(t, h, "alice")
+
+	for _, tt := range []struct {
+		name     string
+		apiKey   string
+		wantCode int
+	}{
+		{"no key", "", http.StatusUnauthorized},
+		{"user key", user.ApiKey, http.StatusForbidden},
+	} {
+		rec := doRequest(t, h, http.MethodPost, "/admin/maintenance/vacuum", tt.apiKey, nil)
+		if rec.Code != tt.wantCode {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantCode)
+		}
+	}
+	if maintainer.calls != 0 {
+		t.Fatalf("Vacuum ran %d times without admin access", maintainer.calls)
+	}
+
+	rec := doRequest(t, h, http.MethodPost, "/admin/maintenance/vacuum", testAdminKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	got := decodeResponse[struct {
+		Vacuumed   bool  `json:"vacuumed"`
+		SizeBefore int64 `json:"size_before"`
+		SizeAfter  int64 `json:"size_after"`
+	}](t, rec)
+	if !got.Vacuumed || got.SizeBefore != 8192 || got.SizeAfter != 4096 || maintainer.calls != 1 {
+		t.Errorf("response = %+v after %d calls, want one vacuum from 8192 to 4096 bytes", got, maintainer.calls)
+	}
+}
+
+func TestAdminVacuumNonFileBackend(t *testing.T) {
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.AdminAPIKey = testAdminKey
+	})
+
+	rec := doRequest(t, h, http.MethodPost, "/admin/maintenance/vacuum", testAdminKey, nil)
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotImplemented)
+	}
+}
+
+func TestAdminRoutesDisabledWithoutKey(t *testing.T) {
+	h, _ := newTestRouter(t)
+
+	rec := doRequest(t, h, http.MethodPost, "/admin/maintenance/vacuum", "", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestAdminUsersSearch(t *testing.T) {
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.AdminAPIKey = testAdminKey
+	})
+	user := createTestUser(t, h, "alice")
+	for _, name := range []string{"Alicia", "al_bert", "alxbert", "bob", "50% off", "50 cents"} {
+		createTestUser(t, h, name)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"?prefix=al", []string{"Alicia", "al_bert", "alice", "alxbert"}},
+		{"?prefix=al_", []string{"al_bert"}},
+		{"?prefix=50%25", []string{"50% off"}},
+		{"?prefix=zed", []string{}},
+		{"?prefix=al&limit=2&offset=1", []string{"al_bert", "alice"}},
+	}
+	for _, tt := range tests {
+		rec := doRequest(t, h, http.MethodGet, "/admin/users"+tt.query, testAdminKey, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", tt.query, rec.Code, http.StatusOK)
+		}
+		if strings.Contains(rec.Body.String(), user.ApiKey) || strings.Contains(rec.Body.String(), "api_key") {
+			t.Fatalf("%s: response leaks API keys: %s", tt.query, rec.Body)
+		}
+		var names []string
+		for _, u := range decodeResponse[[]adminUser](t, rec) {
+			names = append(names, u.Name)
+		}
+		if !slices.Equal(names, tt.want) {
+			t.Errorf("%s: names = %q, want %q", tt.query, names, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		name     string
+		apiKey   string
+		wantCode int
+	}{
+		{"no key", "", http.StatusUnauthorized},
+		{"user key", user.ApiKey, http.StatusForbidden},
+	} {
+		rec := doRequest(t, h, http.MethodGet, "/admin/users?prefix=al", tt.apiKey, nil)
+		if rec.Code != tt.wantCode {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantCode)
+		}
+	}
+}
+
+func TestAdminAuthFailures(t *testing.T) {
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.AdminAPIKey = testAdminKey
+		cfg.AuthFailures = ringbuf.New[authFailure](2)
+	})
+	user := createTestUser(t, h, "alice")
+
+	const staleKey = "stale-key-0123456789"
+	doRequest(t, h, http.MethodGet, "/v1/users", "", nil)
+	doRequest(t, h, http.MethodGet, "/v1/users", staleKey, nil)
+	doRequest(t, h, http.MethodGet, "/v1/users", user.ApiKey, nil)
+	doRequest(t, h, http.MethodGet, "/v1/users", staleKey, nil)
+
+	rec := doRequest(t, h, http.MethodGet, "/admin/auth/failures", testAdminKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if strings.Contains(rec.Body.String(), staleKey) {
+		t.Fatalf("response leaks the full key: %s", rec.Body)
+	}
+	failures := decodeResponse[[]authFailure](t, rec)
+	if len(failures) != 2 {
+		t.Fatalf("got %d failures, want 2 (the buffer size): %+v", len(failures), failures)
+	}
+	for _, f := range failures {
+		if f.Reason != authOutcomeUnknownKey || f.KeyPrefix != staleKey[:authFailureKeyPrefix] {
+			t.Errorf("failure = %+v, want unknown key with prefix %q", f, staleKey[:authFailureKeyPrefix])
+		}
+		if f.IP == "" || f.Timestamp.IsZero() {
+			t.Errorf("failure = %+v, want ip and timestamp set", f)
+		}
+	}
+	if failures[0].Timestamp.Before(failures[1].Timestamp) {
+		t.Errorf("failures not newest first: %+v", failures)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		apiKey   string
+		wantCode int
+	}{
+		{"no key", "", http.StatusUnauthorized},
+		{"user key", user.ApiKey, http.StatusForbidden},
+	} {
+		rec := doRequest(t, h, http.MethodGet, "/admin/auth/failures", tt.apiKey, nil)
+		if rec.Code != tt.wantCode {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantCode)
+		}
+	}
+}
+
+func TestAdminAuthFailuresRecordsMissingHeader(t *testing.T) {
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.AdminAPIKey = testAdminKey
+		cfg.AuthFailures = ringbuf.New[authFailure](10)
+	})
+
+	doRequest(t, h, http.MethodGet, "/v1/users", "", nil)
+
+	rec := doRequest(t, h, http.MethodGet, "/admin/auth/failures", testAdminKey, nil)
+	failures := decodeResponse[[]authFailure](t, rec)
+	if len(failures) != 1 || failures[0].Reason != authOutcomeMissingHeader || failures[0].KeyPrefix != "" {
+		t.Errorf("failures = %+v, want one missing header failure without a key prefix", failures)
+	}
+}
+
+func TestAdminDBStats(t *testing.T) {
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.AdminAPIKey = testAdminKey
+		cfg.DBStats = func() sql.DBStats {
+			return sql.DBStats{OpenConnections: 3, InUse: 1, Idle: 2, WaitCount: 7, WaitDuration: 1500 * time.Millisecond}
+		}
+	})
+	user := createTestUser(t, h, "alice")
+
+	rec := doRequest(t, h, http.MethodGet, "/admin/db/stats", testAdminKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	got := decodeResponse[map[string]float64](t, rec)
+	want := map[string]float64{
+		"max_open_connections": 0,
+		"open_connections":     3,
+		"in_use":               1,
+		"idle":                 2,
+		"wait_count":           7,
+		"wait_duration_ms":     1500,
+		"max_idle_closed":      0,
+		"max_idle_time_closed": 0,
+		"max_lifetime_closed":  0,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("stats = %v, want %v", got, want)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		apiKey   string
+		wantCode int
+	}{
+		{"no key", "", http.StatusUnauthorized},
+		{"user key", user.ApiKey, http.StatusForbidden},
+	} {
+		rec := doRequest(t, h, http.MethodGet, "/admin/db/stats", tt.apiKey, nil)
+		if rec.Code != tt.wantCode {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantCode)
+		}
+	}
+}
+
+func TestAdminDBStatsWithoutPool(t *testing.T) {
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.AdminAPIKey = testAdminKey
+	})
+
+	rec := doRequest(t, h, http.MethodGet, "/admin/db/stats", testAdminKey, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestAdminImpersonate(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	const ttl = 200 * time.Millisecond
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.AdminAPIKey = testAdminKey
+		cfg.Impersonations = nonce.New(ttl)
+	})
+	alice := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, alice.ApiKey, "alice's note")
+	bob := createTestUser(t, h, "bob")
+
+	if rec := doRequest(t, h, http.MethodPost, "/admin/impersonate/"+alice.ID, bob.ApiKey, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("user key: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if rec := doRequest(t, h, http.MethodPost, "/admin/impersonate/"+note.ID, testAdminKey, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown user: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	rec := doRequest(t, h, http.MethodPost, "/admin/impersonate/"+alice.ID, testAdminKey, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	grant := decodeResponse[struct {
+		Token     string    `json:"token"`
+		UserID    string    `json:"user_id"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}](t, rec)
+	if !strings.HasPrefix(grant.Token, impersonationTokenPrefix) || grant.UserID != alice.ID {
+		t.Fatalf("grant = %+v, want an %s token for alice", grant, impersonationTokenPrefix)
+	}
+	if until := time.Until(grant.ExpiresAt); until > ttl+time.Second {
+		t.Errorf("token expires in %s, want about %s", until, ttl)
+	}
+
+	// The token authenticates as alice, for reads only.
+	rec = doRequest(t, h, http.MethodGet, "/v1/users", grant.Token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /v1/users status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := decodeResponse[User](t, rec); got.ID != alice.ID {
+		t.Errorf("authenticated as %s, want alice %s", got.ID, alice.ID)
+	}
+	rec = doRequest(t, h, http.MethodGet, "/v1/notes/"+note.ID, grant.Token, nil)
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET alice's note status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	rec = doRequest(t, h, http.MethodPost, "/v1/notes", grant.Token, map[string]string{"note": "not alice"})
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("POST /v1/notes status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	out := logs.String()
+	if strings.Contains(out, grant.Token) {
+		t.Errorf("logs contain the whole token:\n%s", out)
+	}
+	for _, want := range []string{
+		"Impersonation: admin at",
+		"Impersonation: " + tokenLogID(grant.Token) + " used for GET /v1/users as user " + alice.ID,
+		"Impersonation: " + tokenLogID(grant.Token) + " used for POST /v1/notes as user " + alice.ID,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("logs missing %q:\n%s", want, out)
+		}
+	}
+
+	time.Sleep(time.Until(grant.ExpiresAt) + 10*time.Millisecond)
+	if rec := doRequest(t, h, http.MethodGet, "/v1/users", grant.Token, nil); rec.Code == http.StatusOK {
+		t.Errorf("expired token: status = %d, want it rejected", rec.Code)
+	}
+}
+
+func TestAdminConfig(t *testing.T) {
+	t.Setenv("DATABASE_URL", "libsql://db.example.turso.io?authToken=hunter2")
+	t.Setenv("ADMIN_API_KEY", testAdminKey)
+	t.Setenv("RATE_LIMIT", "50")
+	t.Setenv("DATABASE_READ_URL", "")
+	envString("DATABASE_URL", "")
+	envString("ADMIN_API_KEY", "")
+	envString("DATABASE_READ_URL", "")
+	envPositiveInt("RATE_LIMIT", 0)
+	envDuration("RATE_LIMIT_WINDOW", time.Minute)
+
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.AdminAPIKey = testAdminKey
+	})
+	rec := doRequest(t, h, http.MethodGet, "/admin/config", testAdminKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if body := rec.Body.String(); strings.Contains(body, "hunter2") || strings.Contains(body, testAdminKey) {
+		t.Fatalf("response leaks a secret: %s", body)
+	}
+
+	got := make(map[string]setting)
+	for _, s := range decodeResponse[[]setting](t, rec) {
+		got[s.Name] = s
+	}
+	want := map[string]setting{
+		"DATABASE_URL":      {Name: "DATABASE_URL", Value: redactedValue},
+		"ADMIN_API_KEY":     {Name: "ADMIN_API_KEY", Value: redactedValue},
+		"DATABASE_READ_URL": {Name: "DATABASE_READ_URL", Value: "", Default: true},
+		"RATE_LIMIT":        {Name: "RATE_LIMIT", Value: "50"},
+		"RATE_LIMIT_WINDOW": {Name: "RATE_LIMIT_WINDOW", Value: "1m0s", Default: true},
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s = %+v, want %+v", name, got[name], w)
+		}
+	}
+
+	if rec := doRequest(t, h, http.MethodGet, "/admin/config", "not-the-admin-key", nil); rec.Code != http.StatusForbidden {
+		t.Errorf("non-admin status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestAdminKeyDenylist(t *testing.T) {
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.AdminAPIKey = testAdminKey
+		cfg.KeyDenylist, _ = newKeyDenylist(nil)
+	})
+	user := createTestUser(t, h, "alice")
+	hash := hashAPIKey(user.ApiKey)
+
+	rec := doRequest(t, h, http.MethodPut, "/admin/keys/denylist", testAdminKey, map[string][]string{"hashes": {hash}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	type response struct {
+		Hashes []string `json:"hashes"`
+	}
+	if got := decodeResponse[response](t, rec).Hashes; !slices.Equal(got, []string{hash}) {
+		t.Errorf("PUT hashes = %v, want [%s]", got, hash)
+	}
+	if rec := doRequest(t, h, http.MethodGet, "/v1/users", user.ApiKey, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("denied key status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	rec = doRequest(t, h, http.MethodPut, "/admin/keys/denylist", testAdminKey, map[string][]string{"hashes": {user.ApiKey}})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("raw key PUT status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if strings.Contains(rec.Body.String(), user.ApiKey) {
+		t.Errorf("error echoes the raw key: %s", rec.Body)
+	}
+	if got := decodeResponse[response](t, doRequest(t, h, http.MethodGet, "/admin/keys/denylist", testAdminKey, nil)).Hashes; len(got) != 1 {
+		t.Errorf("a rejected PUT changed the list to %v", got)
+	}
+
+	doRequest(t, h, http.MethodPut, "/admin/keys/denylist", testAdminKey, map[string][]string{"hashes": {}})
+	if rec := doRequest(t, h, http.MethodGet, "/v1/users", user.ApiKey, nil); rec.Code != http.StatusOK {
+		t.Errorf("key after clearing the denylist status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+)
+
+// handlerAuthVerify is a side-effect free probe of whether the presented
+// API key is valid. Unlike the other endpoints, an unknown key is a 401. A
+// failed lookup says nothing about the key, so it is a 500, or a 503 or 504
+// when the store is busy or slow, for the client to retry.
+func (cfg *apiConfig) handlerAuthVerify(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	user, _, err := cfg.authenticate(r)
+	if errors.Is(err, errUserLookup) && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	if errors.Is(err, errUserLookup) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid api key", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find api key", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		ID:   user.ID,
+		Name: user.Name,
+	})
+}
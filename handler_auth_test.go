@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+// failingGetUserStore fails every API key lookup with err once it is set.
+type failingGetUserStore struct {
+	store.Store
+	err error
+}
+
+func (s *failingGetUserStore) GetUser(ctx context.Context, apiKey string) (database.User, error) {
+	if s.err != nil {
+		return database.User{}, s.err
+	}
+	return s.Store.GetUser(ctx, apiKey)
+}
+
+func TestAuthVerify(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/auth/verify", user.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("verify valid key status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	got := decodeResponse[map[string]string](t, rec)
+	if got["id"] != user.ID || got["name"] != "alice" {
+		t.Errorf("verify valid key = %v, want id %s and name alice", got, user.ID)
+	}
+	if _, ok := got["api_key"]; ok {
+		t.Error("verify response includes api_key")
+	}
+
+	for name, apiKey := range map[string]string{
+		"unknown key": "not-a-real-key",
+		"missing key": "",
+	} {
+		rec := doRequest(t, h, http.MethodGet, "/v1/auth/verify", apiKey, nil)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("verify %s status = %d, want %d", name, rec.Code, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestAuthVerifyLookupError(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"failure", errors.New("disk I/O error"), http.StatusInternalServerError},
+		{"busy", store.ErrBusy, http.StatusServiceUnavailable},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			failing := &failingGetUserStore{}
+			h, _ := newTestRouter(t, func(cfg *apiConfig) {
+				failing.Store = cfg.DB
+				cfg.DB = failing
+			})
+			user := createTestUser(t, h, "alice")
+			failing.err = tt.err
+
+			rec := doRequest(t, h, http.MethodGet, "/v1/auth/verify", user.ApiKey, nil)
+			if rec.Code != tt.want {
+				t.Errorf("verify with a failed lookup status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
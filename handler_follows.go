@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+type followResponse struct {
+	UserID    string `json:"user_id"`
+	Following bool   `json:"following"`
+}
+
+func (cfg *apiConfig) handlerFollowCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	followeeID, ok := parseUUIDParam(w, r, "userID")
+	if !ok {
+		return
+	}
+	if followeeID == user.ID {
+		respondWithError(w, http.StatusBadRequest, "Couldn't follow yourself", nil)
+		return
+	}
+
+	_, err := cfg.dbFor(r).GetUserByID(r.Context(), followeeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get user", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	_, err = cfg.dbFor(r).CreateFollow(r.Context(), database.CreateFollowParams{
+		FollowerID: user.ID,
+		FolloweeID: followeeID,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't follow user", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, followResponse{UserID: followeeID, Following: true})
+}
+
+// handlerFollowDelete is idempotent: unfollowing a user who isn't followed
+// succeeds.
+func (cfg *apiConfig) handlerFollowDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	followeeID, ok := parseUUIDParam(w, r, "userID")
+	if !ok {
+		return
+	}
+
+	_, err := cfg.dbFor(r).DeleteFollow(r.Context(), database.DeleteFollowParams{
+		FollowerID: user.ID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unfollow user", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, followResponse{UserID: followeeID, Following: false})
+}
+
+// handlerFeedGet lists public notes from the users the caller follows,
+// newest first. Private notes never appear here, even the caller's own.
+func (cfg *apiConfig) handlerFeedGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	page, err := cfg.parsePagination(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	loc, err := parseTimezone(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	notes, err := cfg.dbFor(r).GetFeedForUser(r.Context(), database.GetFeedForUserParams{
+		FollowerID: user.ID,
+		Limit:      int64(page.Limit),
+		Offset:     int64(page.Offset),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get feed", err)
+		return
+	}
+
+	notesResp, err := databasePostsToPosts(notes)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert notes", err)
+		return
+	}
+	setPaginationHeaders(w, page)
+	respondWithJSON(w, http.StatusOK, notesIn(notesResp, loc))
+}
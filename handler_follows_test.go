@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func createTestNoteWithVisibility(t *testing.T, h http.Handler, apiKey, body string, public bool) Note {
+	t.Helper()
+	rec := doRequest(t, h, http.MethodPost, "/v1/notes", apiKey, map[string]any{"note": body, "public": public})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /v1/notes status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	return decodeResponse[Note](t, rec)
+}
+
+func getFeed(t *testing.T, h http.Handler, apiKey string) []Note {
+	t.Helper()
+	rec := doRequest(t, h, http.MethodGet, "/v1/feed", apiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /v1/feed status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	return decodeResponse[[]Note](t, rec)
+}
+
+func TestFeedVisibility(t *testing.T) {
+	h, _ := newTestRouter(t)
+	alice := createTestUser(t, h, "alice")
+	bob := createTestUser(t, h, "bob")
+	carol := createTestUser(t, h, "carol")
+
+	bobPublic := createTestNoteWithVisibility(t, h, bob.ApiKey, "bob public", true)
+	createTestNoteWithVisibility(t, h, bob.ApiKey, "bob private", false)
+	createTestNote(t, h, bob.ApiKey, "bob default")
+	createTestNoteWithVisibility(t, h, carol.ApiKey, "carol public", true)
+	createTestNoteWithVisibility(t, h, alice.ApiKey, "alice public", true)
+
+	if feed := getFeed(t, h, alice.ApiKey); len(feed) != 0 {
+		t.Fatalf("feed before following = %+v, want empty", feed)
+	}
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/users/"+bob.ID+"/follow", alice.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("follow status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	feed := getFeed(t, h, alice.ApiKey)
+	if len(feed) != 1 || feed[0].ID != bobPublic.ID || !feed[0].Public {
+		t.Errorf("feed = %+v, want only bob's public note", feed)
+	}
+	if feed := getFeed(t, h, carol.ApiKey); len(feed) != 0 {
+		t.Errorf("carol's feed = %+v, want empty", feed)
+	}
+
+	rec = doRequest(t, h, http.MethodDelete, "/v1/users/"+bob.ID+"/follow", alice.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unfollow status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if feed := getFeed(t, h, alice.ApiKey); len(feed) != 0 {
+		t.Errorf("feed after unfollowing = %+v, want empty", feed)
+	}
+}
+
+func TestFeedPagination(t *testing.T) {
+	h, _ := newTestRouter(t)
+	alice := createTestUser(t, h, "alice")
+	bob := createTestUser(t, h, "bob")
+	for _, body := range []string{"one", "two", "three"} {
+		createTestNoteWithVisibility(t, h, bob.ApiKey, body, true)
+	}
+	doRequest(t, h, http.MethodPost, "/v1/users/"+bob.ID+"/follow", alice.ApiKey, nil)
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/feed?limit=2&offset=1", alice.ApiKey, nil)
+	feed := decodeResponse[[]Note](t, rec)
+	if len(feed) != 2 || feed[0].Note != "two" || feed[1].Note != "one" {
+		t.Errorf("feed page = %+v, want two then one", feed)
+	}
+}
+
+func TestFollowErrors(t *testing.T) {
+	h, _ := newTestRouter(t)
+	alice := createTestUser(t, h, "alice")
+
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+	}{
+		{"self", "/v1/users/" + alice.ID + "/follow", http.StatusBadRequest},
+		{"unknown user", "/v1/users/00000000-0000-0000-0000-000000000000/follow", http.StatusNotFound},
+		{"malformed id", "/v1/users/not-a-uuid/follow", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := doRequest(t, h, http.MethodPost, tt.path, alice.ApiKey, nil)
+		if rec.Code != tt.wantCode {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantCode)
+		}
+	}
+}
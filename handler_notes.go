@@ -1,60 +1,661 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"strconv"
 	"time"
 
-	"github.com/bootdotdev/learn-cicd-starter/internal/database"
 	"github.com/google/uuid"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/jsonpatch"
+	"github.com/bootdotdev/learn-cicd-starter/internal/markdown"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
 )
 
+var errNoteNotFound = errors.New("note not found")
+
+// getOwnedNote fetches the caller's note noteID. A missing note and someone
+// else's are both errNoteNotFound, so the two can't be told apart; any
+// other error is returned as it is, for respondWithError to map.
+func getOwnedNote(ctx context.Context, db store.Store, noteID, userID string) (database.Note, error) {
+	note, err := db.GetNote(ctx, noteID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && note.UserID != userID) {
+		return database.Note{}, errNoteNotFound
+	}
+	return note, err
+}
+
 func (cfg *apiConfig) handlerNotesGet(w http.ResponseWriter, r *http.Request, user database.User) {
-	posts, err := cfg.DB.GetNotesForUser(r.Context(), user.ID)
+	fields, err := parseFields(r, noteFields)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	page, err := cfg.parsePagination(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	created, err := parseDateRange(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	loc, err := parseTimezone(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	var hasTags sql.NullBool
+	v, err := queryValue(r.URL.Query(), "hasTags")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("hasTags must be true or false, got %q", v), err)
+			return
+		}
+		hasTags = sql.NullBool{Bool: b, Valid: true}
+	}
+
+	wait, err := cfg.parseWait(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if _, err := queryValue(r.URL.Query(), "format"); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	// A CSV export is the whole filtered list, in fixed columns.
+	csvOut := wantsCSV(r)
+	if q := r.URL.Query(); csvOut && (q.Has("limit") || q.Has("offset") || fields != nil) {
+		respondWithError(w, http.StatusBadRequest, "limit, offset and fields don't apply to CSV", nil)
+		return
+	}
+
+	// A long poll answers 304 if no note turns up in time.
+	var woken bool
+	if wait > 0 {
+		woken, err = cfg.waitForNewNote(r, user, wait)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get posts for user", err)
+			return
+		}
+		if !woken {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	// Creating or editing a note bumps its updated_at, so the newest one
+	// dates the list. Tagging doesn't, so hasTags lists are never dated.
+	if !hasTags.Valid {
+		lastModified, err := cfg.lastNoteUpdate(r, user)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get posts for user", err)
+			return
+		}
+		// A note that woke a long poll may share If-Modified-Since's
+		// second, so the list is sent regardless.
+		if woken {
+			setLastModified(w, lastModified)
+		} else if notModified(w, r, lastModified) {
+			return
+		}
+	}
+
+	params := database.GetNotesForUserParams{
+		UserID:        user.ID,
+		CreatedAfter:  created.After,
+		CreatedBefore: created.Before,
+		HasTags:       hasTags,
+		Limit:         int64(page.Limit),
+		Offset:        int64(page.Offset),
+	}
+	if csvOut {
+		cfg.streamNotesCSV(w, r, params, loc)
+		return
+	}
+	posts, err := cfg.dbFor(r).GetNotesForUser(r.Context(), params)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't get posts for user", err)
 		return
 	}
+	total, err := cfg.dbFor(r).CountNotesForUserFiltered(r.Context(), database.CountNotesForUserFilteredParams{
+		UserID:        user.ID,
+		CreatedAfter:  created.After,
+		CreatedBefore: created.Before,
+		HasTags:       hasTags,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count notes", err)
+		return
+	}
 
 	postsResp, err := databasePostsToPosts(posts)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't convert posts", err)
 		return
 	}
+	postsResp = notesIn(postsResp, loc)
 
+	setPaginationHeaders(w, page)
+	setLinkHeader(w, r, page, total)
+	if fields != nil {
+		selected, err := selectFields(postsResp, fields)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't select fields", err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, selected)
+		return
+	}
 	respondWithJSON(w, http.StatusOK, postsResp)
 }
 
+// lastNoteUpdate returns when the user's notes last changed, or the zero
+// time if they have none.
+func (cfg *apiConfig) lastNoteUpdate(r *http.Request, user database.User) (time.Time, error) {
+	lastUpdated, err := cfg.dbFor(r).GetLastNoteUpdateForUser(r.Context(), user.ID)
+	if err != nil || lastUpdated == "" {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, lastUpdated)
+}
+
+func (cfg *apiConfig) handlerNoteGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	type response struct {
+		Note
+		HTML string `json:"html,omitempty"`
+	}
+
+	render, err := queryValue(r.URL.Query(), "render")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if render != "" && render != "html" {
+		respondWithError(w, http.StatusBadRequest, "render must be html", nil)
+		return
+	}
+	loc, err := parseTimezone(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	noteID, ok := parseUUIDParam(w, r, "noteID")
+	if !ok {
+		return
+	}
+
+	note, err := getOwnedNote(r.Context(), cfg.dbFor(r), noteID, user.ID)
+	if errors.Is(err, errNoteNotFound) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get note", err)
+		return
+	}
+	if wantsNoteRange(r) {
+		serveNoteRange(w, r, note)
+		return
+	}
+
+	noteResp, err := databaseNoteToNote(note)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
+		return
+	}
+
+	resp := response{Note: noteResp.In(loc)}
+	if render == "html" {
+		resp.HTML = markdown.ToHTML(note.Note)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
 func (cfg *apiConfig) handlerNotesCreate(w http.ResponseWriter, r *http.Request, user database.User) {
 	type parameters struct {
-		Note string `json:"note"`
+		Note     string          `json:"note" validate:"required,notblank,max=10000"`
+		Public   bool            `json:"public"`
+		Metadata json.RawMessage `json:"metadata"`
 	}
-	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
-	err := decoder.Decode(&params)
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if !validateParams(w, params) {
+		return
+	}
+	metadata := emptyNoteMetadata
+	if params.Metadata != nil {
+		metadata, err = parseNoteMetadata(params.Metadata)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+	}
+
+	id, err := cfg.newID()
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't generate note id", err)
 		return
 	}
 
-	id := uuid.New().String()
-	err = cfg.DB.CreateNote(r.Context(), database.CreateNoteParams{
+	create := database.CreateNoteParams{
 		ID:        id,
 		CreatedAt: time.Now().UTC().Format(time.RFC3339),
 		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
 		Note:      params.Note,
 		UserID:    user.ID,
-	})
+		Public:    params.Public,
+		Metadata:  metadata,
+	}
+	if cfg.NoteWrites != nil {
+		cfg.acceptNote(w, create)
+		return
+	}
+
+	err = cfg.dbFor(r).CreateNote(r.Context(), create)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create note", err)
 		return
 	}
 
-	note, err := cfg.DB.GetNote(r.Context(), id)
+	note, err := cfg.dbFor(r).GetNote(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+	cfg.NoteEvents.Publish(note)
+
+	noteResp, err := databaseNoteToNote(note)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
+		return
+	}
+
+	respondCreated(w, "/v1/notes/"+noteResp.ID, noteResp)
+}
+
+// handlerNotesDuplicate copies the body, metadata and tags of an owned note
+// into a new note. The copy starts out private and unpinned, whatever the
+// source's state.
+func (cfg *apiConfig) handlerNotesDuplicate(w http.ResponseWriter, r *http.Request, user database.User) {
+	noteID, ok := parseUUIDParam(w, r, "noteID")
+	if !ok {
+		return
+	}
+
+	id, err := cfg.newID()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't generate note id", err)
+		return
+	}
+
+	var note database.Note
+	err = cfg.dbFor(r).InTx(r.Context(), func(tx store.Store) error {
+		source, err := getOwnedNote(r.Context(), tx, noteID, user.ID)
+		if err != nil {
+			return err
+		}
+		tags, err := tx.GetTagsForNote(r.Context(), source.ID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC().Format(time.RFC3339)
+		err = tx.CreateNote(r.Context(), database.CreateNoteParams{
+			ID:        id,
+			CreatedAt: now,
+			UpdatedAt: now,
+			Note:      source.Note,
+			UserID:    user.ID,
+			Metadata:  source.Metadata,
+		})
+		if err != nil {
+			return err
+		}
+		for _, tag := range tags {
+			_, err = tx.AddNoteTag(r.Context(), database.AddNoteTagParams{NoteID: id, Tag: tag})
+			if err != nil {
+				return err
+			}
+		}
+
+		note, err = tx.GetNote(r.Context(), id)
+		return err
+	})
+	if errors.Is(err, errNoteNotFound) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't duplicate note", err)
+		return
+	}
+	cfg.NoteEvents.Publish(note)
+
+	noteResp, err := databaseNoteToNote(note)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
+		return
+	}
+
+	respondCreated(w, "/v1/notes/"+noteResp.ID, noteResp)
+}
+
+// handlerNoteSetPinned returns the owner-only handler behind the pin and
+// unpin endpoints. Pinned notes list ahead of the rest in GET /v1/notes.
+func (cfg *apiConfig) handlerNoteSetPinned(pinned bool) authedHandler {
+	return func(w http.ResponseWriter, r *http.Request, user database.User) {
+		noteID, ok := parseUUIDParam(w, r, "noteID")
+		if !ok {
+			return
+		}
+
+		var note database.Note
+		err := cfg.dbFor(r).InTx(r.Context(), func(tx store.Store) error {
+			var err error
+			note, err = getOwnedNote(r.Context(), tx, noteID, user.ID)
+			if err != nil {
+				return err
+			}
+
+			err = tx.SetNotePinned(r.Context(), database.SetNotePinnedParams{
+				IsPinned:  pinned,
+				UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+				ID:        note.ID,
+			})
+			if err != nil {
+				return err
+			}
+
+			note, err = tx.GetNote(r.Context(), note.ID)
+			return err
+		})
+		if errors.Is(err, errNoteNotFound) {
+			respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't update note", err)
+			return
+		}
+
+		noteResp, err := databaseNoteToNote(note)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, noteResp)
+	}
+}
+
+// RecentNotes defaults to defaultRecentNotes and is capped at maxRecentNotes
+// so the widget endpoint stays cheap.
+const (
+	defaultRecentNotes = 5
+	maxRecentNotes     = 20
+)
+
+// handlerNotesRecent returns the caller's newest notes with just enough
+// fields for a glance. Pinned notes get no priority here, unlike the first
+// page of GET /v1/notes: the widget shows what was written last.
+func (cfg *apiConfig) handlerNotesRecent(w http.ResponseWriter, r *http.Request, user database.User) {
+	type recentNote struct {
+		ID        string `json:"id"`
+		CreatedAt string `json:"created_at"`
+		Note      string `json:"note"`
+	}
+
+	loc, err := parseTimezone(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	notes, err := cfg.dbFor(r).GetRecentNotesForUser(r.Context(), database.GetRecentNotesForUserParams{
+		UserID: user.ID,
+		Limit:  int64(cfg.RecentNotes),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get recent notes", err)
+		return
+	}
+
+	resp := make([]recentNote, len(notes))
+	for i, note := range notes {
+		createdAt, err := time.Parse(time.RFC3339, note.CreatedAt)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't convert recent notes", err)
+			return
+		}
+		resp[i] = recentNote{ID: note.ID, CreatedAt: createdAt.In(loc).Format(time.RFC3339), Note: note.Note}
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// statsTopTags is how many of the caller's tags GET /v1/notes/stats lists,
+// most used first.
+const statsTopTags = 5
+
+func (cfg *apiConfig) handlerNotesStats(w http.ResponseWriter, r *http.Request, user database.User) {
+	type response struct {
+		Total      int64      `json:"total"`
+		Last7Days  int64      `json:"last_7_days"`
+		Last30Days int64      `json:"last_30_days"`
+		TopTags    []TagCount `json:"top_tags"`
+	}
+
+	total, err := cfg.dbFor(r).CountNotesForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count notes", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	last7Days, err := cfg.dbFor(r).CountNotesForUserSince(r.Context(), database.CountNotesForUserSinceParams{
+		UserID:    user.ID,
+		CreatedAt: now.AddDate(0, 0, -7).Format(time.RFC3339),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count notes", err)
+		return
+	}
+
+	last30Days, err := cfg.dbFor(r).CountNotesForUserSince(r.Context(), database.CountNotesForUserSinceParams{
+		UserID:    user.ID,
+		CreatedAt: now.AddDate(0, 0, -30).Format(time.RFC3339),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count notes", err)
+		return
+	}
+
+	topTags, err := cfg.dbFor(r).GetTopTagsForUser(r.Context(), database.GetTopTagsForUserParams{
+		UserID: user.ID,
+		Limit:  statsTopTags,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count tags", err)
+		return
+	}
+	tags := make([]TagCount, len(topTags))
+	for i, tag := range topTags {
+		tags[i] = TagCount{Tag: tag.Tag, NoteCount: tag.NoteCount}
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Total:      total,
+		Last7Days:  last7Days,
+		Last30Days: last30Days,
+		TopTags:    tags,
+	})
+}
+
+// handlerNotesChecksum hashes the ID and updated_at of each of the caller's
+// notes, so sync clients can tell whether anything changed without fetching
+// bodies. It is weak: updated_at has whole second precision and tagging
+// doesn't bump it.
+func (cfg *apiConfig) handlerNotesChecksum(w http.ResponseWriter, r *http.Request, user database.User) {
+	type response struct {
+		Checksum string `json:"checksum"`
+		Count    int    `json:"count"`
+	}
+
+	versions, err := cfg.dbFor(r).GetNoteVersionsForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get note versions", err)
+		return
+	}
+
+	hash := sha256.New()
+	for _, v := range versions {
+		fmt.Fprintf(hash, "%s %s\n", v.ID, v.UpdatedAt)
+	}
+	respondWithJSON(w, http.StatusOK, response{
+		Checksum: hex.EncodeToString(hash.Sum(nil)),
+		Count:    len(versions),
+	})
+}
+
+// handlerNotesIDs lists the ID and updated_at of each of the caller's notes,
+// ordered by ID, so a sync client can work out which bodies it lacks and
+// fetch only those. The query never reads the bodies.
+func (cfg *apiConfig) handlerNotesIDs(w http.ResponseWriter, r *http.Request, user database.User) {
+	type noteVersion struct {
+		ID        string `json:"id"`
+		UpdatedAt string `json:"updated_at"`
+	}
+
+	page, err := cfg.parsePagination(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.dbFor(r).GetNoteIDsForUser(r.Context(), database.GetNoteIDsForUserParams{
+		UserID: user.ID,
+		Limit:  int64(page.Limit),
+		Offset: int64(page.Offset),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get note IDs", err)
+		return
+	}
+	total, err := cfg.dbFor(r).CountNotesForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count notes", err)
+		return
+	}
+
+	versions := make([]noteVersion, len(rows))
+	for i, row := range rows {
+		versions[i] = noteVersion{ID: row.ID, UpdatedAt: row.UpdatedAt}
+	}
+	setPaginationHeaders(w, page)
+	setLinkHeader(w, r, page, total)
+	respondWithJSON(w, http.StatusOK, versions)
+}
+
+func (cfg *apiConfig) handlerNotesUpdate(w http.ResponseWriter, r *http.Request, user database.User) {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == jsonpatch.MediaType {
+		cfg.handlerNotesJSONPatch(w, r, user)
+		return
+	}
+
+	// Omitted fields are left as they are. ID is optional and only checked
+	// against the path, so a body meant for another note isn't applied here.
+	type parameters struct {
+		ID       *string         `json:"id"`
+		Note     *string         `json:"note"`
+		Metadata json.RawMessage `json:"metadata"`
+	}
+	params := parameters{}
+	err := decodeJSON(r, &params)
 	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	var metadata string
+	if params.Metadata != nil {
+		metadata, err = parseNoteMetadata(params.Metadata)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+	}
+
+	noteID, ok := parseUUIDParam(w, r, "noteID")
+	if !ok {
+		return
+	}
+	if params.ID != nil {
+		if bodyID, err := uuid.Parse(*params.ID); err != nil || bodyID.String() != noteID {
+			respondWithError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Body id %q doesn't match note %s", *params.ID, noteID), nil)
+			return
+		}
+	}
+
+	var note database.Note
+	err = cfg.dbFor(r).InTx(r.Context(), func(tx store.Store) error {
+		var err error
+		note, err = getOwnedNote(r.Context(), tx, noteID, user.ID)
+		if err != nil {
+			return err
+		}
+
+		if params.Note != nil {
+			err = cfg.updateNoteBody(r.Context(), tx, note, *params.Note)
+			if err != nil {
+				return err
+			}
+		}
+		if params.Metadata != nil && metadata != note.Metadata {
+			err = tx.SetNoteMetadata(r.Context(), database.SetNoteMetadataParams{
+				Metadata:  metadata,
+				UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+				ID:        note.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		note, err = tx.GetNote(r.Context(), note.ID)
+		return err
+	})
+	if errors.Is(err, errNoteNotFound) {
 		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
 		return
 	}
+	if respondInvalid(w, err) {
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update note", err)
+		return
+	}
 
 	noteResp, err := databaseNoteToNote(note)
 	if err != nil {
@@ -62,5 +663,79 @@ func (cfg *apiConfig) handlerNotesCreate(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, noteResp)
+	respondWithJSON(w, http.StatusOK, noteResp)
+}
+
+// noteBody carries the rule every update applies to a note body, matching
+// the tags on create. It fails with validate.Errors.
+type noteBody struct {
+	Note string `json:"note" validate:"required,notblank,max=10000"`
+}
+
+// updateNoteBody checks body against noteBody, then saves note's current
+// body as a revision and replaces it with body.
+func (cfg *apiConfig) updateNoteBody(ctx context.Context, tx store.Store, note database.Note, body string) error {
+	if err := validate.Struct(noteBody{Note: body}); err != nil {
+		return err
+	}
+	revisionID, err := cfg.newID()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	err = tx.CreateNoteRevision(ctx, database.CreateNoteRevisionParams{
+		ID:        revisionID,
+		NoteID:    note.ID,
+		CreatedAt: now,
+		Note:      note.Note,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.UpdateNote(ctx, database.UpdateNoteParams{
+		Note:      body,
+		UpdatedAt: now,
+		ID:        note.ID,
+	})
+}
+
+func (cfg *apiConfig) handlerNotesHistory(w http.ResponseWriter, r *http.Request, user database.User) {
+	noteID, ok := parseUUIDParam(w, r, "noteID")
+	if !ok {
+		return
+	}
+	loc, err := parseTimezone(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	note, err := getOwnedNote(r.Context(), cfg.dbFor(r), noteID, user.ID)
+	if errors.Is(err, errNoteNotFound) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get note", err)
+		return
+	}
+
+	revisions, err := cfg.dbFor(r).GetNoteRevisions(r.Context(), note.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get note history", err)
+		return
+	}
+
+	revisionsResp, err := databaseNoteRevisionsToNoteRevisions(revisions)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert note history", err)
+		return
+	}
+	for i := range revisionsResp {
+		revisionsResp[i].CreatedAt = revisionsResp[i].CreatedAt.In(loc)
+	}
+
+	respondWithJSON(w, http.StatusOK, revisionsResp)
 }
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+)
+
+const maxBatchSize = 100
+
+type batchNote struct {
+	Note   string `json:"note" validate:"required,notblank,max=10000"`
+	Public bool   `json:"public"`
+}
+
+// batchResult reports the outcome of one item in a partial batch. Status is
+// the code the item would have got from POST /v1/notes on its own.
+type batchResult struct {
+	Index  int             `json:"index"`
+	Status int             `json:"status"`
+	Note   *Note           `json:"note,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Fields validate.Errors `json:"fields,omitempty"`
+}
+
+// handlerNotesBatchCreate creates several notes at once. By default the batch
+// is all-or-nothing: one invalid item fails the request with a 422 and
+// nothing is inserted. With ?partial=true each item is inserted on its own
+// and the response is a 207 listing a result per item.
+func (cfg *apiConfig) handlerNotesBatchCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Notes []batchNote `json:"notes"`
+	}
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if len(params.Notes) == 0 || len(params.Notes) > maxBatchSize {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Batch must contain between 1 and %d notes", maxBatchSize), nil)
+		return
+	}
+
+	partial := false
+	v, err := queryValue(r.URL.Query(), "partial")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if v != "" {
+		partial, err = strconv.ParseBool(v)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("partial must be true or false, got %q", v), err)
+			return
+		}
+	}
+	if partial {
+		cfg.batchCreatePartial(w, r, user, params.Notes)
+		return
+	}
+
+	var fields validate.Errors
+	for i, item := range params.Notes {
+		fields = append(fields, batchFieldErrors(i, item)...)
+	}
+	if len(fields) > 0 {
+		respondWithJSON(w, http.StatusUnprocessableEntity, errorResponse{
+			Error:  "Validation failed",
+			Code:   errCodeValidation,
+			Fields: fields,
+		})
+		return
+	}
+
+	notes := make([]database.Note, 0, len(params.Notes))
+	err = cfg.dbFor(r).InTx(r.Context(), func(tx store.Store) error {
+		notes = notes[:0]
+		for _, item := range params.Notes {
+			note, err := cfg.createNote(r.Context(), tx, user, item)
+			if err != nil {
+				return err
+			}
+			notes = append(notes, note)
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create notes", err)
+		return
+	}
+	for _, note := range notes {
+		cfg.NoteEvents.Publish(note)
+	}
+
+	notesResp, err := databasePostsToPosts(notes)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert notes", err)
+		return
+	}
+	respondCreated(w, "", notesResp)
+}
+
+func (cfg *apiConfig) batchCreatePartial(w http.ResponseWriter, r *http.Request, user database.User, items []batchNote) {
+	results := make([]batchResult, len(items))
+	for i, item := range items {
+		results[i].Index = i
+		if fields := batchFieldErrors(i, item); len(fields) > 0 {
+			results[i].Status = http.StatusUnprocessableEntity
+			results[i].Error = "Validation failed"
+			results[i].Fields = fields
+			continue
+		}
+
+		note, err := cfg.createNote(r.Context(), cfg.dbFor(r), user, item)
+		if err != nil {
+			log.Printf("Couldn't create note %d of batch: %s", i, err)
+			results[i].Status = http.StatusInternalServerError
+			results[i].Error = "Couldn't create note"
+			continue
+		}
+		cfg.NoteEvents.Publish(note)
+
+		noteResp, err := databaseNoteToNote(note)
+		if err != nil {
+			log.Printf("Couldn't convert note %d of batch: %s", i, err)
+			results[i].Status = http.StatusInternalServerError
+			results[i].Error = "Couldn't convert note"
+			continue
+		}
+		results[i].Status = http.StatusCreated
+		results[i].Note = &noteResp
+	}
+	respondWithJSON(w, http.StatusMultiStatus, results)
+}
+
+// batchFieldErrors validates item, naming fields by their position in the
+// batch, as in "notes[2].note".
+func batchFieldErrors(i int, item batchNote) validate.Errors {
+	var fields validate.Errors
+	if !errors.As(validate.Struct(item), &fields) {
+		return nil
+	}
+	for j := range fields {
+		fields[j].Field = fmt.Sprintf("notes[%d].%s", i, fields[j].Field)
+	}
+	return fields
+}
+
+func (cfg *apiConfig) createNote(ctx context.Context, db store.Store, user database.User, item batchNote) (database.Note, error) {
+	id, err := cfg.newID()
+	if err != nil {
+		return database.Note{}, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	err = db.CreateNote(ctx, database.CreateNoteParams{
+		ID:        id,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Note:      item.Note,
+		UserID:    user.ID,
+		Public:    item.Public,
+		Metadata:  emptyNoteMetadata,
+	})
+	if err != nil {
+		return database.Note{}, err
+	}
+	return db.GetNote(ctx, id)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+var mixedBatch = map[string][]map[string]string{
+	"notes": {{"note": "first"}, {"note": ""}, {"note": "third"}},
+}
+
+func listNotes(t *testing.T, h http.Handler, apiKey string) []Note {
+	t.Helper()
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes", apiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	return decodeResponse[[]Note](t, rec)
+}
+
+func TestNotesBatchCreateAtomic(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/notes/batch", user.ApiKey, mixedBatch)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("mixed batch status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	resp := decodeResponse[errorResponse](t, rec)
+	if len(resp.Fields) != 1 || resp.Fields[0].Field != "notes[1].note" {
+		t.Errorf("fields = %+v, want one error for notes[1].note", resp.Fields)
+	}
+	if notes := listNotes(t, h, user.ApiKey); len(notes) != 0 {
+		t.Fatalf("mixed batch created %d notes, want 0", len(notes))
+	}
+
+	rec = doRequest(t, h, http.MethodPost, "/v1/notes/batch", user.ApiKey, map[string][]map[string]string{
+		"notes": {{"note": "first"}, {"note": "second"}},
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("valid batch status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if got := decodeResponse[[]Note](t, rec); len(got) != 2 || got[0].Note != "first" || got[1].Note != "second" {
+		t.Errorf("valid batch = %+v, want notes first and second", got)
+	}
+	if notes := listNotes(t, h, user.ApiKey); len(notes) != 2 {
+		t.Errorf("valid batch created %d notes, want 2", len(notes))
+	}
+}
+
+func TestNotesBatchCreatePartial(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/notes/batch?partial=true", user.ApiKey, mixedBatch)
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusMultiStatus)
+	}
+	results := decodeResponse[[]batchResult](t, rec)
+	wantStatus := []int{http.StatusCreated, http.StatusUnprocessableEntity, http.StatusCreated}
+	if len(results) != len(wantStatus) {
+		t.Fatalf("got %d results, want %d", len(results), len(wantStatus))
+	}
+	for i, res := range results {
+		if res.Index != i || res.Status != wantStatus[i] {
+			t.Errorf("result %d = index %d status %d, want index %d status %d", i, res.Index, res.Status, i, wantStatus[i])
+		}
+		if (res.Note != nil) != (res.Status == http.StatusCreated) {
+			t.Errorf("result %d note = %v with status %d", i, res.Note, res.Status)
+		}
+	}
+	if len(results[1].Fields) != 1 || results[1].Fields[0].Field != "notes[1].note" {
+		t.Errorf("result 1 fields = %+v, want one error for notes[1].note", results[1].Fields)
+	}
+	if notes := listNotes(t, h, user.ApiKey); len(notes) != 2 {
+		t.Errorf("partial batch created %d notes, want 2", len(notes))
+	}
+}
+
+// flakyCreateStore fails the second CreateNote call it sees.
+type flakyCreateStore struct {
+	store.Store
+	calls int
+}
+
+func (s *flakyCreateStore) CreateNote(ctx context.Context, arg database.CreateNoteParams) error {
+	s.calls++
+	if s.calls == 2 {
+		return errors.New("disk full")
+	}
+	return s.Store.CreateNote(ctx, arg)
+}
+
+func (s *flakyCreateStore) InTx(ctx context.Context, fn func(store.Store) error) error {
+	return s.Store.InTx(ctx, func(store.Store) error { return fn(s) })
+}
+
+func TestNotesBatchCreateStoreFailure(t *testing.T) {
+	batch := map[string][]map[string]string{
+		"notes": {{"note": "first"}, {"note": "second"}, {"note": "third"}},
+	}
+
+	t.Run("atomic", func(t *testing.T) {
+		h, _ := newTestRouter(t, func(cfg *apiConfig) {
+			cfg.DB = &flakyCreateStore{Store: cfg.DB}
+		})
+		user := createTestUser(t, h, "alice")
+
+		rec := doRequest(t, h, http.MethodPost, "/v1/notes/batch", user.ApiKey, batch)
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+		}
+		if notes := listNotes(t, h, user.ApiKey); len(notes) != 0 {
+			t.Errorf("failed batch left %d notes, want 0", len(notes))
+		}
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		h, _ := newTestRouter(t, func(cfg *apiConfig) {
+			cfg.DB = &flakyCreateStore{Store: cfg.DB}
+		})
+		user := createTestUser(t, h, "alice")
+
+		rec := doRequest(t, h, http.MethodPost, "/v1/notes/batch?partial=true", user.ApiKey, batch)
+		if rec.Code != http.StatusMultiStatus {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusMultiStatus)
+		}
+		results := decodeResponse[[]batchResult](t, rec)
+		if len(results) != 3 || results[1].Status != http.StatusInternalServerError ||
+			results[0].Status != http.StatusCreated || results[2].Status != http.StatusCreated {
+			t.Errorf("results = %+v, want only the second item to fail", results)
+		}
+		if notes := listNotes(t, h, user.ApiKey); len(notes) != 2 {
+			t.Errorf("partial batch created %d notes, want 2", len(notes))
+		}
+	})
+}
+
+func TestNotesBatchCreateRejectsBadBatch(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+
+	tests := []struct {
+		name string
+		path string
+		body any
+	}{
+		{"empty", "/v1/notes/batch", map[string][]map[string]string{"notes": {}}},
+		{"bad partial", "/v1/notes/batch?partial=maybe", mixedBatch},
+	}
+	for _, tt := range tests {
+		rec := doRequest(t, h, http.MethodPost, tt.path, user.ApiKey, tt.body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
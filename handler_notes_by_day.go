@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// maxNotesByDay caps how many notes GET /v1/notes/by-day buckets at once.
+const maxNotesByDay = 1000
+
+// handlerNotesByDay groups the caller's notes by the calendar day they were
+// created on in the tz parameter's zone, newest day first. SQLite only
+// knows fixed UTC offsets, so the grouping happens here rather than in SQL
+// to follow daylight saving time.
+func (cfg *apiConfig) handlerNotesByDay(w http.ResponseWriter, r *http.Request, user database.User) {
+	type noteDay struct {
+		Date  string `json:"date"`
+		Notes []Note `json:"notes"`
+	}
+
+	created, err := parseDateRange(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	loc, err := parseTimezone(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// One extra row tells us whether the range holds too many notes.
+	notes, err := cfg.dbFor(r).GetNotesForUser(r.Context(), database.GetNotesForUserParams{
+		UserID:        user.ID,
+		CreatedAfter:  created.After,
+		CreatedBefore: created.Before,
+		Limit:         maxNotesByDay + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get posts for user", err)
+		return
+	}
+	if len(notes) > maxNotesByDay {
+		msg := fmt.Sprintf("More than %d notes in range, narrow it with createdAfter and createdBefore", maxNotesByDay)
+		respondWithError(w, http.StatusUnprocessableEntity, msg, nil)
+		return
+	}
+
+	notesResp, err := databasePostsToPosts(notes)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert posts", err)
+		return
+	}
+	// GetNotesForUser lists pinned notes first, which doesn't apply here.
+	slices.SortStableFunc(notesResp, func(a, b Note) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	days := []noteDay{}
+	for _, note := range notesIn(notesResp, loc) {
+		date := note.CreatedAt.Format(time.DateOnly)
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, noteDay{Date: date})
+		}
+		days[len(days)-1].Notes = append(days[len(days)-1].Notes, note)
+	}
+	respondWithJSON(w, http.StatusOK, days)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func TestNotesByDay(t *testing.T) {
+	h, db := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	for i, createdAt := range []string{
+		"2024-03-10T03:30:00Z", // 22:30 on the 9th in New York
+		"2024-03-10T05:30:00Z", // 00:30 on the 10th in New York
+		"2024-03-10T12:00:00Z", // 08:00 on the 10th, after the switch to EDT
+		"2024-03-12T12:00:00Z",
+	} {
+		err := db.CreateNote(context.Background(), database.CreateNoteParams{
+			ID:        string(rune('a' + i)),
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+			Note:      createdAt,
+			UserID:    user.ID,
+			Metadata:  emptyNoteMetadata,
+		})
+		if err != nil {
+			t.Fatalf("seeding note: %v", err)
+		}
+	}
+	type noteDay struct {
+		Date  string `json:"date"`
+		Notes []Note `json:"notes"`
+	}
+	byDay := func(query string) map[string][]string {
+		t.Helper()
+		rec := doRequest(t, h, http.MethodGet, "/v1/notes/by-day"+query, user.ApiKey, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want %d: %s", query, rec.Code, http.StatusOK, rec.Body)
+		}
+		got := make(map[string][]string)
+		days := decodeResponse[[]noteDay](t, rec)
+		for i, day := range days {
+			if i > 0 && day.Date >= days[i-1].Date {
+				t.Errorf("GET %s: day %s listed after %s, want newest first", query, day.Date, days[i-1].Date)
+			}
+			for _, note := range day.Notes {
+				got[day.Date] = append(got[day.Date], note.Note)
+			}
+		}
+		return got
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  map[string][]string
+	}{
+		{"utc", "", map[string][]string{
+			"2024-03-12": {"2024-03-12T12:00:00Z"},
+			"2024-03-10": {"2024-03-10T12:00:00Z", "2024-03-10T05:30:00Z", "2024-03-10T03:30:00Z"},
+		}},
+		{"new york", "?tz=America/New_York", map[string][]string{
+			"2024-03-12": {"2024-03-12T12:00:00Z"},
+			"2024-03-10": {"2024-03-10T12:00:00Z", "2024-03-10T05:30:00Z"},
+			"2024-03-09": {"2024-03-10T03:30:00Z"},
+		}},
+		{"range", "?tz=America/New_York&createdBefore=2024-03-11T00:00:00Z", map[string][]string{
+			"2024-03-10": {"2024-03-10T12:00:00Z", "2024-03-10T05:30:00Z"},
+			"2024-03-09": {"2024-03-10T03:30:00Z"},
+		}},
+	}
+	for _, tt := range tests {
+		got := byDay(tt.query)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: days = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for date, notes := range tt.want {
+			if !slices.Equal(got[date], notes) {
+				t.Errorf("%s: %s = %v, want %v", tt.name, date, got[date], notes)
+			}
+		}
+	}
+
+	if rec := doRequest(t, h, http.MethodGet, "/v1/notes/by-day?tz=Nowhere/Special", user.ApiKey, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid tz: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+	"github.com/google/uuid"
+)
+
+// defaultMergeSeparator goes between the target and source bodies unless the
+// request sets its own.
+const defaultMergeSeparator = "\n\n"
+
+// handlerNotesMerge appends the body of source_id to the note in the path and
+// gives it the source's tags, then deletes the source if delete_source is
+// set, with its tags and revisions. The target's old body is kept as a
+// revision, as with PATCH, and the merged body must pass the same noteBody
+// rule. It all runs in one transaction, so a rule, tag limit or failed
+// delete leaves both notes as they were. Either note belonging to someone
+// else is a 404.
+func (cfg *apiConfig) handlerNotesMerge(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		SourceID     string  `json:"source_id" validate:"required"`
+		Separator    *string `json:"separator"`
+		DeleteSource bool    `json:"delete_source"`
+	}
+
+	noteID, ok := parseUUIDParam(w, r, "noteID")
+	if !ok {
+		return
+	}
+	params := parameters{}
+	if err := decodeJSON(r, &params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if !validateParams(w, params) {
+		return
+	}
+	sourceID, err := uuid.Parse(params.SourceID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid source_id %q", params.SourceID), err)
+		return
+	}
+	if sourceID.String() == noteID {
+		respondWithError(w, http.StatusUnprocessableEntity, "Can't merge a note into itself", nil)
+		return
+	}
+	separator := defaultMergeSeparator
+	if params.Separator != nil {
+		separator = *params.Separator
+	}
+
+	var note database.Note
+	err = cfg.dbFor(r).InTx(r.Context(), func(tx store.Store) error {
+		target, err := getOwnedNote(r.Context(), tx, noteID, user.ID)
+		if err != nil {
+			return err
+		}
+		source, err := getOwnedNote(r.Context(), tx, sourceID.String(), user.ID)
+		if err != nil {
+			return err
+		}
+
+		err = cfg.updateNoteBody(r.Context(), tx, target, target.Note+separator+source.Note)
+		if err != nil {
+			return err
+		}
+		tags, err := tx.GetTagsForNote(r.Context(), source.ID)
+		if err != nil {
+			return err
+		}
+		for _, tag := range tags {
+			if _, err := cfg.addNoteTag(r.Context(), tx, target.ID, tag); err != nil {
+				return err
+			}
+		}
+		if params.DeleteSource {
+			// SQLite skips ON DELETE CASCADE without PRAGMA foreign_keys.
+			if err := tx.DeleteNoteTags(r.Context(), source.ID); err != nil {
+				return err
+			}
+			if err := tx.DeleteNoteRevisions(r.Context(), source.ID); err != nil {
+				return err
+			}
+			_, err = tx.DeleteNote(r.Context(), database.DeleteNoteParams{
+				ID:     source.ID,
+				UserID: user.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		note, err = tx.GetNote(r.Context(), target.ID)
+		return err
+	})
+	if errors.Is(err, errNoteNotFound) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+	if respondInvalid(w, err) {
+		return
+	}
+	if errors.Is(err, errTooManyTags) {
+		msg := fmt.Sprintf("Merging would take note %s past the maximum of %d tags", noteID, cfg.MaxTagsPerNote)
+		respondWithError(w, http.StatusUnprocessableEntity, msg, err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't merge notes", err)
+		return
+	}
+
+	noteResp, err := databaseNoteToNote(note)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, noteResp)
+}
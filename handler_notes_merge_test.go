@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNotesMerge(t *testing.T) {
+	tag := func(t *testing.T, h http.Handler, apiKey, tag, noteID string) {
+		t.Helper()
+		rec := doRequest(t, h, http.MethodPost, "/v1/tags/"+tag+"/assign", apiKey, map[string][]string{"note_ids": {noteID}})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("assign %s status = %d, want %d", tag, rec.Code, http.StatusOK)
+		}
+	}
+
+	t.Run("delete source", func(t *testing.T) {
+		h, db := newTestRouter(t)
+		user := createTestUser(t, h, "alice")
+		target := createTestNote(t, h, user.ApiKey, "first half")
+		source := createTestNote(t, h, user.ApiKey, "second draft")
+		if rec := doRequest(t, h, http.MethodPatch, "/v1/notes/"+source.ID, user.ApiKey, map[string]string{"note": "second half"}); rec.Code != http.StatusOK {
+			t.Fatalf("PATCH source status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		tag(t, h, user.ApiKey, "work", target.ID)
+		tag(t, h, user.ApiKey, "work", source.ID)
+		tag(t, h, user.ApiKey, "ideas", source.ID)
+
+		rec := doRequest(t, h, http.MethodPost, "/v1/notes/"+target.ID+"/merge", user.ApiKey, map[string]any{
+			"source_id":     source.ID,
+			"separator":     " / ",
+			"delete_source": true,
+		})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("merge status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+		}
+		if got := decodeResponse[Note](t, rec); got.ID != target.ID || got.Note != "first half / second half" {
+			t.Errorf("merged note = %+v, want %s with both bodies", got, target.ID)
+		}
+		tags, err := db.GetTagsForNote(context.Background(), target.ID)
+		slices.Sort(tags)
+		if err != nil || !slices.Equal(tags, []string{"ideas", "work"}) {
+			t.Errorf("merged tags = %v (err %v), want [ideas work]", tags, err)
+		}
+		if rec := doRequest(t, h, http.MethodGet, "/v1/notes/"+source.ID, user.ApiKey, nil); rec.Code != http.StatusNotFound {
+			t.Errorf("GET source status = %d, want %d", rec.Code, http.StatusNotFound)
+		}
+		if tags, err := db.GetTagsForNote(context.Background(), source.ID); err != nil || len(tags) != 0 {
+			t.Errorf("deleted source tags = %v (err %v), want none", tags, err)
+		}
+		if revisions, err := db.GetNoteRevisions(context.Background(), source.ID); err != nil || len(revisions) != 0 {
+			t.Errorf("deleted source revisions = %+v (err %v), want none", revisions, err)
+		}
+		revisions, err := db.GetNoteRevisions(context.Background(), target.ID)
+		if err != nil || len(revisions) != 1 || revisions[0].Note != "first half" {
+			t.Errorf("target revisions = %+v (err %v), want the pre-merge body", revisions, err)
+		}
+	})
+
+	t.Run("keep source", func(t *testing.T) {
+		h, db := newTestRouter(t)
+		user := createTestUser(t, h, "alice")
+		target := createTestNote(t, h, user.ApiKey, "first half")
+		source := createTestNote(t, h, user.ApiKey, "second half")
+		tag(t, h, user.ApiKey, "ideas", source.ID)
+
+		rec := doRequest(t, h, http.MethodPost, "/v1/notes/"+target.ID+"/merge", user.ApiKey, map[string]any{"source_id": source.ID})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("merge status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+		}
+		if got := decodeResponse[Note](t, rec); got.Note != "first half\n\nsecond half" {
+			t.Errorf("merged body = %q, want the bodies joined by a blank line", got.Note)
+		}
+		rec = doRequest(t, h, http.MethodGet, "/v1/notes/"+source.ID, user.ApiKey, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET source status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		if got := decodeResponse[Note](t, rec); got.Note != "second half" {
+			t.Errorf("source body = %q, want it unchanged", got.Note)
+		}
+		if tags, err := db.GetTagsForNote(context.Background(), source.ID); err != nil || !slices.Equal(tags, []string{"ideas"}) {
+			t.Errorf("source tags = %v (err %v), want [ideas]", tags, err)
+		}
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		h, _ := newTestRouter(t)
+		user := createTestUser(t, h, "alice")
+		target := createTestNote(t, h, user.ApiKey, strings.Repeat("a", 6000))
+		source := createTestNote(t, h, user.ApiKey, strings.Repeat("b", 4000))
+
+		rec := doRequest(t, h, http.MethodPost, "/v1/notes/"+target.ID+"/merge", user.ApiKey, map[string]any{
+			"source_id":     source.ID,
+			"delete_source": true,
+		})
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("merge past 10000 characters status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+		}
+		for _, note := range []Note{target, source} {
+			rec := doRequest(t, h, http.MethodGet, "/v1/notes/"+note.ID, user.ApiKey, nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s after a rejected merge status = %d, want %d", note.ID, rec.Code, http.StatusOK)
+			}
+			if got := decodeResponse[Note](t, rec); got.Note != note.Note {
+				t.Errorf("note %s changed by a rejected merge", note.ID)
+			}
+		}
+	})
+
+	t.Run("not owned", func(t *testing.T) {
+		h, _ := newTestRouter(t)
+		alice := createTestUser(t, h, "alice")
+		bob := createTestUser(t, h, "bob")
+		mine := createTestNote(t, h, alice.ApiKey, "mine")
+		theirs := createTestNote(t, h, bob.ApiKey, "theirs")
+
+		for _, tt := range []struct{ name, target, source string }{
+			{"source", mine.ID, theirs.ID},
+			{"target", theirs.ID, mine.ID},
+		} {
+			rec := doRequest(t, h, http.MethodPost, "/v1/notes/"+tt.target+"/merge", alice.ApiKey, map[string]any{
+				"source_id":     tt.source,
+				"delete_source": true,
+			})
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("merge with another user's %s status = %d, want %d", tt.name, rec.Code, http.StatusNotFound)
+			}
+		}
+		for _, note := range []Note{mine, theirs} {
+			owner := alice
+			if note.ID == theirs.ID {
+				owner = bob
+			}
+			rec := doRequest(t, h, http.MethodGet, "/v1/notes/"+note.ID, owner.ApiKey, nil)
+			if got := decodeResponse[Note](t, rec); got.Note != note.Note {
+				t.Errorf("note %s = %q after failed merges, want %q", note.ID, got.Note, note.Note)
+			}
+		}
+	})
+
+	t.Run("lookup error", func(t *testing.T) {
+		h, user, source := newBusyNoteRouter(t)
+		target := createTestNote(t, h, user.ApiKey, "target")
+		rec := doRequest(t, h, http.MethodPost, "/v1/notes/"+target.ID+"/merge", user.ApiKey, map[string]any{"source_id": source.ID})
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("merge with a busy source lookup status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+		}
+	})
+}
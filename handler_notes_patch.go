@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/jsonpatch"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+// immutableNoteFields can't be changed through JSON Patch, either because
+// they're server managed or derived from the note. "test" operations may
+// still read them.
+var immutableNoteFields = map[string]bool{
+	"id":         true,
+	"user_id":    true,
+	"created_at": true,
+	"updated_at": true,
+	"char_count": true,
+	"word_count": true,
+}
+
+// errUnprocessablePatch marks patches that are well formed but can't be
+// applied to the note.
+var errUnprocessablePatch = errors.New("couldn't apply patch")
+
+// notePatchDocument is the patched Note representation. Immutable fields are
+// kept raw so they can be compared byte for byte with the original.
+type notePatchDocument struct {
+	ID        json.RawMessage `json:"id"`
+	CreatedAt json.RawMessage `json:"created_at"`
+	UpdatedAt json.RawMessage `json:"updated_at"`
+	UserID    json.RawMessage `json:"user_id"`
+	Note      *string         `json:"note"`
+	Public    *bool           `json:"public"`
+	Metadata  json.RawMessage `json:"metadata"`
+	Pinned    *bool           `json:"pinned"`
+	CharCount json.RawMessage `json:"char_count"`
+	WordCount json.RawMessage `json:"word_count"`
+}
+
+// handlerNotesJSONPatch applies an RFC 6902 patch to the note's JSON
+// representation. The patch is applied inside the transaction so "test"
+// operations see the same state that gets written.
+func (cfg *apiConfig) handlerNotesJSONPatch(w http.ResponseWriter, r *http.Request, user database.User) {
+	patch, err := jsonpatch.Decode(r.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON Patch: "+err.Error(), err)
+		return
+	}
+	for _, op := range patch {
+		if op.Op == "test" {
+			continue
+		}
+		for _, ptr := range []string{op.Path, op.From} {
+			if field := topLevelField(ptr); immutableNoteFields[field] {
+				respondWithError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Couldn't patch immutable field %s", field), nil)
+				return
+			}
+		}
+	}
+
+	noteID, ok := parseUUIDParam(w, r, "noteID")
+	if !ok {
+		return
+	}
+
+	var note database.Note
+	err = cfg.dbFor(r).InTx(r.Context(), func(tx store.Store) error {
+		var err error
+		note, err = getOwnedNote(r.Context(), tx, noteID, user.ID)
+		if err != nil {
+			return err
+		}
+
+		patched, err := applyNotePatch(note, patch)
+		if err != nil {
+			return err
+		}
+
+		if *patched.Note != note.Note {
+			if err := cfg.updateNoteBody(r.Context(), tx, note, *patched.Note); err != nil {
+				return err
+			}
+		}
+		if *patched.Public != note.Public {
+			err := tx.SetNotePublic(r.Context(), database.SetNotePublicParams{
+				Public:    *patched.Public,
+				UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+				ID:        note.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if *patched.Pinned != note.IsPinned {
+			err := tx.SetNotePinned(r.Context(), database.SetNotePinnedParams{
+				IsPinned:  *patched.Pinned,
+				UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+				ID:        note.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if metadata := string(patched.Metadata); metadata != note.Metadata {
+			err := tx.SetNoteMetadata(r.Context(), database.SetNoteMetadataParams{
+				Metadata:  metadata,
+				UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+				ID:        note.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		note, err = tx.GetNote(r.Context(), note.ID)
+		return err
+	})
+	switch {
+	case errors.Is(err, errNoteNotFound):
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		respondWithError(w, http.StatusConflict, "JSON Patch test operation failed", err)
+		return
+	case errors.Is(err, errUnprocessablePatch):
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error(), err)
+		return
+	case respondInvalid(w, err):
+		return
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update note", err)
+		return
+	}
+
+	noteResp, err := databaseNoteToNote(note)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, noteResp)
+}
+
+// applyNotePatch patches note's JSON representation and validates the result.
+func applyNotePatch(note database.Note, patch jsonpatch.Patch) (notePatchDocument, error) {
+	current, err := databaseNoteToNote(note)
+	if err != nil {
+		return notePatchDocument{}, err
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return notePatchDocument{}, err
+	}
+	var before notePatchDocument
+	if err := json.Unmarshal(doc, &before); err != nil {
+		return notePatchDocument{}, err
+	}
+
+	patchedDoc, err := patch.Apply(doc)
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		return notePatchDocument{}, err
+	}
+	if err != nil {
+		return notePatchDocument{}, fmt.Errorf("%w: %w", errUnprocessablePatch, err)
+	}
+
+	var after notePatchDocument
+	dec := json.NewDecoder(bytes.NewReader(patchedDoc))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&after); err != nil {
+		return notePatchDocument{}, fmt.Errorf("%w: %w", errUnprocessablePatch, err)
+	}
+	for field, pair := range map[string][2]json.RawMessage{
+		"id":         {before.ID, after.ID},
+		"user_id":    {before.UserID, after.UserID},
+		"created_at": {before.CreatedAt, after.CreatedAt},
+		"updated_at": {before.UpdatedAt, after.UpdatedAt},
+		"char_count": {before.CharCount, after.CharCount},
+		"word_count": {before.WordCount, after.WordCount},
+	} {
+		if !bytes.Equal(pair[0], pair[1]) {
+			return notePatchDocument{}, fmt.Errorf("%w: immutable field %s changed", errUnprocessablePatch, field)
+		}
+	}
+	if after.Note == nil {
+		return notePatchDocument{}, fmt.Errorf("%w: note must be a string", errUnprocessablePatch)
+	}
+	if after.Public == nil {
+		return notePatchDocument{}, fmt.Errorf("%w: public must be a boolean", errUnprocessablePatch)
+	}
+	if after.Pinned == nil {
+		return notePatchDocument{}, fmt.Errorf("%w: pinned must be a boolean", errUnprocessablePatch)
+	}
+	metadata, err := parseNoteMetadata(after.Metadata)
+	if err != nil {
+		return notePatchDocument{}, fmt.Errorf("%w: %w", errUnprocessablePatch, err)
+	}
+	after.Metadata = json.RawMessage(metadata)
+	return after, nil
+}
+
+// topLevelField returns the note member a JSON Pointer starts at, or "" for
+// the whole document.
+func topLevelField(ptr string) string {
+	field, _, _ := strings.Cut(strings.TrimPrefix(ptr, "/"), "/")
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(field)
+}
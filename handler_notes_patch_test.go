@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func doJSONPatch(t *testing.T, h http.Handler, noteID, apiKey, patch string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPatch, "/v1/notes/"+noteID, strings.NewReader(patch))
+	req.Header.Set("Content-Type", "application/json-patch+json")
+	req.Header.Set("Authorization", "ApiKey "+apiKey)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestNotesJSONPatch(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "v1")
+
+	t.Run("replace", func(t *testing.T) {
+		rec := doJSONPatch(t, h, note.ID, user.ApiKey, `[
+			{"op": "test", "path": "/note", "value": "v1"},
+			{"op": "replace", "path": "/note", "value": "v2"},
+			{"op": "replace", "path": "/public", "value": true}
+		]`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+		}
+		got := decodeResponse[Note](t, rec)
+		if got.Note != "v2" || !got.Public || got.ID != note.ID {
+			t.Errorf("patched note = %+v, want body v2 and public", got)
+		}
+
+		rec = doRequest(t, h, http.MethodGet, "/v1/notes/"+note.ID+"/history", user.ApiKey, nil)
+		if revisions := decodeResponse[[]NoteRevision](t, rec); len(revisions) != 1 || revisions[0].Note != "v1" {
+			t.Errorf("history = %+v, want one revision with v1", revisions)
+		}
+	})
+
+	t.Run("failed test op", func(t *testing.T) {
+		rec := doJSONPatch(t, h, note.ID, user.ApiKey, `[
+			{"op": "test", "path": "/note", "value": "stale"},
+			{"op": "replace", "path": "/note", "value": "v3"}
+		]`)
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusConflict)
+		}
+		rec = doRequest(t, h, http.MethodGet, "/v1/notes/"+note.ID, user.ApiKey, nil)
+		if got := decodeResponse[Note](t, rec).Note; got != "v2" {
+			t.Errorf("note after failed patch = %q, want v2", got)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		patch    string
+		wantCode int
+	}{
+		{"immutable id", `[{"op": "replace", "path": "/id", "value": "x"}]`, http.StatusUnprocessableEntity},
+		{"immutable user_id", `[{"op": "remove", "path": "/user_id"}]`, http.StatusUnprocessableEntity},
+		{"move from created_at", `[{"op": "move", "from": "/created_at", "path": "/note"}]`, http.StatusUnprocessableEntity},
+		{"whole document", `[{"op": "replace", "path": "", "value": {"note": "x", "public": false}}]`, http.StatusUnprocessableEntity},
+		{"unknown field", `[{"op": "add", "path": "/color", "value": "red"}]`, http.StatusUnprocessableEntity},
+		{"wrong type", `[{"op": "replace", "path": "/note", "value": 5}]`, http.StatusUnprocessableEntity},
+		{"missing member", `[{"op": "remove", "path": "/nope"}]`, http.StatusUnprocessableEntity},
+		{"malformed patch", `{"op": "replace"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doJSONPatch(t, h, note.ID, user.ApiKey, tt.patch)
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+		})
+	}
+}
+
+func TestNotesJSONPatchEnforcesOwnership(t *testing.T) {
+	h, _ := newTestRouter(t)
+	owner := createTestUser(t, h, "alice")
+	other := createTestUser(t, h, "bob")
+	note := createTestNote(t, h, owner.ApiKey, "private")
+
+	rec := doJSONPatch(t, h, note.ID, other.ApiKey, `[{"op": "replace", "path": "/note", "value": "mine"}]`)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestNotesJSONPatchLookupError(t *testing.T) {
+	h, user, note := newBusyNoteRouter(t)
+	rec := doJSONPatch(t, h, note.ID, user.ApiKey, `[{"op":"replace","path":"/note","value":"patched"}]`)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("JSON Patch with a busy lookup status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// likeEscaper escapes LIKE wildcards so q is matched literally. The queries
+// declare ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// searchPattern turns the q query parameter into a LIKE pattern matching
+// notes that contain it. An empty q matches every note.
+func searchPattern(r *http.Request) (string, error) {
+	q, err := queryValue(r.URL.Query(), "q")
+	if err != nil {
+		return "", err
+	}
+	return likePattern(q), nil
+}
+
+// likePattern is the LIKE pattern matching notes that contain q literally.
+func likePattern(q string) string {
+	return "%" + likeEscaper.Replace(q) + "%"
+}
+
+func (cfg *apiConfig) handlerNotesSearch(w http.ResponseWriter, r *http.Request, user database.User) {
+	query := r.URL.Query()
+	if query.Has("scroll") || query.Has("scrollToken") {
+		cfg.handlerNotesSearchScroll(w, r, user)
+		return
+	}
+
+	page, err := cfg.parsePagination(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	loc, err := parseTimezone(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	pattern, err := searchPattern(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	notes, err := cfg.dbFor(r).SearchNotesForUser(r.Context(), database.SearchNotesForUserParams{
+		UserID:  user.ID,
+		Pattern: pattern,
+		Limit:   int64(page.Limit),
+		Offset:  int64(page.Offset),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't search notes", err)
+		return
+	}
+	total, err := cfg.dbFor(r).CountNotesMatchingForUser(r.Context(), database.CountNotesMatchingForUserParams{
+		UserID:  user.ID,
+		Pattern: pattern,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count notes", err)
+		return
+	}
+
+	notesResp, err := databasePostsToPosts(notes)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert notes", err)
+		return
+	}
+	setPaginationHeaders(w, page)
+	setLinkHeader(w, r, page, total)
+	respondWithJSON(w, http.StatusOK, notesIn(notesResp, loc))
+}
+
+func (cfg *apiConfig) handlerNotesSearchCount(w http.ResponseWriter, r *http.Request, user database.User) {
+	type response struct {
+		Count int64 `json:"count"`
+	}
+
+	pattern, err := searchPattern(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	count, err := cfg.dbFor(r).CountNotesMatchingForUser(r.Context(), database.CountNotesMatchingForUserParams{
+		UserID:  user.ID,
+		Pattern: pattern,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count notes", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response{Count: count})
+}
+
+// handlerNotesSearchScroll serves search results in scroll mode: ?scroll=true
+// returns the first page and each response carries a next_scroll_token for
+// ?scrollToken= until the matches run out. Pages are read with keyset
+// pagination on (created_at, rowid), so deep pages cost no more than the
+// first and nothing beyond one page is loaded.
+func (cfg *apiConfig) handlerNotesSearchScroll(w http.ResponseWriter, r *http.Request, user database.User) {
+	type response struct {
+		Notes           []Note `json:"notes"`
+		NextScrollToken string `json:"next_scroll_token,omitempty"`
+	}
+
+	query := r.URL.Query()
+	if query.Has("offset") {
+		respondWithError(w, http.StatusBadRequest, "offset can't be combined with scrolling", nil)
+		return
+	}
+	page, err := cfg.parsePagination(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	loc, err := parseTimezone(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	q, err := queryValue(query, "q")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	after := scrollToken{CreatedAt: maxTimestamp, Rowid: math.MaxInt64}
+	token, err := queryValue(query, "scrollToken")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if token != "" {
+		after, err = decodeScrollToken(token, q, time.Now())
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+	} else if v, err := queryValue(query, "scroll"); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	} else if v != "true" {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("scroll must be true, got %q", v), nil)
+		return
+	}
+
+	// One extra row tells us whether another page follows.
+	rows, err := cfg.dbFor(r).SearchNotesForUserAfter(r.Context(), database.SearchNotesForUserAfterParams{
+		UserID:         user.ID,
+		Pattern:        likePattern(q),
+		AfterCreatedAt: after.CreatedAt,
+		AfterRowid:     after.Rowid,
+		Limit:          int64(page.Limit) + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't search notes", err)
+		return
+	}
+
+	resp := response{Notes: []Note{}}
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		resp.NextScrollToken = encodeScrollToken(scrollToken{
+			CreatedAt: last.CreatedAt,
+			Rowid:     last.Rowid,
+			Query:     q,
+			Expires:   time.Now().Add(scrollTokenTTL).Unix(),
+		})
+	}
+	for _, row := range rows {
+		note, err := databaseNoteToNote(database.Note{
+			ID:        row.ID,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+			Note:      row.Note,
+			UserID:    row.UserID,
+			Public:    row.Public,
+			Metadata:  row.Metadata,
+			IsPinned:  row.IsPinned,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't convert notes", err)
+			return
+		}
+		resp.Notes = append(resp.Notes, note.In(loc))
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
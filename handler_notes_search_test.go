@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/testutil"
+)
+
+func TestNotesSearchCount(t *testing.T) {
+	h, _ := newTestRouter(t)
+	alice := createTestUser(t, h, "alice")
+	bob := createTestUser(t, h, "bob")
+	for _, body := range []string{
+		"Buy milk",
+		"buy eggs",
+		"100% done",
+		"file_name.txt",
+		"filename.txt",
+		`C:\temp`,
+	} {
+		createTestNote(t, h, alice.ApiKey, body)
+	}
+	createTestNote(t, h, bob.ApiKey, "buy bread")
+
+	tests := []struct {
+		q    string
+		want int64
+	}{
+		{"", 6},
+		{"buy", 2},
+		{"%", 1},
+		{"_", 1},
+		{`\`, 1},
+		{"nothing", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.q, func(t *testing.T) {
+			query := url.Values{"q": {tt.q}}.Encode()
+
+			rec := doRequest(t, h, http.MethodGet, "/v1/notes/search/count?"+query, alice.ApiKey, nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("count status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			count := decodeResponse[struct {
+				Count int64 `json:"count"`
+			}](t, rec).Count
+
+			rec = doRequest(t, h, http.MethodGet, "/v1/notes/search?limit=100&"+query, alice.ApiKey, nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("search status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			results := decodeResponse[[]Note](t, rec)
+
+			if count != tt.want || int64(len(results)) != count {
+				t.Errorf("count = %d, search returned %d notes, want %d for both", count, len(results), tt.want)
+			}
+		})
+	}
+}
+
+type scrollPage struct {
+	Notes           []Note `json:"notes"`
+	NextScrollToken string `json:"next_scroll_token"`
+}
+
+func TestNotesSearchScroll(t *testing.T) {
+	h, db := newTestRouter(t)
+	user := testutil.SeedUser(t, db, "alice")
+	testutil.SeedNotes(t, db, user.ID, 5)
+	// These land in the same second, so only the rowid orders them.
+	for _, body := range []string{"note a", "note b", "skip me", "note c"} {
+		createTestNote(t, h, user.ApiKey, body)
+	}
+	other := testutil.SeedUser(t, db, "bob")
+	testutil.SeedNotes(t, db, other.ID, 2)
+
+	var got []string
+	var sizes []int
+	path := "/v1/notes/search?q=note&limit=3&scroll=true"
+	for path != "" {
+		if len(sizes) > 5 {
+			t.Fatal("scroll never reached the end")
+		}
+		rec := doRequest(t, h, http.MethodGet, path, user.ApiKey, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want %d", path, rec.Code, http.StatusOK)
+		}
+		page := decodeResponse[scrollPage](t, rec)
+		sizes = append(sizes, len(page.Notes))
+		for _, note := range page.Notes {
+			got = append(got, note.Note)
+		}
+		path = ""
+		if page.NextScrollToken != "" {
+			path = "/v1/notes/search?q=note&limit=3&scrollToken=" + url.QueryEscape(page.NextScrollToken)
+		}
+	}
+
+	want := []string{"note c", "note b", "note a", "note 4", "note 3", "note 2", "note 1", "note 0"}
+	if !slices.Equal(got, want) {
+		t.Errorf("scrolled through %q, want %q", got, want)
+	}
+	if !slices.Equal(sizes, []int{3, 3, 2}) {
+		t.Errorf("page sizes = %v, want [3 3 2]", sizes)
+	}
+}
+
+func TestNotesSearchScrollRejectsBadTokens(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	createTestNote(t, h, user.ApiKey, "note")
+
+	valid := scrollToken{CreatedAt: maxTimestamp, Rowid: 1, Query: "note", Expires: time.Now().Add(time.Minute).Unix()}
+	expired := valid
+	expired.Expires = time.Now().Add(-time.Second).Unix()
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"garbage", "q=note&scrollToken=not-a-token"},
+		{"expired", "q=note&scrollToken=" + encodeScrollToken(expired)},
+		{"different query", "q=other&scrollToken=" + encodeScrollToken(valid)},
+		{"scroll not true", "q=note&scroll=yes"},
+		{"with offset", "q=note&scroll=true&offset=3"},
+	}
+	for _, tt := range tests {
+		rec := doRequest(t, h, http.MethodGet, "/v1/notes/search?"+tt.query, user.ApiKey, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, http.StatusBadRequest)
+		}
+	}
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes/search?q=note&scrollToken="+encodeScrollToken(valid), user.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Errorf("valid token status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (cfg *apiConfig) handlerNotesStream(w http.ResponseWriter, r *http.Request, user database.User) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "Streaming unsupported", nil)
+		return
+	}
+
+	events, unsubscribe := cfg.NoteEvents.Subscribe(user.ID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case note := <-events:
+			noteResp, err := databaseNoteToNote(note)
+			if err != nil {
+				log.Printf("Error converting streamed note: %s", err)
+				continue
+			}
+			dat, err := json.Marshal(noteResp)
+			if err != nil {
+				log.Printf("Error marshalling streamed note: %s", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: note\ndata: %s\n\n", dat); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
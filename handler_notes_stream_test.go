@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotesStreamReceivesCreatedNote(t *testing.T) {
+	h, _ := newTestRouter(t)
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	user := createTestUser(t, h, "alice")
+
+	body := openNotesStream(t, srv.URL, user.ApiKey)
+	created := createTestNote(t, h, user.ApiKey, "live")
+
+	note := readStreamedNote(t, body)
+	if note.ID != created.ID || note.Note != "live" {
+		t.Errorf("streamed note = %+v, want %+v", note, created)
+	}
+}
+
+func TestNotesStreamOutlivesWriteTimeout(t *testing.T) {
+	h, _ := newTestRouter(t)
+	srv := httptest.NewUnstartedServer(h)
+	srv.Config = newServer("", h, serverTimeouts{Read: 50 * time.Millisecond, Write: 50 * time.Millisecond}, 0)
+	srv.Start()
+	t.Cleanup(srv.Close)
+	user := createTestUser(t, h, "alice")
+
+	body := openNotesStream(t, srv.URL, user.ApiKey)
+	time.Sleep(150 * time.Millisecond)
+	created := createTestNote(t, h, user.ApiKey, "late")
+
+	if note := readStreamedNote(t, body); note.ID != created.ID {
+		t.Errorf("streamed note = %+v, want %+v", note, created)
+	}
+}
+
+func openNotesStream(t *testing.T, baseURL, apiKey string) io.Reader {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/v1/notes/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "ApiKey "+apiKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /v1/notes/stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+	return resp.Body
+}
+
+func readStreamedNote(t *testing.T, body io.Reader) Note {
+	t.Helper()
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var note Note
+		if err := json.Unmarshal([]byte(data), &note); err != nil {
+			t.Fatalf("decoding event data %q: %v", data, err)
+		}
+		return note
+	}
+	t.Fatalf("stream ended without an event: %v", scanner.Err())
+	return Note{}
+}
@@ -0,0 +1,858 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+	"github.com/bootdotdev/learn-cicd-starter/internal/testutil"
+)
+
+func TestNotesCreateAndList(t *testing.T) {
+	h, _ := newTestRouter(t)
+	alice := createTestUser(t, h, "alice")
+	bob := createTestUser(t, h, "bob")
+
+	created := createTestNote(t, h, alice.ApiKey, "first")
+	createTestNote(t, h, bob.ApiKey, "not alice's")
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes", alice.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /v1/notes status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	notes := decodeResponse[[]Note](t, rec)
+	if len(notes) != 1 || notes[0].ID != created.ID || notes[0].Note != "first" {
+		t.Errorf("GET /v1/notes = %+v, want only %+v", notes, created)
+	}
+}
+
+func TestNotesResponsesIncludeUserID(t *testing.T) {
+	h, _ := newTestRouter(t)
+	alice := createTestUser(t, h, "alice")
+	createTestUser(t, h, "bob")
+
+	created := createTestNote(t, h, alice.ApiKey, "mine")
+	if created.UserID != alice.ID {
+		t.Errorf("POST /v1/notes user_id = %q, want %q", created.UserID, alice.ID)
+	}
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes/"+created.ID, alice.ApiKey, nil)
+	if got := decodeResponse[Note](t, rec).UserID; got != alice.ID {
+		t.Errorf("GET /v1/notes/{noteID} user_id = %q, want %q", got, alice.ID)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/v1/notes", alice.ApiKey, nil)
+	notes := decodeResponse[[]Note](t, rec)
+	if len(notes) != 1 || notes[0].UserID != alice.ID {
+		t.Errorf("GET /v1/notes = %+v, want one note with user_id %q", notes, alice.ID)
+	}
+}
+
+func TestNotesCreateLocation(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/notes", user.ApiKey, map[string]string{"note": "hi"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	location := rec.Header().Get("Location")
+	if note := decodeResponse[Note](t, rec); location != "/v1/notes/"+note.ID {
+		t.Fatalf("Location = %q, want /v1/notes/%s", location, note.ID)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, location, user.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET Location status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestNotesUpdateRecordsHistory(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "v1")
+
+	for _, body := range []string{"v2", "v3"} {
+		rec := doRequest(t, h, http.MethodPatch, "/v1/notes/"+note.ID, user.ApiKey, map[string]string{"note": body})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("PATCH status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		if got := decodeResponse[Note](t, rec).Note; got != body {
+			t.Errorf("PATCH note = %q, want %q", got, body)
+		}
+	}
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes/"+note.ID+"/history", user.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET history status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	revisions := decodeResponse[[]NoteRevision](t, rec)
+	if len(revisions) != 2 || revisions[0].Note != "v2" || revisions[1].Note != "v1" {
+		t.Errorf("GET history = %+v, want revisions v2 then v1", revisions)
+	}
+}
+
+func TestNotesUpdateBodyID(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "v1")
+	other := createTestNote(t, h, user.ApiKey, "other")
+
+	tests := []struct {
+		name       string
+		body       map[string]string
+		wantStatus int
+		wantNote   string
+	}{
+		{"absent", map[string]string{"note": "v2"}, http.StatusOK, "v2"},
+		{"matching", map[string]string{"id": note.ID, "note": "v3"}, http.StatusOK, "v3"},
+		{"matching in upper case", map[string]string{"id": strings.ToUpper(note.ID), "note": "v4"}, http.StatusOK, "v4"},
+		{"other note", map[string]string{"id": other.ID, "note": "v5"}, http.StatusUnprocessableEntity, "v4"},
+		{"not a uuid", map[string]string{"id": "nope", "note": "v6"}, http.StatusUnprocessableEntity, "v4"},
+	}
+	for _, tt := range tests {
+		rec := doRequest(t, h, http.MethodPatch, "/v1/notes/"+note.ID, user.ApiKey, tt.body)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+		rec = doRequest(t, h, http.MethodGet, "/v1/notes/"+note.ID, user.ApiKey, nil)
+		if got := decodeResponse[Note](t, rec).Note; got != tt.wantNote {
+			t.Errorf("%s: note = %q, want %q", tt.name, got, tt.wantNote)
+		}
+	}
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes/"+other.ID, user.ApiKey, nil)
+	if got := decodeResponse[Note](t, rec).Note; got != "other" {
+		t.Errorf("other note = %q, want it untouched", got)
+	}
+}
+
+func TestNotesHistoryEnforcesOwnership(t *testing.T) {
+	h, _ := newTestRouter(t)
+	owner := createTestUser(t, h, "alice")
+	other := createTestUser(t, h, "bob")
+	note := createTestNote(t, h, owner.ApiKey, "private")
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes/"+note.ID+"/history", other.ApiKey, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET history as non-owner status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	rec = doRequest(t, h, http.MethodPatch, "/v1/notes/"+note.ID, other.ApiKey, map[string]string{"note": "hijacked"})
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("PATCH as non-owner status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestNotesStats(t *testing.T) {
+	h, db := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+
+	now := time.Now().UTC()
+	for i, age := range []time.Duration{time.Hour, 3 * 24 * time.Hour, 10 * 24 * time.Hour, 60 * 24 * time.Hour} {
+		createdAt := now.Add(-age).Format(time.RFC3339)
+		err := db.CreateNote(context.Background(), database.CreateNoteParams{
+			ID:        string(rune('a' + i)),
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+			Note:      "seeded",
+			UserID:    user.ID,
+			Metadata:  emptyNoteMetadata,
+		})
+		if err != nil {
+			t.Fatalf("seeding note: %v", err)
+		}
+	}
+
+	for noteID, tags := range map[string][]string{
+		"a": {"work", "ideas"},
+		"b": {"work", "ideas"},
+		"c": {"work"},
+		"d": {"s1", "s2", "s3", "s4"},
+	} {
+		for _, tag := range tags {
+			if _, err := db.AddNoteTag(context.Background(), database.AddNoteTagParams{NoteID: noteID, Tag: tag}); err != nil {
+				t.Fatalf("seeding tag: %v", err)
+			}
+		}
+	}
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes/stats", user.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /v1/notes/stats status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	stats := decodeResponse[struct {
+		Total      int64      `json:"total"`
+		Last7Days  int64      `json:"last_7_days"`
+		Last30Days int64      `json:"last_30_days"`
+		TopTags    []TagCount `json:"top_tags"`
+	}](t, rec)
+	if stats.Total != 4 || stats.Last7Days != 2 || stats.Last30Days != 3 {
+		t.Errorf("stats = %d total, %d last 7 days, %d last 30 days, want 4, 2 and 3", stats.Total, stats.Last7Days, stats.Last30Days)
+	}
+	wantTags := []TagCount{{"work", 3}, {"ideas", 2}, {"s1", 1}, {"s2", 1}, {"s3", 1}}
+	if !slices.Equal(stats.TopTags, wantTags) {
+		t.Errorf("top_tags = %v, want %v", stats.TopTags, wantTags)
+	}
+}
+
+func TestNotesIDs(t *testing.T) {
+	h, db := newTestRouter(t)
+	alice := createTestUser(t, h, "alice")
+	bob := createTestUser(t, h, "bob")
+	ids := testutil.SeedNotes(t, db, alice.ID, 5)
+	createTestNote(t, h, bob.ApiKey, "bob's note")
+	sort.Strings(ids)
+
+	listIDs := func(query string) []string {
+		t.Helper()
+		rec := doRequest(t, h, http.MethodGet, "/v1/notes/ids"+query, alice.ApiKey, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /v1/notes/ids%s status = %d, want %d", query, rec.Code, http.StatusOK)
+		}
+		if query != "" && !strings.Contains(rec.Header().Get("Link"), `rel="next"`) {
+			t.Errorf("Link = %q, want a next page", rec.Header().Get("Link"))
+		}
+		entries := decodeResponse[[]map[string]any](t, rec)
+		got := make([]string, len(entries))
+		for i, entry := range entries {
+			if _, ok := entry["note"]; ok {
+				t.Errorf("entry %v includes the note body", entry)
+			}
+			if entry["updated_at"] == nil || entry["updated_at"] == "" {
+				t.Errorf("entry %v has no updated_at", entry)
+			}
+			got[i], _ = entry["id"].(string)
+		}
+		return got
+	}
+
+	if got := listIDs(""); !reflect.DeepEqual(got, ids) {
+		t.Errorf("IDs = %v, want %v", got, ids)
+	}
+	if got := listIDs("?limit=2&offset=2"); !reflect.DeepEqual(got, ids[2:4]) {
+		t.Errorf("second page = %v, want %v", got, ids[2:4])
+	}
+}
+
+func TestNotesChecksum(t *testing.T) {
+	h, db := newTestRouter(t)
+	alice := createTestUser(t, h, "alice")
+	bob := createTestUser(t, h, "bob")
+	checksum := func(apiKey string) (string, int) {
+		t.Helper()
+		rec := doRequest(t, h, http.MethodGet, "/v1/notes/checksum", apiKey, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		resp := decodeResponse[struct {
+			Checksum string `json:"checksum"`
+			Count    int    `json:"count"`
+		}](t, rec)
+		return resp.Checksum, resp.Count
+	}
+
+	empty, count := checksum(alice.ApiKey)
+	if count != 0 || empty == "" {
+		t.Errorf("empty collection: checksum %q, count %d", empty, count)
+	}
+	ids := testutil.SeedNotes(t, db, alice.ID, 3)
+	createTestNote(t, h, bob.ApiKey, "bob's note")
+
+	before, count := checksum(alice.ApiKey)
+	if count != 3 || before == empty {
+		t.Errorf("after seeding: checksum %q, count %d; want a new checksum and 3", before, count)
+	}
+	if again, _ := checksum(alice.ApiKey); again != before {
+		t.Errorf("checksum changed without edits: %q then %q", before, again)
+	}
+
+	// The oldest seeded note is dated two seconds ago, so the edit moves
+	// its updated_at.
+	rec := doRequest(t, h, http.MethodPatch, "/v1/notes/"+ids[0], alice.ApiKey, map[string]string{"note": "edited"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PATCH status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	after, _ := checksum(alice.ApiKey)
+	if after == before {
+		t.Error("checksum unchanged after an edit")
+	}
+	if again, _ := checksum(alice.ApiKey); again != after {
+		t.Errorf("checksum changed without edits: %q then %q", after, again)
+	}
+}
+
+func TestNotesRejectMalformedNoteID(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "body")
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       any
+		wantStatus int
+	}{
+		{"history valid id", http.MethodGet, "/v1/notes/" + note.ID + "/history", nil, http.StatusOK},
+		{"history unknown id", http.MethodGet, "/v1/notes/00000000-0000-0000-0000-000000000000/history", nil, http.StatusNotFound},
+		{"history malformed id", http.MethodGet, "/v1/notes/not-a-uuid/history", nil, http.StatusBadRequest},
+		{"update valid id", http.MethodPatch, "/v1/notes/" + note.ID, map[string]string{"note": "new"}, http.StatusOK},
+		{"update malformed id", http.MethodPatch, "/v1/notes/1234", map[string]string{"note": "new"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, h, tt.method, tt.path, user.ApiKey, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestNoteGetRenderHTML(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	other := createTestUser(t, h, "bob")
+	note := createTestNote(t, h, user.ApiKey, "# Plan\n\nRead [docs](https://example.com) <b>now</b>")
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes/"+note.ID, user.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET note status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	plain := decodeResponse[map[string]any](t, rec)
+	if plain["note"] != note.Note {
+		t.Errorf("note = %v, want %q", plain["note"], note.Note)
+	}
+	if _, ok := plain["html"]; ok {
+		t.Error("plain GET includes html")
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/v1/notes/"+note.ID+"?render=html", user.ApiKey, nil)
+	rendered := decodeResponse[map[string]any](t, rec)
+	wantHTML := "<h1>Plan</h1>\n<p>Read <a href=\"https://example.com\">docs</a> &lt;b&gt;now&lt;/b&gt;</p>\n"
+	if rendered["html"] != wantHTML {
+		t.Errorf("html = %q, want %q", rendered["html"], wantHTML)
+	}
+	if rendered["note"] != note.Note {
+		t.Errorf("rendered note body = %v, want raw %q", rendered["note"], note.Note)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/v1/notes/"+note.ID+"?render=pdf", user.ApiKey, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("render=pdf status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	rec = doRequest(t, h, http.MethodGet, "/v1/notes/"+note.ID, other.ApiKey, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET note as non-owner status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestNoteGetRange(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "0123456789abcdef")
+
+	get := func(header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/notes/"+note.ID, nil)
+		req.Header = header
+		req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("valid range", func(t *testing.T) {
+		rec := get(http.Header{"Range": {"bytes=2-5"}})
+		if rec.Code != http.StatusPartialContent {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusPartialContent)
+		}
+		if got := rec.Body.String(); got != "2345" {
+			t.Errorf("body = %q, want %q", got, "2345")
+		}
+		if got := rec.Header().Get("Content-Range"); got != "bytes 2-5/16" {
+			t.Errorf("Content-Range = %q, want %q", got, "bytes 2-5/16")
+		}
+
+		etag := rec.Header().Get("ETag")
+		rec = get(http.Header{"Range": {"bytes=-3"}, "If-Range": {etag}})
+		if rec.Code != http.StatusPartialContent || rec.Body.String() != "def" {
+			t.Errorf("matching If-Range = %d %q, want 206 %q", rec.Code, rec.Body, "def")
+		}
+		rec = get(http.Header{"Range": {"bytes=-3"}, "If-Range": {`"stale"`}})
+		if rec.Code != http.StatusOK || rec.Body.String() != note.Note {
+			t.Errorf("stale If-Range = %d %q, want 200 with the whole body", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("unsatisfiable range", func(t *testing.T) {
+		rec := get(http.Header{"Range": {"bytes=100-200"}})
+		if rec.Code != http.StatusRequestedRangeNotSatisfiable {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusRequestedRangeNotSatisfiable)
+		}
+		if got := rec.Header().Get("Content-Range"); got != "bytes */16" {
+			t.Errorf("Content-Range = %q, want %q", got, "bytes */16")
+		}
+	})
+
+	t.Run("full request", func(t *testing.T) {
+		for _, header := range []http.Header{{}, {"Range": {"lines=1-2"}}} {
+			rec := get(header)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			if got := decodeResponse[Note](t, rec); got.ID != note.ID || got.Note != note.Note {
+				t.Errorf("Range %q: note = %+v, want the JSON note", header.Get("Range"), got)
+			}
+		}
+	})
+}
+
+// lockedNotesStore fails every CreateNote as SQLite does under write
+// contention.
+type lockedNotesStore struct {
+	store.Store
+	calls int
+}
+
+func (s *lockedNotesStore) CreateNote(ctx context.Context, arg database.CreateNoteParams) error {
+	s.calls++
+	return errors.New("SQLite error: database is locked")
+}
+
+func TestNotesCreateDatabaseLocked(t *testing.T) {
+	var locked *lockedNotesStore
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		locked = &lockedNotesStore{Store: cfg.DB}
+		cfg.DB = store.WithRetry(locked, 3, time.Millisecond)
+	})
+	user := createTestUser(t, h, "alice")
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/notes", user.ApiKey, map[string]string{"note": "hi"})
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Retry-After header missing")
+	}
+	if locked.calls != 3 {
+		t.Errorf("CreateNote calls = %d, want 3", locked.calls)
+	}
+}
+
+func TestNotesListHasTags(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	tagged := createTestNote(t, h, user.ApiKey, "tagged")
+	untagged := createTestNote(t, h, user.ApiKey, "untagged")
+	rec := doRequest(t, h, http.MethodPost, "/v1/tags/work/assign", user.ApiKey, map[string][]string{"note_ids": {tagged.ID}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("assign status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"?hasTags=true", []string{tagged.ID}},
+		{"?hasTags=false", []string{untagged.ID}},
+		{"", []string{untagged.ID, tagged.ID}},
+	}
+	for _, tt := range tests {
+		rec := doRequest(t, h, http.MethodGet, "/v1/notes"+tt.query, user.ApiKey, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /v1/notes%s status = %d, want %d", tt.query, rec.Code, http.StatusOK)
+		}
+		notes := decodeResponse[[]Note](t, rec)
+		var got []string
+		for _, note := range notes {
+			got = append(got, note.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("GET /v1/notes%s = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/v1/notes?hasTags=maybe", user.ApiKey, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("hasTags=maybe status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestNotesDuplicate(t *testing.T) {
+	h, db := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	source := createTestNote(t, h, user.ApiKey, "template")
+	rec := doRequest(t, h, http.MethodPost, "/v1/tags/work/assign", user.ApiKey, map[string][]string{"note_ids": {source.ID}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("assign status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	rec = doRequest(t, h, http.MethodPost, "/v1/notes/"+source.ID+"/duplicate", user.ApiKey, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("duplicate status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	dup := decodeResponse[Note](t, rec)
+	if dup.ID == source.ID || dup.Note != "template" || dup.UserID != user.ID {
+		t.Fatalf("duplicate = %+v, want a new note copying %+v", dup, source)
+	}
+	if tags, err := db.GetTagsForNote(context.Background(), dup.ID); err != nil || !slices.Equal(tags, []string{"work"}) {
+		t.Errorf("duplicate tags = %v (err %v), want [work]", tags, err)
+	}
+
+	rec = doRequest(t, h, http.MethodPatch, "/v1/notes/"+dup.ID, user.ApiKey, map[string]string{"note": "edited"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PATCH duplicate status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	rec = doRequest(t, h, http.MethodGet, "/v1/notes/"+source.ID, user.ApiKey, nil)
+	if got := decodeResponse[Note](t, rec); got.Note != "template" {
+		t.Errorf("source note = %q after editing the copy, want %q", got.Note, "template")
+	}
+}
+
+func TestNotesDuplicateEnforcesOwnership(t *testing.T) {
+	h, _ := newTestRouter(t)
+	owner := createTestUser(t, h, "alice")
+	other := createTestUser(t, h, "bob")
+	note := createTestNote(t, h, owner.ApiKey, "private")
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/notes/"+note.ID+"/duplicate", other.ApiKey, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("duplicate as non-owner status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+// newBusyNoteRouter is newTestRouter with a note of alice's whose lookups
+// fail with store.ErrBusy.
+func newBusyNoteRouter(t *testing.T) (http.Handler, User, Note) {
+	t.Helper()
+	busy := &busyNoteStore{}
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		busy.Store = cfg.DB
+		cfg.DB = busy
+	})
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "busy")
+	busy.noteID = note.ID
+	return h, user, note
+}
+
+func TestNotesDuplicateLookupError(t *testing.T) {
+	h, user, note := newBusyNoteRouter(t)
+	rec := doRequest(t, h, http.MethodPost, "/v1/notes/"+note.ID+"/duplicate", user.ApiKey, nil)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("duplicate with a busy lookup status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestNotesPinnedLookupError(t *testing.T) {
+	h, user, note := newBusyNoteRouter(t)
+	rec := doRequest(t, h, http.MethodPost, "/v1/notes/"+note.ID+"/pin", user.ApiKey, nil)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("pin with a busy lookup status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestNotesLookupError(t *testing.T) {
+	h, user, note := newBusyNoteRouter(t)
+	for _, tt := range []struct {
+		method, path string
+		body         any
+	}{
+		{http.MethodGet, "/v1/notes/" + note.ID, nil},
+		{http.MethodPatch, "/v1/notes/" + note.ID, map[string]string{"note": "updated"}},
+		{http.MethodGet, "/v1/notes/" + note.ID + "/history", nil},
+	} {
+		rec := doRequest(t, h, tt.method, tt.path, user.ApiKey, tt.body)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s with a busy lookup status = %d, want %d", tt.method, tt.path, rec.Code, http.StatusServiceUnavailable)
+		}
+	}
+}
+
+func TestNotesCreateRejectsInvisibleBody(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"zero-width space", "\u200b", http.StatusUnprocessableEntity},
+		{"control characters", "\u200b\a\ufeff\n", http.StatusUnprocessableEntity},
+		{"emoji", "🎉 shipped 👩\u200d💻", http.StatusCreated},
+		{"zero-width joiner inside text", "a\u200db", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		rec := doRequest(t, h, http.MethodPost, "/v1/notes", user.ApiKey, map[string]string{"note": tt.body})
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+			continue
+		}
+		if tt.wantStatus == http.StatusCreated {
+			if got := decodeResponse[Note](t, rec); got.Note != tt.body {
+				t.Errorf("%s: note = %q, want %q unchanged", tt.name, got.Note, tt.body)
+			}
+		} else if fields := decodeResponse[errorResponse](t, rec).Fields; len(fields) != 1 || fields[0].Field != "note" {
+			t.Errorf("%s: fields = %+v, want one error for note", tt.name, fields)
+		}
+	}
+}
+
+func TestNotesUpdateBodyLimits(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "original")
+	tooLong := strings.Repeat("a", 10001)
+
+	for _, tt := range []struct {
+		name   string
+		update func() *httptest.ResponseRecorder
+	}{
+		{"PATCH", func() *httptest.ResponseRecorder {
+			return doRequest(t, h, http.MethodPatch, "/v1/notes/"+note.ID, user.ApiKey, map[string]string{"note": tooLong})
+		}},
+		{"JSON Patch", func() *httptest.ResponseRecorder {
+			return doJSONPatch(t, h, note.ID, user.ApiKey, `[{"op":"replace","path":"/note","value":"`+tooLong+`"}]`)
+		}},
+	} {
+		rec := tt.update()
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s with a 10001 character body status = %d, want %d", tt.name, rec.Code, http.StatusUnprocessableEntity)
+			continue
+		}
+		if fields := decodeResponse[errorResponse](t, rec).Fields; len(fields) != 1 || fields[0].Field != "note" {
+			t.Errorf("%s: fields = %+v, want one error for note", tt.name, fields)
+		}
+	}
+
+	rec := doRequest(t, h, http.MethodPatch, "/v1/notes/"+note.ID, user.ApiKey, map[string]string{"note": tooLong[1:]})
+	if rec.Code != http.StatusOK {
+		t.Errorf("PATCH with a 10000 character body status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestNotesUpdateRejectsBlankBody(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "original")
+
+	for _, body := range []string{" \t\n ", "\u200b\ufeff"} {
+		value, _ := json.Marshal(body)
+		for name, rec := range map[string]*httptest.ResponseRecorder{
+			"PATCH":      doRequest(t, h, http.MethodPatch, "/v1/notes/"+note.ID, user.ApiKey, map[string]string{"note": body}),
+			"JSON Patch": doJSONPatch(t, h, note.ID, user.ApiKey, `[{"op":"replace","path":"/note","value":`+string(value)+`}]`),
+		} {
+			if rec.Code != http.StatusUnprocessableEntity {
+				t.Errorf("%s with body %q status = %d, want %d", name, body, rec.Code, http.StatusUnprocessableEntity)
+			}
+		}
+	}
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes/"+note.ID, user.ApiKey, nil)
+	if got := decodeResponse[Note](t, rec); got.Note != "original" {
+		t.Errorf("note = %q after rejected updates, want %q", got.Note, "original")
+	}
+}
+
+func TestNotesCreateEmptyBody(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+		wantError  string
+	}{
+		{"no body", "", http.StatusBadRequest, errCodeBodyRequired, "Request body is required"},
+		{"whitespace only", " \n\t ", http.StatusBadRequest, errCodeBodyRequired, "Request body is required"},
+		{"malformed", `{"note":`, http.StatusBadRequest, errCodeInvalidJSON, "Request body is not valid JSON"},
+		{"syntax error", `{"note" "hi"}`, http.StatusBadRequest, errCodeInvalidJSON, "Request body is not valid JSON"},
+		{"wrong type", `{"note": 1}`, http.StatusBadRequest, errCodeInvalidJSON, "Request body is not valid JSON"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/v1/notes", strings.NewReader(tt.body))
+		req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+			continue
+		}
+		body := decodeResponse[errorResponse](t, rec)
+		if body.Code != tt.wantCode {
+			t.Errorf("%s: code = %q, want %q", tt.name, body.Code, tt.wantCode)
+		}
+		if body.Error != tt.wantError {
+			t.Errorf("%s: error = %q, want %q", tt.name, body.Error, tt.wantError)
+		}
+	}
+}
+
+func TestDecodeInvalidJSON(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "hi")
+
+	for _, route := range []struct{ method, path, apiKey string }{
+		{http.MethodPost, "/v1/users", ""},
+		{http.MethodPatch, "/v1/users", user.ApiKey},
+		{http.MethodPost, "/v1/notes", user.ApiKey},
+		{http.MethodPost, "/v1/notes/batch", user.ApiKey},
+		{http.MethodPatch, "/v1/notes/" + note.ID, user.ApiKey},
+		{http.MethodPost, "/v1/notes/" + note.ID + "/merge", user.ApiKey},
+		{http.MethodPost, "/v1/tags/work/assign", user.ApiKey},
+		{http.MethodPost, "/v1/notes/search/tag", user.ApiKey},
+	} {
+		req := httptest.NewRequest(route.method, route.path, strings.NewReader(`{"note":`))
+		if route.apiKey != "" {
+			req.Header.Set("Authorization", "ApiKey "+route.apiKey)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: status = %d, want %d", route.method, route.path, rec.Code, http.StatusBadRequest)
+			continue
+		}
+		if body := decodeResponse[errorResponse](t, rec); body.Code != errCodeInvalidJSON {
+			t.Errorf("%s %s: code = %q, want %q", route.method, route.path, body.Code, errCodeInvalidJSON)
+		}
+	}
+}
+
+func TestNotesCharAndWordCount(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+
+	tests := []struct {
+		name      string
+		body      string
+		wantChars int
+		wantWords int
+	}{
+		{"ascii", "hello world", 11, 2},
+		{"multi-byte", "héllo wörld 👋", 13, 3},
+		{"combining mark", "cafe\u0301", 5, 1},
+		{"repeated whitespace", " a \t\n  b\u3000c ", 11, 3},
+	}
+	for _, tt := range tests {
+		note := createTestNote(t, h, user.ApiKey, tt.body)
+		if note.CharCount != tt.wantChars || note.WordCount != tt.wantWords {
+			t.Errorf("%s: char_count = %d, word_count = %d, want %d and %d", tt.name, note.CharCount, note.WordCount, tt.wantChars, tt.wantWords)
+		}
+	}
+
+	// The counts follow edits rather than being stored.
+	note := createTestNote(t, h, user.ApiKey, "one")
+	rec := doRequest(t, h, http.MethodPatch, "/v1/notes/"+note.ID, user.ApiKey, map[string]string{"note": "one two three"})
+	if got := decodeResponse[Note](t, rec); got.CharCount != 13 || got.WordCount != 3 {
+		t.Errorf("after update: char_count = %d, word_count = %d, want 13 and 3", got.CharCount, got.WordCount)
+	}
+	rec = doJSONPatch(t, h, note.ID, user.ApiKey, `[{"op":"replace","path":"/word_count","value":100}]`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("patching word_count: status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestNotesRecent(t *testing.T) {
+	h, db := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.RecentNotes = 3
+	})
+	user := testutil.SeedUser(t, db, "alice")
+	ids := testutil.SeedNotes(t, db, user.ID, 5)
+	// Pins put a note first in the list but not in recent.
+	err := db.SetNotePinned(context.Background(), database.SetNotePinnedParams{
+		IsPinned:  true,
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+		ID:        ids[0],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes/recent", user.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	got := decodeResponse[[]map[string]string](t, rec)
+	want := []string{ids[4], ids[3], ids[2]}
+	if len(got) != len(want) {
+		t.Fatalf("got %d notes, want %d", len(got), len(want))
+	}
+	for i, note := range got {
+		if note["id"] != want[i] {
+			t.Errorf("notes[%d].id = %q, want %q", i, note["id"], want[i])
+		}
+		if _, ok := note["user_id"]; ok || len(note) != 3 {
+			t.Errorf("notes[%d] = %v, want only id, created_at and note", i, note)
+		}
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/v1/notes?limit=1", user.ApiKey, nil)
+	if list := decodeResponse[[]Note](t, rec); len(list) != 1 || list[0].ID != ids[0] {
+		t.Errorf("list = %+v, want the pinned note %s first", list, ids[0])
+	}
+}
+
+func TestNotesPinned(t *testing.T) {
+	h, db := newTestRouter(t)
+	user := testutil.SeedUser(t, db, "alice")
+	other := createTestUser(t, h, "bob")
+	ids := testutil.SeedNotes(t, db, user.ID, 4)
+
+	listIDs := func() []string {
+		t.Helper()
+		rec := doRequest(t, h, http.MethodGet, "/v1/notes", user.ApiKey, nil)
+		var got []string
+		for _, note := range decodeResponse[[]Note](t, rec) {
+			got = append(got, note.ID)
+		}
+		return got
+	}
+	pin := func(id, action string) Note {
+		t.Helper()
+		rec := doRequest(t, h, http.MethodPost, "/v1/notes/"+id+"/"+action, user.ApiKey, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s status = %d, want %d", action, rec.Code, http.StatusOK)
+		}
+		return decodeResponse[Note](t, rec)
+	}
+
+	if note := pin(ids[0], "pin"); !note.Pinned {
+		t.Errorf("pin response pinned = false, want true")
+	}
+	pin(ids[2], "pin")
+	if got, want := listIDs(), []string{ids[2], ids[0], ids[3], ids[1]}; !slices.Equal(got, want) {
+		t.Errorf("list = %v, want pinned notes first, each group newest first: %v", got, want)
+	}
+
+	if note := pin(ids[2], "unpin"); note.Pinned {
+		t.Errorf("unpin response pinned = true, want false")
+	}
+	if got, want := listIDs(), []string{ids[0], ids[3], ids[2], ids[1]}; !slices.Equal(got, want) {
+		t.Errorf("list after unpin = %v, want %v", got, want)
+	}
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes/"+ids[0], user.ApiKey, nil)
+	if !decodeResponse[Note](t, rec).Pinned {
+		t.Error("GET pinned note pinned = false, want true")
+	}
+
+	rec = doRequest(t, h, http.MethodPost, "/v1/notes/"+ids[1]+"/pin", other.ApiKey, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("pin by non-owner status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi"
+)
+
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// postmanExamples holds a sample request body per route, keyed by "METHOD
+// pattern" as chi.Walk reports it. Routes without an entry get no body.
+var postmanExamples = map[string]any{
+	"POST /v1/users":             map[string]any{"name": "alice"},
+	"PATCH /v1/users":            map[string]any{"name": "alicia", "rotateKey": false},
+	"POST /v1/notes":             map[string]any{"note": "Buy milk", "public": false, "metadata": map[string]any{}},
+	"POST /v1/notes/batch":       map[string]any{"notes": []map[string]any{{"note": "First", "public": false}, {"note": "Second", "public": true}}},
+	"PATCH /v1/notes/{noteID}":   map[string]any{"note": "Buy oat milk"},
+	"POST /v1/notes/search/tag":  map[string]any{"q": "invoice", "tag": "billing"},
+	"POST /v1/tags/{tag}/assign": map[string]any{"note_ids": []string{"{{noteID}}"}},
+}
+
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Auth     postmanAuth       `json:"auth"`
+	Variable []postmanKeyValue `json:"variable"`
+	Item     []postmanItem     `json:"item"`
+}
+
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+type postmanAuth struct {
+	Type   string            `json:"type"`
+	APIKey []postmanKeyValue `json:"apikey"`
+}
+
+type postmanKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type postmanItem struct {
+	Name    string         `json:"name"`
+	Request postmanRequest `json:"request"`
+}
+
+type postmanRequest struct {
+	Method string            `json:"method"`
+	Header []postmanKeyValue `json:"header"`
+	URL    postmanURL        `json:"url"`
+	Body   *postmanBody      `json:"body,omitempty"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Variable []postmanKeyValue `json:"variable,omitempty"`
+}
+
+type postmanBody struct {
+	Mode    string         `json:"mode"`
+	Raw     string         `json:"raw"`
+	Options map[string]any `json:"options"`
+}
+
+// handlerPostmanCollection serves a Postman collection of the API routes
+// registered on router, so it can't drift from the real route table. Only
+// routes under /v1 and /public are listed; admin routes stay unadvertised.
+// Every request inherits the collection's ApiKey auth, filled from the
+// apiKey variable, which unauthenticated routes simply ignore.
+func handlerPostmanCollection(router chi.Routes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		collection, err := buildPostmanCollection(router, scheme+"://"+r.Host)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't build collection", err)
+			return
+		}
+
+		// Written directly rather than through respondWithJSON, so the
+		// response envelope never wraps it and Postman can import it as is.
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="notely.postman_collection.json"`)
+		if err := json.NewEncoder(w).Encode(collection); err != nil {
+			log.Printf("Error writing Postman collection: %s", err)
+		}
+	}
+}
+
+func buildPostmanCollection(router chi.Routes, baseURL string) (postmanCollection, error) {
+	collection := postmanCollection{
+		Info: postmanInfo{Name: "Notely", Schema: postmanSchema},
+		Auth: postmanAuth{
+			Type: "apikey",
+			APIKey: []postmanKeyValue{
+				{Key: "key", Value: "Authorization"},
+				{Key: "value", Value: "ApiKey {{apiKey}}"},
+				{Key: "in", Value: "header"},
+			},
+		},
+		Variable: []postmanKeyValue{
+			{Key: "baseUrl", Value: baseURL},
+			{Key: "apiKey", Value: ""},
+		},
+		Item: []postmanItem{},
+	}
+
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if !strings.HasPrefix(route, "/v1/") && !strings.HasPrefix(route, "/public/") {
+			return nil
+		}
+		item, err := postmanRouteItem(method, route)
+		if err != nil {
+			return err
+		}
+		collection.Item = append(collection.Item, item)
+		return nil
+	})
+	if err != nil {
+		return postmanCollection{}, err
+	}
+
+	// chi keeps a route's methods in a map, so Walk's order isn't stable.
+	sort.Slice(collection.Item, func(i, j int) bool {
+		return collection.Item[i].Name < collection.Item[j].Name
+	})
+	return collection, nil
+}
+
+// postmanRouteItem turns a chi pattern such as /v1/notes/{noteID} into
+// Postman's /v1/notes/:noteID, with a variable per path parameter.
+func postmanRouteItem(method, route string) (postmanItem, error) {
+	segments := strings.Split(strings.TrimPrefix(route, "/"), "/")
+	var vars []postmanKeyValue
+	for i, seg := range segments {
+		if name, ok := strings.CutPrefix(seg, "{"); ok {
+			name = strings.TrimSuffix(name, "}")
+			segments[i] = ":" + name
+			vars = append(vars, postmanKeyValue{Key: name, Value: "{{" + name + "}}"})
+		}
+	}
+
+	req := postmanRequest{
+		Method: method,
+		Header: []postmanKeyValue{},
+		URL: postmanURL{
+			Raw:      "{{baseUrl}}/" + strings.Join(segments, "/"),
+			Host:     []string{"{{baseUrl}}"},
+			Path:     segments,
+			Variable: vars,
+		},
+	}
+	if example := postmanExamples[method+" "+route]; example != nil {
+		raw, err := json.MarshalIndent(example, "", "  ")
+		if err != nil {
+			return postmanItem{}, err
+		}
+		req.Header = append(req.Header, postmanKeyValue{Key: "Content-Type", Value: "application/json"})
+		req.Body = &postmanBody{
+			Mode:    "raw",
+			Raw:     string(raw),
+			Options: map[string]any{"raw": map[string]string{"language": "json"}},
+		}
+	}
+	return postmanItem{Name: method + " " + route, Request: req}, nil
+}
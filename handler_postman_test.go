@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+)
+
+func TestPostmanCollection(t *testing.T) {
+	h, _ := newTestRouter(t)
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/postman.json", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if !json.Valid(rec.Body.Bytes()) {
+		t.Fatalf("body is not valid JSON: %s", rec.Body)
+	}
+	collection := decodeResponse[postmanCollection](t, rec)
+	if collection.Info.Schema != postmanSchema {
+		t.Errorf("schema = %q, want %q", collection.Info.Schema, postmanSchema)
+	}
+	if collection.Auth.Type != "apikey" {
+		t.Errorf("auth type = %q, want apikey", collection.Auth.Type)
+	}
+
+	items := make(map[string]postmanItem)
+	for _, item := range collection.Item {
+		items[item.Name] = item
+	}
+	var routes []string
+	err := chi.Walk(h.(chi.Routes), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if strings.HasPrefix(route, "/v1/") || strings.HasPrefix(route, "/public/") {
+			routes = append(routes, method+" "+route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, route := range routes {
+		if _, ok := items[route]; !ok {
+			t.Errorf("collection has no item for %s", route)
+		}
+	}
+	if len(collection.Item) != len(routes) {
+		t.Errorf("collection has %d items, want one per route (%d)", len(collection.Item), len(routes))
+	}
+
+	// A stale example would silently stop appearing in the collection.
+	for route := range postmanExamples {
+		item, ok := items[route]
+		if !ok {
+			t.Errorf("example for %s matches no route", route)
+			continue
+		}
+		if item.Request.Body == nil || !json.Valid([]byte(item.Request.Body.Raw)) {
+			t.Errorf("%s body = %+v, want the JSON example", route, item.Request.Body)
+		}
+	}
+
+	note := items["GET /v1/notes/{noteID}"].Request.URL
+	if note.Raw != "{{baseUrl}}/v1/notes/:noteID" || len(note.Variable) != 1 || note.Variable[0].Key != "noteID" {
+		t.Errorf("GET /v1/notes/{noteID} url = %+v, want a :noteID path variable", note)
+	}
+}
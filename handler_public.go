@@ -0,0 +1,104 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+// publicNote is what an unauthenticated reader sees. It leaves out the
+// owner's user ID.
+type publicNote struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Note      string    `json:"note"`
+}
+
+// handlerPublicNoteGet serves a shared note without authentication. Private
+// and missing notes are indistinguishable 404s.
+func (cfg *apiConfig) handlerPublicNoteGet(w http.ResponseWriter, r *http.Request) {
+	noteID, ok := parseUUIDParam(w, r, "noteID")
+	if !ok {
+		return
+	}
+	loc, err := parseTimezone(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	note, err := cfg.dbFor(r).GetNote(r.Context(), noteID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !note.Public) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get note", err)
+		return
+	}
+
+	noteResp, err := databaseNoteToNote(note)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
+		return
+	}
+	noteResp = noteResp.In(loc)
+	respondWithJSON(w, http.StatusOK, publicNote{
+		ID:        noteResp.ID,
+		CreatedAt: noteResp.CreatedAt,
+		UpdatedAt: noteResp.UpdatedAt,
+		Note:      noteResp.Note,
+	})
+}
+
+// handlerNoteSetPublic returns the owner-only handler behind the publish and
+// unpublish endpoints.
+func (cfg *apiConfig) handlerNoteSetPublic(public bool) authedHandler {
+	return func(w http.ResponseWriter, r *http.Request, user database.User) {
+		noteID, ok := parseUUIDParam(w, r, "noteID")
+		if !ok {
+			return
+		}
+
+		var note database.Note
+		err := cfg.dbFor(r).InTx(r.Context(), func(tx store.Store) error {
+			var err error
+			note, err = getOwnedNote(r.Context(), tx, noteID, user.ID)
+			if err != nil {
+				return err
+			}
+
+			err = tx.SetNotePublic(r.Context(), database.SetNotePublicParams{
+				Public:    public,
+				UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+				ID:        note.ID,
+			})
+			if err != nil {
+				return err
+			}
+
+			note, err = tx.GetNote(r.Context(), note.ID)
+			return err
+		})
+		if errors.Is(err, errNoteNotFound) {
+			respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't update note", err)
+			return
+		}
+
+		noteResp, err := databaseNoteToNote(note)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, noteResp)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPublicNoteLink(t *testing.T) {
+	h, _ := newTestRouter(t)
+	owner := createTestUser(t, h, "alice")
+	other := createTestUser(t, h, "bob")
+	note := createTestNote(t, h, owner.ApiKey, "shared")
+
+	getPublic := func() int {
+		return doRequest(t, h, http.MethodGet, "/public/notes/"+note.ID, "", nil).Code
+	}
+
+	if code := getPublic(); code != http.StatusNotFound {
+		t.Fatalf("private note status = %d, want %d", code, http.StatusNotFound)
+	}
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/notes/"+note.ID+"/publish", other.ApiKey, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("publish by non-owner status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if code := getPublic(); code != http.StatusNotFound {
+		t.Fatalf("note published by non-owner: status = %d, want %d", code, http.StatusNotFound)
+	}
+
+	rec = doRequest(t, h, http.MethodPost, "/v1/notes/"+note.ID+"/publish", owner.ApiKey, nil)
+	if rec.Code != http.StatusOK || !decodeResponse[Note](t, rec).Public {
+		t.Fatalf("publish status = %d, want %d with public set", rec.Code, http.StatusOK)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/public/notes/"+note.ID, "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("public note status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	got := decodeResponse[map[string]any](t, rec)
+	if got["note"] != "shared" {
+		t.Errorf("public note = %v, want note %q", got, "shared")
+	}
+	if _, ok := got["user_id"]; ok {
+		t.Errorf("public note = %v, want no user_id", got)
+	}
+
+	rec = doRequest(t, h, http.MethodPost, "/v1/notes/"+note.ID+"/unpublish", owner.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unpublish status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if code := getPublic(); code != http.StatusNotFound {
+		t.Errorf("unpublished note status = %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestPublicNoteUnknown(t *testing.T) {
+	h, _ := newTestRouter(t)
+
+	rec := doRequest(t, h, http.MethodGet, "/public/notes/00000000-0000-0000-0000-000000000000", "", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestPublicNoteLookupError(t *testing.T) {
+	h, user, note := newBusyNoteRouter(t)
+	for _, tt := range []struct{ method, path, apiKey string }{
+		{http.MethodGet, "/public/notes/" + note.ID, ""},
+		{http.MethodPost, "/v1/notes/" + note.ID + "/publish", user.ApiKey},
+	} {
+		rec := doRequest(t, h, tt.method, tt.path, tt.apiKey, nil)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s with a busy lookup status = %d, want %d", tt.method, tt.path, rec.Code, http.StatusServiceUnavailable)
+		}
+	}
+}
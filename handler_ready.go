@@ -1,7 +1,70 @@
 package main
 
-import "net/http"
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+// readyzTimeout bounds each database ping, well below the usual probe
+// timeout so a hung database reports down rather than timing the probe out.
+const readyzTimeout = 2 * time.Second
 
 func handlerReadiness(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
+
+// handlerReadyz pings the primary and, when configured, the read replica.
+// A down primary makes the instance unfit for traffic and answers 503. A
+// down replica only costs read scaling, so it answers 200 with status
+// "degraded" for the load balancer to keep routing here. Ping errors are
+// logged rather than returned, since they can name internal hosts.
+func (cfg *apiConfig) handlerReadyz(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks,omitempty"`
+	}
+
+	dbs := map[string]store.Store{}
+	if cfg.DB != nil {
+		dbs["primary"] = cfg.DB
+	}
+	if cfg.ReadDB != nil {
+		dbs["replica"] = cfg.ReadDB
+	}
+
+	resp := response{Status: "ok", Checks: make(map[string]string, len(dbs))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, db := range dbs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), readyzTimeout)
+			defer cancel()
+			state := "ok"
+			if err := db.Ping(ctx); err != nil {
+				log.Printf("Readiness: %s database is down: %v", name, err)
+				state = "down"
+			}
+			mu.Lock()
+			resp.Checks[name] = state
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	switch {
+	case resp.Checks["primary"] == "down":
+		resp.Status = "unavailable"
+		code = http.StatusServiceUnavailable
+	case resp.Checks["replica"] == "down":
+		resp.Status = "degraded"
+	}
+	respondWithJSON(w, code, resp)
+}
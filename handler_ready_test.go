@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+	"github.com/bootdotdev/learn-cicd-starter/internal/testutil"
+)
+
+// pingStore fails Ping with err when it is set.
+type pingStore struct {
+	store.Store
+	err error
+}
+
+func (s *pingStore) Ping(ctx context.Context) error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.Store.Ping(ctx)
+}
+
+func TestReadyz(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	down := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		primary    error
+		replica    error
+		noReplica  bool
+		wantCode   int
+		wantStatus string
+		wantChecks map[string]string
+	}{
+		{"both healthy", nil, nil, false, http.StatusOK, "ok", map[string]string{"primary": "ok", "replica": "ok"}},
+		{"no replica", nil, nil, true, http.StatusOK, "ok", map[string]string{"primary": "ok"}},
+		{"replica down", nil, down, false, http.StatusOK, "degraded", map[string]string{"primary": "ok", "replica": "down"}},
+		{"primary down", down, nil, false, http.StatusServiceUnavailable, "unavailable", map[string]string{"primary": "down", "replica": "ok"}},
+		{"both down", down, down, false, http.StatusServiceUnavailable, "unavailable", map[string]string{"primary": "down", "replica": "down"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestRouter(t, func(cfg *apiConfig) {
+				cfg.DB = &pingStore{Store: cfg.DB, err: tt.primary}
+				if !tt.noReplica {
+					cfg.ReadDB = &pingStore{Store: testutil.NewStore(t), err: tt.replica}
+				}
+			})
+			rec := doRequest(t, h, http.MethodGet, "/readyz", "", nil)
+			if rec.Code != tt.wantCode {
+				t.Errorf("status code = %d, want %d", rec.Code, tt.wantCode)
+			}
+			body := decodeResponse[struct {
+				Status string            `json:"status"`
+				Checks map[string]string `json:"checks"`
+			}](t, rec)
+			if body.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", body.Status, tt.wantStatus)
+			}
+			if len(body.Checks) != len(tt.wantChecks) {
+				t.Errorf("checks = %v, want %v", body.Checks, tt.wantChecks)
+			}
+			for name, want := range tt.wantChecks {
+				if body.Checks[name] != want {
+					t.Errorf("checks[%s] = %q, want %q", name, body.Checks[name], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+const (
+	defaultMaxTagsPerNote  = 20
+	defaultMaxBulkTagNotes = 500
+	maxTagLength           = 50
+)
+
+var (
+	errEmptyTag    = errors.New("tag must not be empty")
+	errTagTooLong  = fmt.Errorf("tag must be at most %d characters", maxTagLength)
+	errTooManyTags = errors.New("too many tags")
+	// errTooManyMatches aborts a bulk tag whose query matches more than
+	// MaxBulkTagNotes notes.
+	errTooManyMatches = errors.New("too many matching notes")
+)
+
+// normalizeTag trims and lowercases a tag so "Work " and "work" are the same.
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", errEmptyTag
+	}
+	if utf8.RuneCountInString(tag) > maxTagLength {
+		return "", errTagTooLong
+	}
+	return tag, nil
+}
+
+func (cfg *apiConfig) handlerTagsGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	tags, err := cfg.dbFor(r).GetTagCountsForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get tags for user", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseTagCountsToTagCounts(tags))
+}
+
+func (cfg *apiConfig) handlerTagsAssign(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		NoteIDs []string `json:"note_ids"`
+	}
+	type response struct {
+		Tag           string   `json:"tag"`
+		Assigned      int      `json:"assigned"`
+		AlreadyTagged int      `json:"already_tagged"`
+		NoteIDs       []string `json:"note_ids"`
+	}
+
+	// chi matches against RawPath when it is set, leaving params escaped.
+	rawTag, ok := urlParam(w, r, "tag")
+	if !ok {
+		return
+	}
+	if r.URL.RawPath != "" {
+		var err error
+		rawTag, err = url.PathUnescape(rawTag)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid tag", err)
+			return
+		}
+	}
+	tag, err := normalizeTag(rawTag)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid tag: "+err.Error(), err)
+		return
+	}
+
+	params := parameters{}
+	err = decodeJSON(r, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	resp := response{Tag: tag, NoteIDs: []string{}}
+	var fullNoteID string
+	err = cfg.dbFor(r).InTx(r.Context(), func(tx store.Store) error {
+		seen := make(map[string]bool, len(params.NoteIDs))
+		for _, noteID := range params.NoteIDs {
+			if seen[noteID] {
+				continue
+			}
+			seen[noteID] = true
+
+			note, err := tx.GetNote(r.Context(), noteID)
+			if errors.Is(err, sql.ErrNoRows) || (err == nil && note.UserID != user.ID) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			added, err := cfg.addNoteTag(r.Context(), tx, note.ID, tag)
+			if errors.Is(err, errTooManyTags) {
+				fullNoteID = note.ID
+			}
+			if err != nil {
+				return err
+			}
+			if added {
+				resp.Assigned++
+			} else {
+				resp.AlreadyTagged++
+			}
+			resp.NoteIDs = append(resp.NoteIDs, note.ID)
+		}
+		return nil
+	})
+	if errors.Is(err, errTooManyTags) {
+		msg := fmt.Sprintf("Note %s already has the maximum of %d tags", fullNoteID, cfg.MaxTagsPerNote)
+		respondWithError(w, http.StatusUnprocessableEntity, msg, err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't assign tag", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// handlerNotesSearchTag tags every note of the caller's that contains q, as
+// GET /v1/notes/search matches them, in one transaction. A query matching
+// more than MaxBulkTagNotes notes is refused whole rather than tagging an
+// arbitrary subset.
+func (cfg *apiConfig) handlerNotesSearchTag(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Query string `json:"q" validate:"required,notblank"`
+		Tag   string `json:"tag" validate:"required"`
+	}
+	type response struct {
+		Tag           string `json:"tag"`
+		Matched       int    `json:"matched"`
+		Assigned      int    `json:"assigned"`
+		AlreadyTagged int    `json:"already_tagged"`
+	}
+
+	params := parameters{}
+	if err := decodeJSON(r, &params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if !validateParams(w, params) {
+		return
+	}
+	tag, err := normalizeTag(params.Tag)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid tag: "+err.Error(), err)
+		return
+	}
+
+	resp := response{Tag: tag}
+	var fullNoteID string
+	err = cfg.dbFor(r).InTx(r.Context(), func(tx store.Store) error {
+		notes, err := tx.SearchNotesForUser(r.Context(), database.SearchNotesForUserParams{
+			UserID:  user.ID,
+			Pattern: likePattern(params.Query),
+			Limit:   int64(cfg.MaxBulkTagNotes) + 1,
+			Offset:  0,
+		})
+		if err != nil {
+			return err
+		}
+		if len(notes) > cfg.MaxBulkTagNotes {
+			return errTooManyMatches
+		}
+		resp.Matched = len(notes)
+		for _, note := range notes {
+			added, err := cfg.addNoteTag(r.Context(), tx, note.ID, tag)
+			if errors.Is(err, errTooManyTags) {
+				fullNoteID = note.ID
+			}
+			if err != nil {
+				return err
+			}
+			if added {
+				resp.Assigned++
+			} else {
+				resp.AlreadyTagged++
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errTooManyMatches) {
+		msg := fmt.Sprintf("Query matches more than %d notes, narrow it down", cfg.MaxBulkTagNotes)
+		respondWithError(w, http.StatusUnprocessableEntity, msg, err)
+		return
+	}
+	if errors.Is(err, errTooManyTags) {
+		msg := fmt.Sprintf("Note %s already has the maximum of %d tags", fullNoteID, cfg.MaxTagsPerNote)
+		respondWithError(w, http.StatusUnprocessableEntity, msg, err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't assign tag", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// addNoteTag tags noteID unless that would take it past MaxTagsPerNote, and
+// reports whether the tag is new to the note. A tag the note already has
+// doesn't count against the limit.
+func (cfg *apiConfig) addNoteTag(ctx context.Context, tx store.Store, noteID, tag string) (bool, error) {
+	tags, err := tx.GetTagsForNote(ctx, noteID)
+	if err != nil {
+		return false, err
+	}
+	if !slices.Contains(tags, tag) && len(tags) >= cfg.MaxTagsPerNote {
+		return false, errTooManyTags
+	}
+	added, err := tx.AddNoteTag(ctx, database.AddNoteTagParams{
+		NoteID: noteID,
+		Tag:    tag,
+	})
+	return added > 0, err
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+func TestTagsGetCounts(t *testing.T) {
+	h, db := newTestRouter(t)
+	alice := createTestUser(t, h, "alice")
+	bob := createTestUser(t, h, "bob")
+
+	tagNote := func(userKey string, tags ...string) {
+		t.Helper()
+		note := createTestNote(t, h, userKey, "tagged")
+		for _, tag := range tags {
+			if _, err := db.AddNoteTag(context.Background(), database.AddNoteTagParams{NoteID: note.ID, Tag: tag}); err != nil {
+				t.Fatalf("seeding tag: %v", err)
+			}
+		}
+	}
+	tagNote(alice.ApiKey, "work", "urgent")
+	tagNote(alice.ApiKey, "work", "ideas")
+	tagNote(alice.ApiKey, "work", "urgent")
+	tagNote(alice.ApiKey, "books")
+	tagNote(bob.ApiKey, "books", "books-only-bob")
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/tags", alice.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /v1/tags status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	want := []TagCount{
+		{Tag: "work", NoteCount: 3},
+		{Tag: "urgent", NoteCount: 2},
+		{Tag: "books", NoteCount: 1},
+		{Tag: "ideas", NoteCount: 1},
+	}
+	if got := decodeResponse[[]TagCount](t, rec); !reflect.DeepEqual(got, want) {
+		t.Errorf("GET /v1/tags = %+v, want %+v", got, want)
+	}
+}
+
+func TestTagsAssignBatch(t *testing.T) {
+	h, db := newTestRouter(t)
+	alice := createTestUser(t, h, "alice")
+	bob := createTestUser(t, h, "bob")
+
+	untagged := createTestNote(t, h, alice.ApiKey, "untagged")
+	tagged := createTestNote(t, h, alice.ApiKey, "tagged")
+	if _, err := db.AddNoteTag(context.Background(), database.AddNoteTagParams{NoteID: tagged.ID, Tag: "work"}); err != nil {
+		t.Fatalf("seeding tag: %v", err)
+	}
+	unowned := createTestNote(t, h, bob.ApiKey, "bob's")
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/tags/%20Work%20/assign", alice.ApiKey, map[string][]string{
+		"note_ids": {untagged.ID, tagged.ID, unowned.ID, "not-a-note", untagged.ID},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST assign status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	got := decodeResponse[struct {
+		Tag           string   `json:"tag"`
+		Assigned      int      `json:"assigned"`
+		AlreadyTagged int      `json:"already_tagged"`
+		NoteIDs       []string `json:"note_ids"`
+	}](t, rec)
+	if got.Tag != "work" || got.Assigned != 1 || got.AlreadyTagged != 1 {
+		t.Errorf("assign result = %+v, want tag work with 1 assigned and 1 already tagged", got)
+	}
+	if want := []string{untagged.ID, tagged.ID}; !reflect.DeepEqual(got.NoteIDs, want) {
+		t.Errorf("note_ids = %v, want %v", got.NoteIDs, want)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/v1/tags", bob.ApiKey, nil)
+	if tags := decodeResponse[[]TagCount](t, rec); len(tags) != 0 {
+		t.Errorf("bob's tags = %+v, want none", tags)
+	}
+}
+
+// busyNoteStore fails GetNote for noteID with store.ErrBusy.
+type busyNoteStore struct {
+	store.Store
+	noteID string
+}
+
+func (s *busyNoteStore) GetNote(ctx context.Context, id string) (database.Note, error) {
+	if id == s.noteID {
+		return database.Note{}, store.ErrBusy
+	}
+	return s.Store.GetNote(ctx, id)
+}
+
+func (s *busyNoteStore) InTx(ctx context.Context, fn func(store.Store) error) error {
+	return s.Store.InTx(ctx, func(store.Store) error { return fn(s) })
+}
+
+func TestTagsAssignLookupError(t *testing.T) {
+	busy := &busyNoteStore{}
+	h, db := newTestRouter(t, func(cfg *apiConfig) {
+		busy.Store = cfg.DB
+		cfg.DB = busy
+	})
+	user := createTestUser(t, h, "alice")
+	first := createTestNote(t, h, user.ApiKey, "first")
+	second := createTestNote(t, h, user.ApiKey, "second")
+	busy.noteID = second.ID
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/tags/work/assign", user.ApiKey, map[string][]string{
+		"note_ids": {first.ID, second.ID},
+	})
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("assign with a busy lookup status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if tags, err := db.GetTagsForNote(context.Background(), first.ID); err != nil || len(tags) != 0 {
+		t.Errorf("first note tags = %v (err %v), want none after the failed assign", tags, err)
+	}
+}
+
+func TestTagsAssignRejectsBlankTag(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/tags/%20/assign", user.ApiKey, map[string][]string{"note_ids": {}})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("POST assign with blank tag status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestTagsAssignMaxTagsPerNote(t *testing.T) {
+	h, db := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.MaxTagsPerNote = 3
+	})
+	user := createTestUser(t, h, "alice")
+	full := createTestNote(t, h, user.ApiKey, "full")
+	roomy := createTestNote(t, h, user.ApiKey, "roomy")
+	for _, tag := range []string{"a", "b"} {
+		if _, err := db.AddNoteTag(context.Background(), database.AddNoteTagParams{NoteID: full.ID, Tag: tag}); err != nil {
+			t.Fatalf("seeding tag: %v", err)
+		}
+	}
+	assign := func(tag string, noteIDs ...string) int {
+		t.Helper()
+		return doRequest(t, h, http.MethodPost, "/v1/tags/"+tag+"/assign", user.ApiKey, map[string][]string{"note_ids": noteIDs}).Code
+	}
+
+	if code := assign("c", full.ID); code != http.StatusOK {
+		t.Fatalf("third tag status = %d, want %d", code, http.StatusOK)
+	}
+	if code := assign("b", full.ID); code != http.StatusOK {
+		t.Errorf("re-assigning a present tag at the limit status = %d, want %d", code, http.StatusOK)
+	}
+	if code := assign("d", roomy.ID, full.ID); code != http.StatusUnprocessableEntity {
+		t.Errorf("fourth tag status = %d, want %d", code, http.StatusUnprocessableEntity)
+	}
+
+	tags, err := db.GetTagsForNote(context.Background(), full.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags on the full note = %v, want %v", tags, want)
+	}
+	if tags, _ := db.GetTagsForNote(context.Background(), roomy.ID); len(tags) != 0 {
+		t.Errorf("tags on the other note = %v, want none since the assignment failed as a whole", tags)
+	}
+}
+
+func TestTagsAssignRejectsLongTag(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "hello")
+	body := map[string][]string{"note_ids": {note.ID}}
+
+	// Length is counted in characters, not bytes.
+	atLimit := strings.Repeat("é", maxTagLength)
+	if rec := doRequest(t, h, http.MethodPost, "/v1/tags/"+url.PathEscape(atLimit)+"/assign", user.ApiKey, body); rec.Code != http.StatusOK {
+		t.Errorf("tag of %d characters status = %d, want %d", maxTagLength, rec.Code, http.StatusOK)
+	}
+	if rec := doRequest(t, h, http.MethodPost, "/v1/tags/"+strings.Repeat("x", maxTagLength+1)+"/assign", user.ApiKey, body); rec.Code != http.StatusBadRequest {
+		t.Errorf("tag of %d characters status = %d, want %d", maxTagLength+1, rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestNotesSearchTag(t *testing.T) {
+	h, db := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.MaxBulkTagNotes = 3
+	})
+	alice := createTestUser(t, h, "alice")
+	bob := createTestUser(t, h, "bob")
+
+	first := createTestNote(t, h, alice.ApiKey, "Invoice #1 for March")
+	second := createTestNote(t, h, alice.ApiKey, "pay the invoice")
+	tagged := createTestNote(t, h, alice.ApiKey, "old invoice")
+	if _, err := db.AddNoteTag(context.Background(), database.AddNoteTagParams{NoteID: tagged.ID, Tag: "billing"}); err != nil {
+		t.Fatalf("seeding tag: %v", err)
+	}
+	other := createTestNote(t, h, alice.ApiKey, "groceries")
+	bobs := createTestNote(t, h, bob.ApiKey, "bob's invoice")
+	// The % must match literally, as in GET /v1/notes/search.
+	createTestNote(t, h, alice.ApiKey, "100% done")
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/notes/search/tag", alice.ApiKey, map[string]string{"q": "invoice", "tag": " Billing "})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	type response struct {
+		Tag           string `json:"tag"`
+		Matched       int    `json:"matched"`
+		Assigned      int    `json:"assigned"`
+		AlreadyTagged int    `json:"already_tagged"`
+	}
+	want := response{Tag: "billing", Matched: 3, Assigned: 2, AlreadyTagged: 1}
+	if got := decodeResponse[response](t, rec); got != want {
+		t.Errorf("response = %+v, want %+v", got, want)
+	}
+	for _, tt := range []struct {
+		note Note
+		want []string
+	}{
+		{first, []string{"billing"}},
+		{second, []string{"billing"}},
+		{tagged, []string{"billing"}},
+		{other, nil},
+		{bobs, nil},
+	} {
+		tags, err := db.GetTagsForNote(context.Background(), tt.note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(tags, tt.want) {
+			t.Errorf("note %q tags = %v, want %v", tt.note.Note, tags, tt.want)
+		}
+	}
+
+	rec = doRequest(t, h, http.MethodPost, "/v1/notes/search/tag", alice.ApiKey, map[string]string{"q": "%", "tag": "pct"})
+	if got := decodeResponse[response](t, rec); got.Matched != 1 {
+		t.Errorf("q=%% matched %d notes, want 1", got.Matched)
+	}
+
+	createTestNote(t, h, alice.ApiKey, "invoice four")
+	rec = doRequest(t, h, http.MethodPost, "/v1/notes/search/tag", alice.ApiKey, map[string]string{"q": "invoice", "tag": "capped"})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("over the cap status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if tags, _ := db.GetTagsForNote(context.Background(), first.ID); len(tags) != 1 {
+		t.Errorf("refused bulk tag left tags %v, want only billing", tags)
+	}
+
+	rec = doRequest(t, h, http.MethodPost, "/v1/notes/search/tag", alice.ApiKey, map[string]string{"q": " ", "tag": "all"})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("blank query status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
@@ -1,48 +1,61 @@
 package main
 
 import (
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/hex"
-	"encoding/json"
+	"context"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/bootdotdev/learn-cicd-starter/internal/database"
-	"github.com/google/uuid"
+	"github.com/bootdotdev/learn-cicd-starter/internal/nonce"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
 )
 
 func (cfg *apiConfig) handlerUsersCreate(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Name string `json:"name"`
+		Name string `json:"name" validate:"required,notblank,max=100"`
 	}
-	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
-	err := decoder.Decode(&params)
+	err := decodeJSON(r, &params)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if !validateParams(w, params) {
 		return
 	}
 
-	apiKey, err := generateRandomSHA256Hash()
+	apiKey, err := cfg.newAPIKey()
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't gen apikey", err)
 		return
 	}
 
-	err = cfg.DB.CreateUser(r.Context(), database.CreateUserParams{
-		ID:        uuid.New().String(),
-		CreatedAt: time.Now().UTC().Format(time.RFC3339),
-		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
-		Name:      params.Name,
-		ApiKey:    apiKey,
+	id, err := cfg.newID()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't generate user id", err)
+		return
+	}
+
+	err = cfg.dbFor(r).CreateUser(r.Context(), database.CreateUserParams{
+		ID:             id,
+		CreatedAt:      time.Now().UTC().Format(time.RFC3339),
+		UpdatedAt:      time.Now().UTC().Format(time.RFC3339),
+		Name:           params.Name,
+		ApiKey:         apiKey,
+		NameNormalized: normalizeUserName(params.Name),
 	})
+	if store.IsUniqueViolation(err, "users.name_normalized") {
+		respondNameTaken(w)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create user", err)
 		return
 	}
 
-	user, err := cfg.DB.GetUser(r.Context(), apiKey)
+	user, err := cfg.dbFor(r).GetUser(r.Context(), apiKey)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
 		return
@@ -53,21 +66,40 @@ func (cfg *apiConfig) handlerUsersCreate(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, http.StatusInternalServerError, "Couldn't convert user", err)
 		return
 	}
-	respondWithJSON(w, http.StatusCreated, userResp)
+	// Users are only addressable as the authenticated caller, so there is no
+	// Location to point at.
+	respondCreated(w, "", userResp)
 }
 
-func generateRandomSHA256Hash() (string, error) {
-	randomBytes := make([]byte, 32)
-	_, err := rand.Read(randomBytes)
-	if err != nil {
-		return "", err
-	}
-	hash := sha256.Sum256(randomBytes)
-	hashString := hex.EncodeToString(hash[:])
-	return hashString, nil
+// normalizeUserName is the form user names are unique in, so that "Alice"
+// and "alice" can't both register. The name as typed is kept for display.
+// It folds ASCII letters only, as SQLite's lower() does, so that it agrees
+// with the name_normalized values migration 011 backfilled.
+func normalizeUserName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, name)
+}
+
+// respondNameTaken reports a name held by another user, ignoring case. The
+// users_name_normalized index enforces it, so two concurrent requests for
+// one name can't both succeed.
+func respondNameTaken(w http.ResponseWriter) {
+	respondWithJSON(w, http.StatusConflict, errorResponse{
+		Error: "User name already taken",
+		Code:  errCodeNameTaken,
+	})
 }
 
 func (cfg *apiConfig) handlerUsersGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	loc, err := parseTimezone(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
 
 	userResp, err := databaseUserToUser(user)
 	if err != nil {
@@ -75,5 +107,168 @@ func (cfg *apiConfig) handlerUsersGet(w http.ResponseWriter, r *http.Request, us
 		return
 	}
 
+	respondWithJSON(w, http.StatusOK, userResp.In(loc))
+}
+
+// handlerUsersUpdate renames the caller and/or rotates their API key. Both
+// changes share a transaction, so a failure leaves the user untouched.
+func (cfg *apiConfig) handlerUsersUpdate(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Name      *string `json:"name" validate:"notblank,max=100"`
+		RotateKey bool    `json:"rotateKey"`
+	}
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if !validateParams(w, params) {
+		return
+	}
+
+	var newKey string
+	if params.RotateKey {
+		newKey, err = cfg.newAPIKey()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't gen apikey", err)
+			return
+		}
+	}
+
+	updated := user
+	err = cfg.dbFor(r).InTx(r.Context(), func(tx store.Store) error {
+		now := time.Now().UTC().Format(time.RFC3339)
+		if params.Name != nil {
+			err := tx.UpdateUserName(r.Context(), database.UpdateUserNameParams{
+				Name:           *params.Name,
+				NameNormalized: normalizeUserName(*params.Name),
+				UpdatedAt:      now,
+				ID:             user.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if params.RotateKey {
+			err := tx.UpdateUserAPIKey(r.Context(), database.UpdateUserAPIKeyParams{
+				ApiKey:    newKey,
+				UpdatedAt: now,
+				ID:        user.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		updated, err = tx.GetUserByID(r.Context(), user.ID)
+		return err
+	})
+	if store.IsUniqueViolation(err, "users.name_normalized") {
+		respondNameTaken(w)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+	if cfg.UserCache != nil {
+		cfg.UserCache.Remove(hashAPIKey(user.ApiKey))
+	}
+
+	userResp, err := databaseUserToUser(updated)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert user", err)
+		return
+	}
 	respondWithJSON(w, http.StatusOK, userResp)
 }
+
+// handlerUsersDeleteToken issues the single-use nonce that DELETE /v1/users/me
+// expects in its X-Nonce header.
+func (cfg *apiConfig) handlerUsersDeleteToken(w http.ResponseWriter, r *http.Request, user database.User) {
+	type response struct {
+		Nonce     string    `json:"nonce"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	token, expires, err := cfg.DeleteTokens.Issue(user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't issue delete token", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response{Nonce: token, ExpiresAt: expires.UTC()})
+}
+
+// handlerUsersDelete deletes the caller's account, with their notes and
+// follows. The X-Nonce header must carry an unused, unexpired delete token,
+// so a captured request can't be replayed. The transaction only looks the
+// token up, as a retried transaction runs again, and it's consumed once the
+// delete commits, so a failed delete leaves it usable.
+func (cfg *apiConfig) handlerUsersDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	token := r.Header.Get("X-Nonce")
+	if token == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing X-Nonce header", nil)
+		return
+	}
+
+	err := cfg.dbFor(r).InTx(r.Context(), func(tx store.Store) error {
+		subject, err := cfg.DeleteTokens.Lookup(token)
+		if err != nil {
+			return err
+		}
+		if subject != user.ID {
+			return nonce.ErrUnknown
+		}
+		return deleteAccount(r.Context(), tx, user.ID)
+	})
+	if err == nil {
+		err = cfg.DeleteTokens.Consume(user.ID, token)
+	}
+	switch {
+	case errors.Is(err, nonce.ErrUsed):
+		respondWithError(w, http.StatusConflict, "Nonce already used", err)
+		return
+	case errors.Is(err, nonce.ErrExpired):
+		respondWithError(w, http.StatusGone, "Nonce expired", err)
+		return
+	case errors.Is(err, nonce.ErrUnknown):
+		respondWithError(w, http.StatusBadRequest, "Invalid nonce", err)
+		return
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete user", err)
+		return
+	}
+	if cfg.UserCache != nil {
+		cfg.UserCache.Remove(hashAPIKey(user.ApiKey))
+	}
+	respondNoContent(w)
+}
+
+// deleteAccount deletes userID with its notes, their revisions and tags, its
+// follows either way, and the record of any migration renaming it. The schema declares ON DELETE CASCADE, but SQLite
+// only applies it with PRAGMA foreign_keys on, so the dependents are
+// deleted explicitly, children first. Run it inside a transaction.
+func deleteAccount(ctx context.Context, tx store.Store, userID string) error {
+	if err := tx.DeleteNoteTagsForUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := tx.DeleteNoteRevisionsForUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := tx.DeleteNotesForUser(ctx, userID); err != nil {
+		return err
+	}
+	err := tx.DeleteFollowsForUser(ctx, database.DeleteFollowsForUserParams{
+		FollowerID: userID,
+		FolloweeID: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if err := tx.DeleteUserRenames(ctx, userID); err != nil {
+		return err
+	}
+	return tx.DeleteUser(ctx, userID)
+}
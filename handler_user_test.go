@@ -0,0 +1,409 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/cache"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/nonce"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+func TestUsersUpdate(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        map[string]any
+		wantName    string
+		wantRotated bool
+	}{
+		{"rename only", map[string]any{"name": "alicia"}, "alicia", false},
+		{"rotate only", map[string]any{"rotateKey": true}, "alice", true},
+		{"rename and rotate", map[string]any{"name": "alicia", "rotateKey": true}, "alicia", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestRouter(t, func(cfg *apiConfig) {
+				cfg.UserCache = cache.New[database.User](8, time.Minute)
+			})
+			user := createTestUser(t, h, "alice")
+			// Warm the cache so a stale entry would be noticed.
+			doRequest(t, h, http.MethodGet, "/v1/users", user.ApiKey, nil)
+
+			rec := doRequest(t, h, http.MethodPatch, "/v1/users", user.ApiKey, tt.body)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("PATCH status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			updated := decodeResponse[User](t, rec)
+			if updated.Name != tt.wantName || updated.ID != user.ID {
+				t.Errorf("PATCH user = %+v, want name %q", updated, tt.wantName)
+			}
+			if rotated := updated.ApiKey != user.ApiKey; rotated != tt.wantRotated {
+				t.Errorf("key rotated = %v, want %v", rotated, tt.wantRotated)
+			}
+
+			rec = doRequest(t, h, http.MethodGet, "/v1/users", updated.ApiKey, nil)
+			if got := decodeResponse[User](t, rec); got.Name != tt.wantName {
+				t.Errorf("GET /v1/users name = %q, want %q", got.Name, tt.wantName)
+			}
+			if tt.wantRotated {
+				rec := doRequest(t, h, http.MethodGet, "/v1/users", user.ApiKey, nil)
+				if rec.Code != http.StatusNotFound {
+					t.Errorf("old key status = %d, want %d", rec.Code, http.StatusNotFound)
+				}
+			}
+		})
+	}
+}
+
+// failingKeyStore fails every API key update.
+type failingKeyStore struct {
+	store.Store
+}
+
+func (s failingKeyStore) UpdateUserAPIKey(ctx context.Context, arg database.UpdateUserAPIKeyParams) error {
+	return errors.New("disk full")
+}
+
+func (s failingKeyStore) InTx(ctx context.Context, fn func(store.Store) error) error {
+	return s.Store.InTx(ctx, func(tx store.Store) error {
+		return fn(failingKeyStore{tx})
+	})
+}
+
+func TestUsersAPIKeyGenerator(t *testing.T) {
+	h, _ := newTestRouter(t)
+	if key := createTestUser(t, h, "alice").ApiKey; len(key) != auth.DefaultKeyLength || strings.Trim(key, auth.URLSafeAlphabet) != "" {
+		t.Errorf("default key = %q, want %d URL-safe base64 characters", key, auth.DefaultKeyLength)
+	}
+
+	keys, err := auth.NewKeyGenerator(48, "0123456789abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, _ = newTestRouter(t, func(cfg *apiConfig) { cfg.APIKeys = keys })
+	user := createTestUser(t, h, "bob")
+	rec := doRequest(t, h, http.MethodPatch, "/v1/users", user.ApiKey, map[string]any{"rotateKey": true})
+	rotated := decodeResponse[User](t, rec).ApiKey
+	if rotated == user.ApiKey {
+		t.Fatal("rotateKey kept the old key")
+	}
+	for _, key := range []string{user.ApiKey, rotated} {
+		if len(key) != 48 || strings.Trim(key, "0123456789abcdef") != "" {
+			t.Errorf("configured key = %q, want 48 lower-case hex characters", key)
+		}
+	}
+}
+
+func TestUsersUpdateRollsBack(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.DB = failingKeyStore{cfg.DB}
+	})
+	user := createTestUser(t, h, "alice")
+
+	rec := doRequest(t, h, http.MethodPatch, "/v1/users", user.ApiKey, map[string]any{"name": "alicia", "rotateKey": true})
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("PATCH status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/v1/users", user.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET with original key status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := decodeResponse[User](t, rec).Name; got != "alice" {
+		t.Errorf("name after failed update = %q, want alice", got)
+	}
+}
+
+func TestUsersUpdateValidation(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+
+	rec := doRequest(t, h, http.MethodPatch, "/v1/users", user.ApiKey, map[string]any{"name": "  "})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("blank name status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func deleteUser(t *testing.T, h http.Handler, apiKey, nonce string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodDelete, "/v1/users/me", nil)
+	req.Header.Set("Authorization", "ApiKey "+apiKey)
+	if nonce != "" {
+		req.Header.Set("X-Nonce", nonce)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func issueDeleteToken(t *testing.T, h http.Handler, apiKey string) string {
+	t.Helper()
+	rec := doRequest(t, h, http.MethodGet, "/v1/users/me/delete-token", apiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete-token status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	resp := decodeResponse[struct {
+		Nonce     string    `json:"nonce"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}](t, rec)
+	if resp.Nonce == "" || !resp.ExpiresAt.After(time.Now()) {
+		t.Fatalf("delete-token = %+v, want a nonce expiring in the future", resp)
+	}
+	return resp.Nonce
+}
+
+func withDeleteTokens(ttl time.Duration) func(*apiConfig) {
+	return func(cfg *apiConfig) {
+		cfg.DeleteTokens = nonce.New(ttl)
+	}
+}
+
+func TestUsersCreateDuplicateName(t *testing.T) {
+	h, _ := newTestRouter(t)
+
+	const racers = 2
+	codes := make([]int, racers)
+	var start, done sync.WaitGroup
+	start.Add(1)
+	for i := range codes {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			start.Wait()
+			codes[i] = doRequest(t, h, http.MethodPost, "/v1/users", "", map[string]string{"name": "alice"}).Code
+		}()
+	}
+	start.Done()
+	done.Wait()
+
+	slices.Sort(codes)
+	if want := []int{http.StatusCreated, http.StatusConflict}; !slices.Equal(codes, want) {
+		t.Fatalf("concurrent create statuses = %v, want %v", codes, want)
+	}
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/users", "", map[string]string{"name": "alice"})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("repeat create status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if got := decodeResponse[errorResponse](t, rec); got.Code != errCodeNameTaken {
+		t.Errorf("code = %q, want %q", got.Code, errCodeNameTaken)
+	}
+
+	bob := createTestUser(t, h, "bob")
+	rec = doRequest(t, h, http.MethodPatch, "/v1/users", bob.ApiKey, map[string]any{"name": "alice"})
+	if rec.Code != http.StatusConflict {
+		t.Errorf("rename to taken name status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+}
+
+func TestUsersNameCaseInsensitive(t *testing.T) {
+	h, _ := newTestRouter(t)
+	alice := createTestUser(t, h, "Alice")
+	if alice.Name != "Alice" {
+		t.Errorf("name = %q, want the casing as registered", alice.Name)
+	}
+
+	for _, name := range []string{"alice", "ALICE", "aLiCe"} {
+		rec := doRequest(t, h, http.MethodPost, "/v1/users", "", map[string]string{"name": name})
+		if rec.Code != http.StatusConflict {
+			t.Errorf("create %q status = %d, want %d", name, rec.Code, http.StatusConflict)
+		}
+	}
+
+	bob := createTestUser(t, h, "bob")
+	rec := doRequest(t, h, http.MethodPatch, "/v1/users", bob.ApiKey, map[string]any{"name": "ALICE"})
+	if rec.Code != http.StatusConflict {
+		t.Errorf("rename to case variant status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+
+	// Changing only the casing of one's own name is not a conflict.
+	rec = doRequest(t, h, http.MethodPatch, "/v1/users", alice.ApiKey, map[string]any{"name": "ALICE"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("recasing own name status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := decodeResponse[User](t, rec); got.Name != "ALICE" {
+		t.Errorf("name = %q, want ALICE", got.Name)
+	}
+}
+
+func TestNormalizeUserName(t *testing.T) {
+	// The same results as SQLite's lower(), which backfilled the names
+	// registered before name_normalized existed.
+	for name, want := range map[string]string{
+		"Alice":  "alice",
+		"aLiCe1": "alice1",
+		"ÉVA":    "Éva",
+		"Straße": "straße",
+		"K":      "k",
+		"\u212a": "\u212a",
+	} {
+		if got := normalizeUserName(name); got != want {
+			t.Errorf("normalizeUserName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestUsersDelete(t *testing.T) {
+	h, db := newTestRouter(t, withDeleteTokens(time.Minute))
+	user := createTestUser(t, h, "alice")
+	other := createTestUser(t, h, "bob")
+	note := createTestNote(t, h, user.ApiKey, "goodbye")
+	doRequest(t, h, http.MethodPost, "/v1/users/"+user.ID+"/follow", other.ApiKey, nil)
+	doRequest(t, h, http.MethodPost, "/v1/users/"+other.ID+"/follow", user.ApiKey, nil)
+	doRequest(t, h, http.MethodPost, "/v1/tags/work/assign", user.ApiKey, map[string][]string{"note_ids": {note.ID}})
+	doRequest(t, h, http.MethodPatch, "/v1/notes/"+note.ID, user.ApiKey, map[string]string{"note": "edited"})
+
+	if rec := deleteUser(t, h, user.ApiKey, ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("DELETE without nonce status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if rec := deleteUser(t, h, user.ApiKey, issueDeleteToken(t, h, other.ApiKey)); rec.Code != http.StatusBadRequest {
+		t.Errorf("DELETE with another user's nonce status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec := deleteUser(t, h, user.ApiKey, issueDeleteToken(t, h, user.ApiKey))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if _, err := db.GetUserByID(context.Background(), user.ID); err == nil {
+		t.Error("user still exists after DELETE")
+	}
+	if _, err := db.GetNote(context.Background(), note.ID); err == nil {
+		t.Error("note still exists after deleting its owner")
+	}
+	// memstore, like SQLite without PRAGMA foreign_keys, doesn't cascade,
+	// so these only pass if the handler deletes the dependents itself.
+	if tags, err := db.GetTagsForNote(context.Background(), note.ID); err != nil || len(tags) != 0 {
+		t.Errorf("tags after deleting the owner = %v (err %v), want none", tags, err)
+	}
+	if revisions, err := db.GetNoteRevisions(context.Background(), note.ID); err != nil || len(revisions) != 0 {
+		t.Errorf("revisions after deleting the owner = %v (err %v), want none", revisions, err)
+	}
+	for _, follow := range []database.DeleteFollowParams{
+		{FollowerID: other.ID, FolloweeID: user.ID},
+		{FollowerID: user.ID, FolloweeID: other.ID},
+	} {
+		if n, err := db.DeleteFollow(context.Background(), follow); err != nil || n != 0 {
+			t.Errorf("follow %+v still present after deleting the user (err %v)", follow, err)
+		}
+	}
+	if rec := doRequest(t, h, http.MethodGet, "/v1/users", other.ApiKey, nil); rec.Code != http.StatusOK {
+		t.Errorf("other user status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+// failOnceDeleteStore fails the first DeleteUser call, leaving the user in
+// place so that the same nonce can be retried.
+type failOnceDeleteStore struct {
+	store.Store
+	failed bool
+}
+
+func (s *failOnceDeleteStore) DeleteUser(ctx context.Context, id string) error {
+	if !s.failed {
+		s.failed = true
+		return errors.New("disk I/O error")
+	}
+	return s.Store.DeleteUser(ctx, id)
+}
+
+func (s *failOnceDeleteStore) InTx(ctx context.Context, fn func(store.Store) error) error {
+	return s.Store.InTx(ctx, func(store.Store) error { return fn(s) })
+}
+
+// busyOnceCommitStore fails the first transaction's commit as SQLite does
+// under write contention, after the whole body has run, so store.WithRetry
+// runs the transaction again.
+type busyOnceCommitStore struct {
+	store.Store
+	attempts int
+}
+
+func (s *busyOnceCommitStore) InTx(ctx context.Context, fn func(store.Store) error) error {
+	return s.Store.InTx(ctx, func(tx store.Store) error {
+		s.attempts++
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if s.attempts == 1 {
+			return errors.New("SQLite error: database is locked")
+		}
+		return nil
+	})
+}
+
+func TestUsersDeleteBusyRetry(t *testing.T) {
+	var busy *busyOnceCommitStore
+	h, db := newTestRouter(t, withDeleteTokens(time.Minute), func(cfg *apiConfig) {
+		busy = &busyOnceCommitStore{Store: cfg.DB}
+		cfg.DB = store.WithRetry(busy, 3, time.Millisecond)
+	})
+	user := createTestUser(t, h, "alice")
+	token := issueDeleteToken(t, h, user.ApiKey)
+
+	if rec := deleteUser(t, h, user.ApiKey, token); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d, want %d: the retried transaction shouldn't find the nonce used", rec.Code, http.StatusNoContent)
+	}
+	if busy.attempts != 2 {
+		t.Errorf("transaction attempts = %d, want 2", busy.attempts)
+	}
+	if _, err := db.GetUserByID(context.Background(), user.ID); err == nil {
+		t.Error("user still exists after the DELETE")
+	}
+	if rec := deleteUser(t, h, user.ApiKey, token); rec.Code == http.StatusNoContent {
+		t.Error("nonce accepted twice")
+	}
+}
+
+func TestUsersDeleteRetry(t *testing.T) {
+	h, db := newTestRouter(t, withDeleteTokens(time.Minute), func(cfg *apiConfig) {
+		cfg.DB = &failOnceDeleteStore{Store: cfg.DB}
+	})
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "kept")
+	token := issueDeleteToken(t, h, user.ApiKey)
+
+	if rec := deleteUser(t, h, user.ApiKey, token); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("first DELETE status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if _, err := db.GetNote(context.Background(), note.ID); err != nil {
+		t.Errorf("note after a failed DELETE: %v, want it rolled back", err)
+	}
+	if rec := deleteUser(t, h, user.ApiKey, token); rec.Code != http.StatusNoContent {
+		t.Fatalf("retried DELETE status = %d, want %d: the failure shouldn't burn the nonce", rec.Code, http.StatusNoContent)
+	}
+	if _, err := db.GetNote(context.Background(), note.ID); err == nil {
+		t.Error("note still exists after the retried DELETE")
+	}
+}
+
+func TestUsersDeleteExpiredNonce(t *testing.T) {
+	h, _ := newTestRouter(t, withDeleteTokens(time.Nanosecond))
+	user := createTestUser(t, h, "alice")
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/users/me/delete-token", user.ApiKey, nil)
+	token := decodeResponse[struct {
+		Nonce string `json:"nonce"`
+	}](t, rec).Nonce
+
+	if rec := deleteUser(t, h, user.ApiKey, token); rec.Code != http.StatusGone {
+		t.Errorf("DELETE with expired nonce status = %d, want %d", rec.Code, http.StatusGone)
+	}
+	if rec := doRequest(t, h, http.MethodGet, "/v1/users", user.ApiKey, nil); rec.Code != http.StatusOK {
+		t.Errorf("user status after rejected DELETE = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+)
+
+// errIDGeneration wraps failures to read randomness for a new row ID or API
+// key.
+var errIDGeneration = errors.New("couldn't generate id")
+
+// newID returns a random UUID drawn from cfg.Random, or crypto/rand when it
+// is nil. Unlike uuid.New it reports a failing source instead of panicking.
+func (cfg *apiConfig) newID() (string, error) {
+	src := cfg.Random
+	if src == nil {
+		src = rand.Reader
+	}
+	id, err := uuid.NewRandomFromReader(src)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errIDGeneration, err)
+	}
+	return id.String(), nil
+}
+
+// newAPIKey returns a key from cfg.APIKeys, or auth.DefaultKeyGenerator when
+// it is nil, drawn from cfg.Random.
+func (cfg *apiConfig) newAPIKey() (string, error) {
+	keys := cfg.APIKeys
+	if keys == nil {
+		keys = auth.DefaultKeyGenerator
+	}
+	key, err := keys.Generate(cfg.Random)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errIDGeneration, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"testing"
+	"testing/iotest"
+)
+
+func TestIDGenerationFailure(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	var cfg *apiConfig
+	h, db := newTestRouter(t, func(c *apiConfig) { cfg = c })
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "v1")
+	cfg.Random = iotest.ErrReader(errors.New("entropy exhausted"))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+	}{
+		{"create user", http.MethodPost, "/v1/users", map[string]string{"name": "bob"}},
+		{"create note", http.MethodPost, "/v1/notes", map[string]string{"note": "hi"}},
+		{"update note", http.MethodPatch, "/v1/notes/" + note.ID, map[string]string{"note": "v2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, h, tt.method, tt.path, user.ApiKey, tt.body)
+			if rec.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+			}
+			if got := decodeResponse[errorResponse](t, rec).Code; got != errCodeIDGeneration {
+				t.Errorf("code = %q, want %q", got, errCodeIDGeneration)
+			}
+		})
+	}
+
+	got, err := db.GetNote(context.Background(), note.ID)
+	if err != nil || got.Note != "v1" {
+		t.Errorf("note after failed update = %q, %v; want v1 unchanged", got.Note, err)
+	}
+}
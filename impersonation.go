@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// impersonationTokenPrefix marks impersonation tokens, which are presented
+// like API keys. "." isn't in the default key alphabet, so they don't
+// collide with generated keys.
+const impersonationTokenPrefix = "imp."
+
+// errImpersonationReadOnly is returned by authenticate for an impersonation
+// token used on anything but a read.
+var errImpersonationReadOnly = errors.New("impersonation tokens are read-only")
+
+// handlerAdminImpersonate issues a short-lived token that authenticates as
+// the user, so support can see what they see. Tokens only allow reads and
+// every use is logged.
+func (cfg *apiConfig) handlerAdminImpersonate(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Token     string    `json:"token"`
+		UserID    string    `json:"user_id"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	userID, ok := parseUUIDParam(w, r, "userID")
+	if !ok {
+		return
+	}
+	if _, err := cfg.dbFor(r).GetUserByID(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get user", err)
+		return
+	}
+
+	nonce, expires, err := cfg.Impersonations.Issue(userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't issue impersonation token", err)
+		return
+	}
+	token := impersonationTokenPrefix + nonce
+	log.Printf("Impersonation: admin at %s issued %s for user %s, expires %s",
+		cfg.clientIP(r), tokenLogID(token), userID, expires.UTC().Format(time.RFC3339))
+	respondCreated(w, "", response{
+		Token:     token,
+		UserID:    userID,
+		ExpiresAt: expires.UTC(),
+	})
+}
+
+// authenticateImpersonation resolves an impersonation token to its user and
+// logs the request under the token, so it can't be mistaken for the user's
+// own. ok is false if apiKey isn't a live token, leaving it to be tried as
+// an API key.
+func (cfg *apiConfig) authenticateImpersonation(r *http.Request, apiKey string) (user database.User, ok bool, err error) {
+	nonce, found := strings.CutPrefix(apiKey, impersonationTokenPrefix)
+	if !found || cfg.Impersonations == nil {
+		return database.User{}, false, nil
+	}
+	userID, err := cfg.Impersonations.Lookup(nonce)
+	if err != nil {
+		return database.User{}, false, nil
+	}
+
+	log.Printf("Impersonation: %s used for %s %s as user %s (request %s)",
+		tokenLogID(apiKey), r.Method, r.URL.Path, userID, requestIDFromContext(r.Context()))
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return database.User{}, true, errImpersonationReadOnly
+	}
+	user, err = cfg.dbFor(r).GetUserByID(r.Context(), userID)
+	return user, true, err
+}
+
+// tokenLogID is enough of an impersonation token to match its uses up in
+// the logs, but not to use it.
+func tokenLogID(token string) string {
+	return token[:len(impersonationTokenPrefix)+authFailureKeyPrefix] + "..."
+}
@@ -7,17 +7,47 @@ import (
 )
 
 var ErrNoAuthHeaderIncluded = errors.New("no authorization header included")
+var ErrAPIKeyTooLong = errors.New("api key too long")
+var ErrMalformedAuthHeader = errors.New("malformed authorization header")
 
-// GetAPIKey -
+// DefaultMaxAPIKeyLen is well above any key this service generates.
+const DefaultMaxAPIKeyLen = 512
+
+// MaxAPIKeyLen caps the key length GetAPIKey accepts. Longer keys fail with
+// ErrAPIKeyTooLong before being split, hashed or looked up. Set it at
+// startup, before serving requests.
+var MaxAPIKeyLen = DefaultMaxAPIKeyLen
+
+// apiKeyScheme prefixes the key in the Authorization header.
+const apiKeyScheme = "ApiKey "
+
+// GetAPIKey extracts the key from an "Authorization: ApiKey <key>" header,
+// falling back to a raw key in X-API-Key when Authorization is absent.
 func GetAPIKey(headers http.Header) (string, error) {
 	authHeader := headers.Get("Authorization")
 	if authHeader == "" {
-		return "", ErrNoAuthHeaderIncluded
+		return getRawAPIKey(headers.Get("X-API-Key"))
+	}
+	if len(authHeader) > len(apiKeyScheme)+MaxAPIKeyLen {
+		return "", ErrAPIKeyTooLong
 	}
 	splitAuth := strings.Split(authHeader, " ")
-	if len(splitAuth) < 2 || splitAuth[0] != "ApiKey" {
-		return "", errors.New("malformed authorization header")
+	if len(splitAuth) < 2 || splitAuth[0] != "ApiKey" || splitAuth[1] == "" {
+		return "", ErrMalformedAuthHeader
 	}
 
 	return splitAuth[1], nil
 }
+
+func getRawAPIKey(apiKey string) (string, error) {
+	if apiKey == "" {
+		return "", ErrNoAuthHeaderIncluded
+	}
+	if len(apiKey) > MaxAPIKeyLen {
+		return "", ErrAPIKeyTooLong
+	}
+	if strings.Contains(apiKey, " ") {
+		return "", ErrMalformedAuthHeader
+	}
+	return apiKey, nil
+}
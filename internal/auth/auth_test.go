@@ -2,7 +2,9 @@ package auth
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -20,10 +22,10 @@ func TestGetAPIKey(t *testing.T) {
 			expectedError:  nil,
 		},
 		{
-			name:           "valid API key with extra spaces",
+			name:           "malformed header - extra spaces before key",
 			headers:        map[string]string{"Authorization": "ApiKey  another-valid-key"},
-			expectedAPIKey: "", // strings.Split returns empty string for the second element
-			expectedError:  nil,
+			expectedAPIKey: "",
+			expectedError:  errors.New("malformed authorization header"),
 		},
 		{
 			name:           "valid API key with special characters",
@@ -70,14 +72,14 @@ func TestGetAPIKey(t *testing.T) {
 		{
 			name:           "malformed header - only ApiKey with space",
 			headers:        map[string]string{"Authorization": "ApiKey "},
-			expectedAPIKey: "", // strings.Split returns empty string for the second element
-			expectedError:  nil,
+			expectedAPIKey: "",
+			expectedError:  errors.New("malformed authorization header"),
 		},
 		{
 			name:           "malformed header - multiple spaces before key",
 			headers:        map[string]string{"Authorization": "ApiKey  "},
-			expectedAPIKey: "", // strings.Split returns empty string for the second element
-			expectedError:  nil,
+			expectedAPIKey: "",
+			expectedError:  errors.New("malformed authorization header"),
 		},
 		{
 			name:           "case insensitive authorization header key",
@@ -91,6 +93,36 @@ func TestGetAPIKey(t *testing.T) {
 			expectedAPIKey: "some-key",
 			expectedError:  nil,
 		},
+		{
+			name:           "X-API-Key only",
+			headers:        map[string]string{"X-API-Key": "raw-key-123"},
+			expectedAPIKey: "raw-key-123",
+			expectedError:  nil,
+		},
+		{
+			name:           "Authorization takes precedence over X-API-Key",
+			headers:        map[string]string{"Authorization": "ApiKey header-key", "X-API-Key": "raw-key-123"},
+			expectedAPIKey: "header-key",
+			expectedError:  nil,
+		},
+		{
+			name:           "malformed Authorization is not rescued by X-API-Key",
+			headers:        map[string]string{"Authorization": "Bearer some-token", "X-API-Key": "raw-key-123"},
+			expectedAPIKey: "",
+			expectedError:  errors.New("malformed authorization header"),
+		},
+		{
+			name:           "X-API-Key with a scheme prefix",
+			headers:        map[string]string{"X-API-Key": "ApiKey raw-key-123"},
+			expectedAPIKey: "",
+			expectedError:  errors.New("malformed authorization header"),
+		},
+		{
+			name:           "empty X-API-Key",
+			headers:        map[string]string{"X-API-Key": ""},
+			expectedAPIKey: "",
+			expectedError:  ErrNoAuthHeaderIncluded,
+		},
 	}
 
 	for _, tt := range tests {
@@ -139,18 +171,88 @@ func TestGetAPIKey_EdgeCases(t *testing.T) {
 		}
 	})
 
-	t.Run("very long API key", func(t *testing.T) {
-		longKey := "a" + string(make([]byte, 1000)) // 1001 character key
+}
+
+func TestGetAPIKey_MaxLength(t *testing.T) {
+	defer func(n int) { MaxAPIKeyLen = n }(MaxAPIKeyLen)
+
+	for _, limit := range []int{DefaultMaxAPIKeyLen, 16} {
+		MaxAPIKeyLen = limit
+		for _, tt := range []struct {
+			name    string
+			headers func(key string) http.Header
+		}{
+			{"Authorization", func(key string) http.Header { return http.Header{"Authorization": {"ApiKey " + key}} }},
+			{"X-API-Key", func(key string) http.Header { return http.Header{"X-Api-Key": {key}} }},
+		} {
+			t.Run(fmt.Sprintf("%s/limit %d", tt.name, limit), func(t *testing.T) {
+				atLimit := strings.Repeat("a", limit)
+				apiKey, err := GetAPIKey(tt.headers(atLimit))
+				if err != nil || apiKey != atLimit {
+					t.Errorf("GetAPIKey() with a %d character key = %d characters, %v; want the key", limit, len(apiKey), err)
+				}
+
+				apiKey, err = GetAPIKey(tt.headers(atLimit + "a"))
+				if err != ErrAPIKeyTooLong {
+					t.Errorf("GetAPIKey() with a %d character key error = %v, want %v", limit+1, err, ErrAPIKeyTooLong)
+				}
+				if apiKey != "" {
+					t.Errorf("GetAPIKey() with a %d character key apiKey length = %d, want 0", limit+1, len(apiKey))
+				}
+			})
+		}
+	}
+}
+
+func TestGetAPIKey_OversizedHeader(t *testing.T) {
+	headers := make(http.Header)
+	headers.Set("Authorization", "ApiKey "+strings.Repeat("a", 4<<20))
+
+	apiKey, err := GetAPIKey(headers)
+	if err != ErrAPIKeyTooLong {
+		t.Errorf("GetAPIKey() with oversized header error = %v, want %v", err, ErrAPIKeyTooLong)
+	}
+	if apiKey != "" {
+		t.Errorf("GetAPIKey() with oversized header apiKey length = %d, want 0", len(apiKey))
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		_, _ = GetAPIKey(headers)
+	})
+	if allocs > 0 {
+		t.Errorf("GetAPIKey() with oversized header allocated %v times per call, want 0", allocs)
+	}
+}
+
+func FuzzGetAPIKey(f *testing.F) {
+	for _, seed := range []string{
+		"ApiKey valid-api-key-123",
+		"ApiKey  another-valid-key",
+		"ApiKey key-with-special-chars!@#$%",
+		"ApiKey key with multiple parts",
+		"",
+		"Bearer some-token",
+		"apikey some-key",
+		"ApiKey",
+		"ApiKey ",
+		"ApiKey  ",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, authHeader string) {
 		headers := make(http.Header)
-		headers.Set("Authorization", "ApiKey "+longKey)
+		headers.Set("Authorization", authHeader)
 
 		apiKey, err := GetAPIKey(headers)
-
-		if err != nil {
-			t.Errorf("GetAPIKey() with long key error = %v, want nil", err)
+		if strings.Contains(apiKey, " ") {
+			t.Errorf("GetAPIKey(%q) apiKey = %q, contains a space", authHeader, apiKey)
+		}
+		if err == nil && apiKey == "" {
+			t.Errorf("GetAPIKey(%q) returned an empty apiKey with nil error", authHeader)
 		}
-		if apiKey != longKey {
-			t.Errorf("GetAPIKey() with long key length = %d, want %d", len(apiKey), len(longKey))
+		if err != nil && apiKey != "" {
+			t.Errorf("GetAPIKey(%q) returned apiKey %q alongside error %v", authHeader, apiKey, err)
 		}
 	})
 }
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// URLSafeAlphabet is the unpadded URL-safe base64 alphabet of RFC 4648.
+const URLSafeAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+// DefaultKeyLength is the length in URLSafeAlphabet of 32 random bytes.
+const DefaultKeyLength = 43
+
+// MinKeyEntropyBits is the least entropy NewKeyGenerator accepts.
+const MinKeyEntropyBits = 128
+
+var ErrWeakKeyConfig = errors.New("api key config too weak")
+
+// KeyGenerator makes API keys of a fixed length drawn uniformly from an
+// alphabet.
+type KeyGenerator struct {
+	length   int
+	alphabet string
+}
+
+// DefaultKeyGenerator makes 43 character URL-safe base64 keys, worth 32
+// random bytes.
+var DefaultKeyGenerator = &KeyGenerator{length: DefaultKeyLength, alphabet: URLSafeAlphabet}
+
+// NewKeyGenerator checks that keys of length characters from alphabet carry
+// at least MinKeyEntropyBits and fit within MaxAPIKeyLen. The alphabet must
+// be distinct printable ASCII without spaces.
+func NewKeyGenerator(length int, alphabet string) (*KeyGenerator, error) {
+	if len(alphabet) < 2 {
+		return nil, errors.New("api key alphabet needs at least 2 characters")
+	}
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if c <= ' ' || c > '~' {
+			return nil, fmt.Errorf("api key alphabet must be printable ASCII without spaces, got %q", c)
+		}
+		if strings.IndexByte(alphabet[i+1:], c) >= 0 {
+			return nil, fmt.Errorf("api key alphabet repeats %q", c)
+		}
+	}
+	if length <= 0 || length > MaxAPIKeyLen {
+		return nil, fmt.Errorf("api key length must be between 1 and %d, got %d", MaxAPIKeyLen, length)
+	}
+	g := &KeyGenerator{length: length, alphabet: alphabet}
+	if bits := g.EntropyBits(); bits < MinKeyEntropyBits {
+		return nil, fmt.Errorf("%w: %d characters from a %d character alphabet give %.0f bits, need %d",
+			ErrWeakKeyConfig, length, len(alphabet), bits, MinKeyEntropyBits)
+	}
+	return g, nil
+}
+
+// EntropyBits is how many random bits each key carries.
+func (g *KeyGenerator) EntropyBits() float64 {
+	return float64(g.length) * math.Log2(float64(len(g.alphabet)))
+}
+
+// Generate returns a new key read from random, or crypto/rand when it is
+// nil. Bytes that would bias the choice of character are skipped.
+func (g *KeyGenerator) Generate(random io.Reader) (string, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+	n := len(g.alphabet)
+	limit := 256 - 256%n
+	key := make([]byte, 0, g.length)
+	buf := make([]byte, g.length)
+	for len(key) < g.length {
+		if _, err := io.ReadFull(random, buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) < limit && len(key) < g.length {
+				key = append(key, g.alphabet[int(b)%n])
+			}
+		}
+	}
+	return string(key), nil
+}
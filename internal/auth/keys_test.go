@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewKeyGenerator(t *testing.T) {
+	tests := []struct {
+		name     string
+		length   int
+		alphabet string
+		wantErr  bool
+		wantWeak bool
+	}{
+		{"default", DefaultKeyLength, URLSafeAlphabet, false, false},
+		{"long hex", 64, "0123456789abcdef", false, false},
+		{"short hex", 31, "0123456789abcdef", true, true},
+		{"just enough hex", 32, "0123456789abcdef", false, false},
+		{"binary", 128, "01", false, false},
+		{"single character", 200, "a", true, false},
+		{"repeated character", 64, "aab", true, false},
+		{"space", 64, "ab cd", true, false},
+		{"non-ASCII", 64, "abcé", true, false},
+		{"too long", 2000, URLSafeAlphabet, true, false},
+		{"zero length", 0, URLSafeAlphabet, true, false},
+	}
+	for _, tt := range tests {
+		_, err := NewKeyGenerator(tt.length, tt.alphabet)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, want error %t", tt.name, err, tt.wantErr)
+		}
+		if errors.Is(err, ErrWeakKeyConfig) != tt.wantWeak {
+			t.Errorf("%s: err = %v, want ErrWeakKeyConfig %t", tt.name, err, tt.wantWeak)
+		}
+	}
+}
+
+func TestKeyGeneratorGenerate(t *testing.T) {
+	hex, err := NewKeyGenerator(40, "0123456789ABCDEF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name string
+		gen  *KeyGenerator
+		n    int
+		abc  string
+	}{
+		{"default", DefaultKeyGenerator, DefaultKeyLength, URLSafeAlphabet},
+		{"custom", hex, 40, "0123456789ABCDEF"},
+	} {
+		seen := make(map[string]bool)
+		for i := 0; i < 1000; i++ {
+			key, err := tt.gen.Generate(nil)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if len(key) != tt.n {
+				t.Fatalf("%s: key %q has length %d, want %d", tt.name, key, len(key), tt.n)
+			}
+			if i := strings.IndexFunc(key, func(r rune) bool { return !strings.ContainsRune(tt.abc, r) }); i >= 0 {
+				t.Fatalf("%s: key %q has %q outside the alphabet", tt.name, key, key[i])
+			}
+			if seen[key] {
+				t.Fatalf("%s: key %q generated twice", tt.name, key)
+			}
+			seen[key] = true
+		}
+	}
+}
+
+func TestKeyGeneratorSkipsBiasedBytes(t *testing.T) {
+	// With 3 characters, bytes from 255 up would favour "a" and are skipped.
+	g := &KeyGenerator{length: 4, alphabet: "abc"}
+	key, err := g.Generate(bytes.NewReader([]byte{255, 0, 1, 2, 3, 255, 255, 255}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "abca" {
+		t.Errorf("key = %q, want %q", key, "abca")
+	}
+}
+
+func TestKeyGeneratorShortRead(t *testing.T) {
+	if _, err := DefaultKeyGenerator.Generate(bytes.NewReader(make([]byte, 10))); err == nil {
+		t.Error("Generate succeeded on a short random source")
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLRU_Hit(t *testing.T) {
+	c := New[string](2, time.Minute)
+	c.Add("a", "alice")
+
+	if v, ok := c.Get("a"); !ok || v != "alice" {
+		t.Errorf("Get(a) = %q, %v, want alice, true", v, ok)
+	}
+	if _, ok := c.Get("missing"); ok {
+		t.Error("Get(missing) ok = true, want false")
+	}
+}
+
+func TestLRU_TTLExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New[string](2, time.Minute)
+	c.now = func() time.Time { return now }
+	c.Add("a", "alice")
+
+	now = now.Add(59 * time.Second)
+	if _, ok := c.Get("a"); !ok {
+		t.Error("Get(a) before TTL ok = false, want true")
+	}
+
+	now = now.Add(time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Error("Get(a) at TTL ok = true, want false")
+	}
+	if c.Len() != 0 {
+		t.Errorf("Len() after expiry = %d, want 0", c.Len())
+	}
+}
+
+func TestLRU_Remove(t *testing.T) {
+	c := New[string](2, time.Minute)
+	c.Add("a", "alice")
+	c.Remove("a")
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("Get(a) after Remove ok = true, want false")
+	}
+}
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := New[string](2, time.Minute)
+	c.Add("a", "alice")
+	c.Add("b", "bob")
+	c.Get("a")
+	c.Add("c", "carol")
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("Get(b) ok = true, want b evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("Get(%s) ok = false, want true", key)
+		}
+	}
+}
+
+func TestLRU_Concurrent(t *testing.T) {
+	c := New[int](16, time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				key := strconv.Itoa((i + j) % 32)
+				c.Add(key, j)
+				c.Get(key)
+				if j%10 == 0 {
+					c.Remove(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if c.Len() > 16 {
+		t.Errorf("Len() = %d, want at most 16", c.Len())
+	}
+}
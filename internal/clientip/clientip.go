@@ -0,0 +1,85 @@
+// Package clientip determines the real client address of a request that may
+// have passed through reverse proxies.
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Resolver trusts X-Forwarded-For only when it was set by one of its proxies.
+type Resolver struct {
+	trusted []netip.Prefix
+}
+
+// NewResolver parses a comma separated list of trusted proxy CIDRs. Bare
+// addresses are treated as single-host prefixes.
+func NewResolver(trustedProxies string) (*Resolver, error) {
+	r := &Resolver{}
+	for _, s := range strings.Split(trustedProxies, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(s)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		r.trusted = append(r.trusted, prefix.Masked())
+	}
+	return r, nil
+}
+
+// ClientIP returns the client address for req. If the immediate peer is a
+// trusted proxy, X-Forwarded-For is walked from the right and the first hop
+// that isn't itself a trusted proxy is the client. Otherwise, or if the header
+// is unusable, the peer address from RemoteAddr is returned.
+func (r *Resolver) ClientIP(req *http.Request) string {
+	peer, ok := parseIP(req.RemoteAddr)
+	if !ok {
+		return req.RemoteAddr
+	}
+	if !r.isTrusted(peer) {
+		return peer.String()
+	}
+
+	hops := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseIP(strings.TrimSpace(hops[i]))
+		if !ok {
+			break
+		}
+		if !r.isTrusted(hop) {
+			return hop.String()
+		}
+	}
+	return peer.String()
+}
+
+func (r *Resolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIP accepts either a bare address or a host:port pair.
+func parseIP(s string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
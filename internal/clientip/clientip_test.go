@@ -0,0 +1,49 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolver_ClientIP(t *testing.T) {
+	r, err := NewResolver("10.0.0.0/8, 192.168.1.1")
+	if err != nil {
+		t.Fatalf("NewResolver() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		want       string
+	}{
+		{"no proxy", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"untrusted peer with spoofed XFF", "203.0.113.7:5000", []string{"1.2.3.4"}, "203.0.113.7"},
+		{"trusted peer", "10.1.2.3:5000", []string{"198.51.100.9"}, "198.51.100.9"},
+		{"trusted single host", "192.168.1.1:5000", []string{"198.51.100.9"}, "198.51.100.9"},
+		{"trusted chain skips inner proxies", "10.1.2.3:5000", []string{"198.51.100.9, 10.9.9.9"}, "198.51.100.9"},
+		{"client spoofs extra hops", "10.1.2.3:5000", []string{"1.2.3.4, 198.51.100.9"}, "198.51.100.9"},
+		{"multiple XFF headers", "10.1.2.3:5000", []string{"1.2.3.4", "198.51.100.9"}, "198.51.100.9"},
+		{"trusted peer without XFF", "10.1.2.3:5000", nil, "10.1.2.3"},
+		{"trusted peer with garbage XFF", "10.1.2.3:5000", []string{"not-an-ip"}, "10.1.2.3"},
+		{"ipv6 peer", "[2001:db8::1]:443", []string{"1.2.3.4"}, "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.xff {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			if got := r.ClientIP(req); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewResolver_Invalid(t *testing.T) {
+	if _, err := NewResolver("10.0.0.0/8,not-a-cidr"); err == nil {
+		t.Error("NewResolver() error = nil, want error for invalid CIDR")
+	}
+}
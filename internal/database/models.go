@@ -6,18 +6,47 @@ package database
 
 import ()
 
+type Follow struct {
+	FollowerID string
+	FolloweeID string
+	CreatedAt  string
+}
+
 type Note struct {
 	ID        string
 	CreatedAt string
 	UpdatedAt string
 	Note      string
 	UserID    string
+	Public    bool
+	Metadata  string
+	IsPinned  bool
 }
 
-type User struct {
+type NoteRevision struct {
 	ID        string
+	NoteID    string
 	CreatedAt string
-	UpdatedAt string
-	Name      string
-	ApiKey    string
+	Note      string
+}
+
+type NoteTag struct {
+	NoteID string
+	Tag    string
+}
+
+type User struct {
+	ID             string
+	CreatedAt      string
+	UpdatedAt      string
+	Name           string
+	ApiKey         string
+	NameNormalized string
+}
+
+type UserRename struct {
+	UserID    string
+	OldName   string
+	NewName   string
+	Migration string
 }
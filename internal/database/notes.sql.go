@@ -7,11 +7,89 @@ package database
 
 import (
 	"context"
+	"database/sql"
 )
 
+const countNotesForUser = `-- name: CountNotesForUser :one
+
+SELECT COUNT(*) FROM notes WHERE user_id = ?
+`
+
+func (q *Queries) CountNotesForUser(ctx context.Context, userID string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countNotesForUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countNotesForUserFiltered = `-- name: CountNotesForUserFiltered :one
+
+SELECT COUNT(*) FROM notes
+WHERE user_id = ?
+  AND created_at >= ?
+  AND created_at <= ?
+  AND (CAST(? AS BOOLEAN) IS NULL
+       OR EXISTS (SELECT 1 FROM note_tags WHERE note_tags.note_id = notes.id) = CAST(? AS BOOLEAN))
+`
+
+type CountNotesForUserFilteredParams struct {
+	UserID        string
+	CreatedAfter  string
+	CreatedBefore string
+	HasTags       sql.NullBool
+}
+
+func (q *Queries) CountNotesForUserFiltered(ctx context.Context, arg CountNotesForUserFilteredParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countNotesForUserFiltered,
+		arg.UserID,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.HasTags,
+		arg.HasTags,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countNotesForUserSince = `-- name: CountNotesForUserSince :one
+
+SELECT COUNT(*) FROM notes WHERE user_id = ? AND created_at >= ?
+`
+
+type CountNotesForUserSinceParams struct {
+	UserID    string
+	CreatedAt string
+}
+
+func (q *Queries) CountNotesForUserSince(ctx context.Context, arg CountNotesForUserSinceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countNotesForUserSince, arg.UserID, arg.CreatedAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countNotesMatchingForUser = `-- name: CountNotesMatchingForUser :one
+
+SELECT COUNT(*) FROM notes
+WHERE user_id = ? AND note LIKE ? ESCAPE '\'
+`
+
+type CountNotesMatchingForUserParams struct {
+	UserID  string
+	Pattern string
+}
+
+func (q *Queries) CountNotesMatchingForUser(ctx context.Context, arg CountNotesMatchingForUserParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countNotesMatchingForUser, arg.UserID, arg.Pattern)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createNote = `-- name: CreateNote :exec
-INSERT INTO notes (id, created_at, updated_at, note, user_id)
-VALUES (?, ?, ?, ?, ?)
+INSERT INTO notes (id, created_at, updated_at, note, user_id, public, metadata)
+VALUES (?, ?, ?, ?, ?, ?, ?)
 `
 
 type CreateNoteParams struct {
@@ -20,6 +98,8 @@ type CreateNoteParams struct {
 	UpdatedAt string
 	Note      string
 	UserID    string
+	Public    bool
+	Metadata  string
 }
 
 func (q *Queries) CreateNote(ctx context.Context, arg CreateNoteParams) error {
@@ -29,13 +109,153 @@ func (q *Queries) CreateNote(ctx context.Context, arg CreateNoteParams) error {
 		arg.UpdatedAt,
 		arg.Note,
 		arg.UserID,
+		arg.Public,
+		arg.Metadata,
 	)
 	return err
 }
 
+const deleteNote = `-- name: DeleteNote :execrows
+
+DELETE FROM notes WHERE id = ? AND user_id = ?
+`
+
+type DeleteNoteParams struct {
+	ID     string
+	UserID string
+}
+
+func (q *Queries) DeleteNote(ctx context.Context, arg DeleteNoteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteNote, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteNotesForUser = `-- name: DeleteNotesForUser :exec
+
+DELETE FROM notes WHERE user_id = ?
+`
+
+func (q *Queries) DeleteNotesForUser(ctx context.Context, userID string) error {
+	_, err := q.db.ExecContext(ctx, deleteNotesForUser, userID)
+	return err
+}
+
+const getActivityForUser = `-- name: GetActivityForUser :many
+
+SELECT CAST('created' AS TEXT) AS type, id AS note_id, created_at AS occurred_at FROM notes
+WHERE user_id = ?
+UNION ALL
+SELECT CAST('updated' AS TEXT) AS type, id AS note_id, updated_at AS occurred_at FROM notes
+WHERE user_id = ? AND updated_at != created_at
+ORDER BY occurred_at DESC, type DESC, note_id DESC
+LIMIT ? OFFSET ?
+`
+
+type GetActivityForUserParams struct {
+	UserID string
+	Limit  int64
+	Offset int64
+}
+
+type GetActivityForUserRow struct {
+	Type       string
+	NoteID     string
+	OccurredAt string
+}
+
+func (q *Queries) GetActivityForUser(ctx context.Context, arg GetActivityForUserParams) ([]GetActivityForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getActivityForUser,
+		arg.UserID,
+		arg.UserID,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetActivityForUserRow
+	for rows.Next() {
+		var i GetActivityForUserRow
+		if err := rows.Scan(&i.Type, &i.NoteID, &i.OccurredAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedForUser = `-- name: GetFeedForUser :many
+
+SELECT notes.id, notes.created_at, notes.updated_at, notes.note, notes.user_id, notes.public, notes.metadata, notes.is_pinned FROM notes
+JOIN follows ON follows.followee_id = notes.user_id
+WHERE follows.follower_id = ? AND notes.public = TRUE
+ORDER BY notes.created_at DESC, notes.rowid DESC
+LIMIT ? OFFSET ?
+`
+
+type GetFeedForUserParams struct {
+	FollowerID string
+	Limit      int64
+	Offset     int64
+}
+
+func (q *Queries) GetFeedForUser(ctx context.Context, arg GetFeedForUserParams) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedForUser, arg.FollowerID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Note,
+			&i.UserID,
+			&i.Public,
+			&i.Metadata,
+			&i.IsPinned,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLastNoteUpdateForUser = `-- name: GetLastNoteUpdateForUser :one
+
+SELECT CAST(COALESCE(MAX(updated_at), '') AS TEXT) AS last_updated_at FROM notes WHERE user_id = ?
+`
+
+func (q *Queries) GetLastNoteUpdateForUser(ctx context.Context, userID string) (string, error) {
+	row := q.db.QueryRowContext(ctx, getLastNoteUpdateForUser, userID)
+	var last_updated_at string
+	err := row.Scan(&last_updated_at)
+	return last_updated_at, err
+}
+
 const getNote = `-- name: GetNote :one
 
-SELECT id, created_at, updated_at, note, user_id FROM notes WHERE id = ?
+SELECT id, created_at, updated_at, note, user_id, public, metadata, is_pinned FROM notes WHERE id = ?
 `
 
 func (q *Queries) GetNote(ctx context.Context, id string) (Note, error) {
@@ -47,17 +267,162 @@ func (q *Queries) GetNote(ctx context.Context, id string) (Note, error) {
 		&i.UpdatedAt,
 		&i.Note,
 		&i.UserID,
+		&i.Public,
+		&i.Metadata,
+		&i.IsPinned,
 	)
 	return i, err
 }
 
+const getNoteIDsForUser = `-- name: GetNoteIDsForUser :many
+
+SELECT id, updated_at FROM notes WHERE user_id = ? ORDER BY id LIMIT ? OFFSET ?
+`
+
+type GetNoteIDsForUserParams struct {
+	UserID string
+	Limit  int64
+	Offset int64
+}
+
+type GetNoteIDsForUserRow struct {
+	ID        string
+	UpdatedAt string
+}
+
+func (q *Queries) GetNoteIDsForUser(ctx context.Context, arg GetNoteIDsForUserParams) ([]GetNoteIDsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getNoteIDsForUser, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNoteIDsForUserRow
+	for rows.Next() {
+		var i GetNoteIDsForUserRow
+		if err := rows.Scan(&i.ID, &i.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNoteVersionsForUser = `-- name: GetNoteVersionsForUser :many
+
+SELECT id, updated_at FROM notes WHERE user_id = ? ORDER BY id
+`
+
+type GetNoteVersionsForUserRow struct {
+	ID        string
+	UpdatedAt string
+}
+
+func (q *Queries) GetNoteVersionsForUser(ctx context.Context, userID string) ([]GetNoteVersionsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getNoteVersionsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNoteVersionsForUserRow
+	for rows.Next() {
+		var i GetNoteVersionsForUserRow
+		if err := rows.Scan(&i.ID, &i.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getNotesForUser = `-- name: GetNotesForUser :many
 
-SELECT id, created_at, updated_at, note, user_id FROM notes WHERE user_id = ?
+SELECT id, created_at, updated_at, note, user_id, public, metadata, is_pinned FROM notes
+WHERE user_id = ?
+  AND is_pinned IN (TRUE, FALSE)
+  AND created_at >= ?
+  AND created_at <= ?
+  AND (CAST(? AS BOOLEAN) IS NULL
+       OR EXISTS (SELECT 1 FROM note_tags WHERE note_tags.note_id = notes.id) = CAST(? AS BOOLEAN))
+ORDER BY is_pinned DESC, created_at DESC, rowid DESC
+LIMIT ? OFFSET ?
+`
+
+type GetNotesForUserParams struct {
+	UserID        string
+	CreatedAfter  string
+	CreatedBefore string
+	HasTags       sql.NullBool
+	Limit         int64
+	Offset        int64
+}
+
+func (q *Queries) GetNotesForUser(ctx context.Context, arg GetNotesForUserParams) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, getNotesForUser,
+		arg.UserID,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.HasTags,
+		arg.HasTags,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Note,
+			&i.UserID,
+			&i.Public,
+			&i.Metadata,
+			&i.IsPinned,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecentNotesForUser = `-- name: GetRecentNotesForUser :many
+
+SELECT id, created_at, updated_at, note, user_id, public, metadata, is_pinned FROM notes
+WHERE user_id = ?
+ORDER BY created_at DESC, rowid DESC
+LIMIT ?
 `
 
-func (q *Queries) GetNotesForUser(ctx context.Context, userID string) ([]Note, error) {
-	rows, err := q.db.QueryContext(ctx, getNotesForUser, userID)
+type GetRecentNotesForUserParams struct {
+	UserID string
+	Limit  int64
+}
+
+func (q *Queries) GetRecentNotesForUser(ctx context.Context, arg GetRecentNotesForUserParams) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentNotesForUser, arg.UserID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +436,131 @@ func (q *Queries) GetNotesForUser(ctx context.Context, userID string) ([]Note, e
 			&i.UpdatedAt,
 			&i.Note,
 			&i.UserID,
+			&i.Public,
+			&i.Metadata,
+			&i.IsPinned,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchNotesForUser = `-- name: SearchNotesForUser :many
+
+SELECT id, created_at, updated_at, note, user_id, public, metadata, is_pinned FROM notes
+WHERE user_id = ? AND note LIKE ? ESCAPE '\'
+ORDER BY created_at DESC, rowid DESC
+LIMIT ? OFFSET ?
+`
+
+type SearchNotesForUserParams struct {
+	UserID  string
+	Pattern string
+	Limit   int64
+	Offset  int64
+}
+
+func (q *Queries) SearchNotesForUser(ctx context.Context, arg SearchNotesForUserParams) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, searchNotesForUser,
+		arg.UserID,
+		arg.Pattern,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Note,
+			&i.UserID,
+			&i.Public,
+			&i.Metadata,
+			&i.IsPinned,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchNotesForUserAfter = `-- name: SearchNotesForUserAfter :many
+
+SELECT rowid, id, created_at, updated_at, note, user_id, public, metadata, is_pinned FROM notes
+WHERE user_id = ? AND note LIKE ? ESCAPE '\'
+  AND (created_at < ?
+       OR (created_at = ? AND rowid < ?))
+ORDER BY created_at DESC, rowid DESC
+LIMIT ?
+`
+
+type SearchNotesForUserAfterParams struct {
+	UserID         string
+	Pattern        string
+	AfterCreatedAt string
+	AfterRowid     int64
+	Limit          int64
+}
+
+type SearchNotesForUserAfterRow struct {
+	Rowid     int64
+	ID        string
+	CreatedAt string
+	UpdatedAt string
+	Note      string
+	UserID    string
+	Public    bool
+	Metadata  string
+	IsPinned  bool
+}
+
+func (q *Queries) SearchNotesForUserAfter(ctx context.Context, arg SearchNotesForUserAfterParams) ([]SearchNotesForUserAfterRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchNotesForUserAfter,
+		arg.UserID,
+		arg.Pattern,
+		arg.AfterCreatedAt,
+		arg.AfterCreatedAt,
+		arg.AfterRowid,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchNotesForUserAfterRow
+	for rows.Next() {
+		var i SearchNotesForUserAfterRow
+		if err := rows.Scan(
+			&i.Rowid,
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Note,
+			&i.UserID,
+			&i.Public,
+			&i.Metadata,
+			&i.IsPinned,
 		); err != nil {
 			return nil, err
 		}
@@ -84,3 +574,67 @@ func (q *Queries) GetNotesForUser(ctx context.Context, userID string) ([]Note, e
 	}
 	return items, nil
 }
+
+const setNoteMetadata = `-- name: SetNoteMetadata :exec
+
+UPDATE notes SET metadata = ?, updated_at = ? WHERE id = ?
+`
+
+type SetNoteMetadataParams struct {
+	Metadata  string
+	UpdatedAt string
+	ID        string
+}
+
+func (q *Queries) SetNoteMetadata(ctx context.Context, arg SetNoteMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, setNoteMetadata, arg.Metadata, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setNotePinned = `-- name: SetNotePinned :exec
+
+UPDATE notes SET is_pinned = ?, updated_at = ? WHERE id = ?
+`
+
+type SetNotePinnedParams struct {
+	IsPinned  bool
+	UpdatedAt string
+	ID        string
+}
+
+func (q *Queries) SetNotePinned(ctx context.Context, arg SetNotePinnedParams) error {
+	_, err := q.db.ExecContext(ctx, setNotePinned, arg.IsPinned, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setNotePublic = `-- name: SetNotePublic :exec
+
+UPDATE notes SET public = ?, updated_at = ? WHERE id = ?
+`
+
+type SetNotePublicParams struct {
+	Public    bool
+	UpdatedAt string
+	ID        string
+}
+
+func (q *Queries) SetNotePublic(ctx context.Context, arg SetNotePublicParams) error {
+	_, err := q.db.ExecContext(ctx, setNotePublic, arg.Public, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const updateNote = `-- name: UpdateNote :exec
+
+UPDATE notes SET note = ?, updated_at = ? WHERE id = ?
+`
+
+type UpdateNoteParams struct {
+	Note      string
+	UpdatedAt string
+	ID        string
+}
+
+func (q *Queries) UpdateNote(ctx context.Context, arg UpdateNoteParams) error {
+	_, err := q.db.ExecContext(ctx, updateNote, arg.Note, arg.UpdatedAt, arg.ID)
+	return err
+}
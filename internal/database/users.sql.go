@@ -10,22 +10,24 @@ import (
 )
 
 const createUser = `-- name: CreateUser :exec
-INSERT INTO users (id, created_at, updated_at, name, api_key)
+INSERT INTO users (id, created_at, updated_at, name, api_key, name_normalized)
 VALUES (
     ?,
     ?,
     ?,
     ?,
+    ?,
     ?
 )
 `
 
 type CreateUserParams struct {
-	ID        string
-	CreatedAt string
-	UpdatedAt string
-	Name      string
-	ApiKey    string
+	ID             string
+	CreatedAt      string
+	UpdatedAt      string
+	Name           string
+	ApiKey         string
+	NameNormalized string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
@@ -35,13 +37,34 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
 		arg.UpdatedAt,
 		arg.Name,
 		arg.ApiKey,
+		arg.NameNormalized,
 	)
 	return err
 }
 
+const deleteUser = `-- name: DeleteUser :exec
+
+DELETE FROM users WHERE id = ?
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
+const deleteUserRenames = `-- name: DeleteUserRenames :exec
+
+DELETE FROM user_renames WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserRenames(ctx context.Context, userID string) error {
+	_, err := q.db.ExecContext(ctx, deleteUserRenames, userID)
+	return err
+}
+
 const getUser = `-- name: GetUser :one
 
-SELECT id, created_at, updated_at, name, api_key FROM users WHERE api_key = ?
+SELECT id, created_at, updated_at, name, api_key, name_normalized FROM users WHERE api_key = ?
 `
 
 func (q *Queries) GetUser(ctx context.Context, apiKey string) (User, error) {
@@ -53,6 +76,108 @@ func (q *Queries) GetUser(ctx context.Context, apiKey string) (User, error) {
 		&i.UpdatedAt,
 		&i.Name,
 		&i.ApiKey,
+		&i.NameNormalized,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+
+SELECT id, created_at, updated_at, name, api_key, name_normalized FROM users WHERE id = ?
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKey,
+		&i.NameNormalized,
 	)
 	return i, err
 }
+
+const searchUsersByNamePrefix = `-- name: SearchUsersByNamePrefix :many
+
+SELECT id, created_at, updated_at, name, api_key, name_normalized FROM users
+WHERE name LIKE ? ESCAPE '\'
+ORDER BY name ASC, id ASC
+LIMIT ? OFFSET ?
+`
+
+type SearchUsersByNamePrefixParams struct {
+	Pattern string
+	Limit   int64
+	Offset  int64
+}
+
+func (q *Queries) SearchUsersByNamePrefix(ctx context.Context, arg SearchUsersByNamePrefixParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, searchUsersByNamePrefix, arg.Pattern, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.ApiKey,
+			&i.NameNormalized,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateUserAPIKey = `-- name: UpdateUserAPIKey :exec
+
+UPDATE users SET api_key = ?, updated_at = ? WHERE id = ?
+`
+
+type UpdateUserAPIKeyParams struct {
+	ApiKey    string
+	UpdatedAt string
+	ID        string
+}
+
+func (q *Queries) UpdateUserAPIKey(ctx context.Context, arg UpdateUserAPIKeyParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserAPIKey, arg.ApiKey, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const updateUserName = `-- name: UpdateUserName :exec
+
+UPDATE users SET name = ?, name_normalized = ?, updated_at = ? WHERE id = ?
+`
+
+type UpdateUserNameParams struct {
+	Name           string
+	NameNormalized string
+	UpdatedAt      string
+	ID             string
+}
+
+func (q *Queries) UpdateUserName(ctx context.Context, arg UpdateUserNameParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserName,
+		arg.Name,
+		arg.NameNormalized,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	return err
+}
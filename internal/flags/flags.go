@@ -0,0 +1,108 @@
+// Package flags holds feature flags that can be switched while the server
+// runs.
+package flags
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Set is a fixed list of known flags and their current state. It is safe
+// for concurrent use.
+type Set struct {
+	defaults map[string]bool
+
+	mu      sync.RWMutex
+	enabled map[string]bool
+}
+
+// New returns a Set of the flags in defaults, each in its default state.
+func New(defaults map[string]bool) *Set {
+	return &Set{defaults: maps.Clone(defaults), enabled: maps.Clone(defaults)}
+}
+
+// Load resets every flag to its default and then applies spec, a list of
+// entries separated by commas or whitespace. "name" or "name=true" turns a
+// flag on and "name=false" turns it off. Unknown flags and values that
+// aren't booleans are errors, and leave the Set unchanged.
+func (s *Set) Load(spec string) error {
+	enabled := maps.Clone(s.defaults)
+	entries := strings.FieldsFunc(spec, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	for _, entry := range entries {
+		name, value, hasValue := strings.Cut(entry, "=")
+		if _, ok := s.defaults[name]; !ok {
+			return fmt.Errorf("unknown feature flag %q", name)
+		}
+		on := true
+		if hasValue {
+			var err error
+			if on, err = strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("feature flag %s must be a boolean, got %q", name, value)
+			}
+		}
+		enabled[name] = on
+	}
+
+	s.mu.Lock()
+	s.enabled = enabled
+	s.mu.Unlock()
+	return nil
+}
+
+// IsEnabled reports whether the named flag is on. Unknown flags are off.
+func (s *Set) IsEnabled(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.enabled[name]
+}
+
+// String lists every flag as name=on or name=off, sorted by name.
+func (s *Set) String() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]string, 0, len(s.enabled))
+	for name, on := range s.enabled {
+		state := "off"
+		if on {
+			state = "on"
+		}
+		entries = append(entries, name+"="+state)
+	}
+	slices.Sort(entries)
+	return strings.Join(entries, " ")
+}
+
+// Watch loads the spec returned by read every interval until ctx is done.
+// A failed read or load is logged and keeps the current state.
+func (s *Set) Watch(ctx context.Context, interval time.Duration, read func() (string, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		spec, err := read()
+		if err == nil {
+			before := s.String()
+			if err = s.Load(spec); err == nil && s.String() != before {
+				log.Printf("Feature flags changed: %s", s)
+			}
+		}
+		if err != nil {
+			log.Printf("Couldn't refresh feature flags: %v", err)
+		}
+	}
+}
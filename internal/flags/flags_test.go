@@ -0,0 +1,63 @@
+package flags
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	s := New(map[string]bool{"batch": true, "pins": false, "beta": false})
+
+	if err := s.Load("pins, batch=false\nbeta=1"); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got, want := s.String(), "batch=off beta=on pins=on"; got != want {
+		t.Errorf("after Load, flags = %q, want %q", got, want)
+	}
+
+	// Each Load starts from the defaults.
+	if err := s.Load("beta"); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got, want := s.String(), "batch=on beta=on pins=off"; got != want {
+		t.Errorf("after second Load, flags = %q, want %q", got, want)
+	}
+
+	for _, spec := range []string{"pins,nope", "pins=maybe"} {
+		if err := s.Load(spec); err == nil {
+			t.Errorf("Load(%q) error = nil, want one", spec)
+		}
+	}
+	if got, want := s.String(), "batch=on beta=on pins=off"; got != want {
+		t.Errorf("after failed Loads, flags = %q, want them unchanged %q", got, want)
+	}
+	if s.IsEnabled("nope") {
+		t.Error("IsEnabled(unknown flag) = true, want false")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	s := New(map[string]bool{"pins": false})
+	var spec atomic.Value
+	spec.Store("")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Watch(ctx, time.Millisecond, func() (string, error) { return spec.Load().(string), nil })
+		close(done)
+	}()
+
+	spec.Store("pins")
+	deadline := time.Now().Add(time.Second)
+	for !s.IsEnabled("pins") {
+		if time.Now().After(deadline) {
+			t.Fatal("Watch didn't pick up the new spec")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
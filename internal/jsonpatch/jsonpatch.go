@@ -0,0 +1,292 @@
+// Package jsonpatch applies RFC 6902 JSON Patch documents.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MediaType is the Content-Type of a JSON Patch document.
+const MediaType = "application/json-patch+json"
+
+// ErrTestFailed is returned by Apply when a "test" operation doesn't match.
+var ErrTestFailed = errors.New("jsonpatch: test operation failed")
+
+// Operation is a single entry of a patch document.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch is an ordered list of operations, applied atomically.
+type Patch []Operation
+
+// Decode reads a patch document and checks that every operation is well
+// formed, so that Apply only fails on the target document.
+func Decode(r io.Reader) (Patch, error) {
+	var patch Patch
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&patch); err != nil {
+		return nil, fmt.Errorf("jsonpatch: %w", err)
+	}
+	for i, op := range patch {
+		if err := op.validate(); err != nil {
+			return nil, fmt.Errorf("jsonpatch: operation %d: %w", i, err)
+		}
+	}
+	return patch, nil
+}
+
+func (op Operation) validate() error {
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return fmt.Errorf("%s requires a value", op.Op)
+		}
+	case "move", "copy":
+		if _, err := parsePointer(op.From); err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+	case "remove":
+	default:
+		return fmt.Errorf("unknown op %q", op.Op)
+	}
+	if _, err := parsePointer(op.Path); err != nil {
+		return fmt.Errorf("path: %w", err)
+	}
+	return nil
+}
+
+// Apply returns doc with the patch applied. doc itself is never modified,
+// and no partial result is returned if an operation fails.
+func (p Patch) Apply(doc []byte) ([]byte, error) {
+	var root any
+	if err := unmarshal(doc, &root); err != nil {
+		return nil, fmt.Errorf("jsonpatch: decoding document: %w", err)
+	}
+	for i, op := range p {
+		var err error
+		root, err = op.apply(root)
+		if err != nil {
+			if errors.Is(err, ErrTestFailed) {
+				return nil, fmt.Errorf("%w at operation %d (%s)", ErrTestFailed, i, op.Path)
+			}
+			return nil, fmt.Errorf("jsonpatch: operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+func (op Operation) apply(root any) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	if op.Value != nil {
+		if err := unmarshal(op.Value, &value); err != nil {
+			return nil, err
+		}
+	}
+
+	switch op.Op {
+	case "add":
+		return add(root, path, value)
+	case "remove":
+		root, _, err = remove(root, path)
+		return root, err
+	case "replace":
+		if len(path) == 0 {
+			return value, nil
+		}
+		if root, _, err = remove(root, path); err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+	case "move":
+		from, _ := parsePointer(op.From)
+		if op.Path != op.From && strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, errors.New("can't move a value into one of its children")
+		}
+		root, value, err = remove(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+	case "copy":
+		from, _ := parsePointer(op.From)
+		value, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = deepCopy(value); err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+	case "test":
+		got, err := get(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(got, value) {
+			return nil, ErrTestFailed
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped tokens.
+func parsePointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(ptr, "/") {
+		return nil, fmt.Errorf("pointer %q must start with /", ptr)
+	}
+	tokens := strings.Split(ptr[1:], "/")
+	for i, tok := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(tok)
+	}
+	return tokens, nil
+}
+
+func get(node any, path []string) (any, error) {
+	for _, tok := range path {
+		var err error
+		if node, err = child(node, tok); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+func child(node any, tok string) (any, error) {
+	switch n := node.(type) {
+	case map[string]any:
+		v, ok := n[tok]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", tok)
+		}
+		return v, nil
+	case []any:
+		i, err := arrayIndex(tok, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		return n[i], nil
+	}
+	return nil, fmt.Errorf("can't index %T with %q", node, tok)
+}
+
+// arrayIndex parses tok as an index no larger than limit.
+func arrayIndex(tok string, limit int) (int, error) {
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i < 0 || i > limit {
+		return 0, fmt.Errorf("array index %q out of range", tok)
+	}
+	return i, nil
+}
+
+// update rebuilds the path down to the parent of its last token, which fn
+// replaces. Array edits can reallocate, so every ancestor is reassigned.
+func update(node any, path []string, fn func(parent any, tok string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(node, path[0])
+	}
+	next, err := child(node, path[0])
+	if err != nil {
+		return nil, err
+	}
+	if next, err = update(next, path[1:], fn); err != nil {
+		return nil, err
+	}
+	switch n := node.(type) {
+	case map[string]any:
+		n[path[0]] = next
+	case []any:
+		i, _ := arrayIndex(path[0], len(n)-1)
+		n[i] = next
+	}
+	return node, nil
+}
+
+func add(root any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(root, path, func(parent any, tok string) (any, error) {
+		switch p := parent.(type) {
+		case map[string]any:
+			p[tok] = value
+			return p, nil
+		case []any:
+			if tok == "-" {
+				return append(p, value), nil
+			}
+			i, err := arrayIndex(tok, len(p))
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = value
+			return p, nil
+		}
+		return nil, fmt.Errorf("can't add %q to %T", tok, parent)
+	})
+}
+
+func remove(root any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("can't remove the whole document")
+	}
+	var removed any
+	root, err := update(root, path, func(parent any, tok string) (any, error) {
+		var err error
+		if removed, err = child(parent, tok); err != nil {
+			return nil, err
+		}
+		switch p := parent.(type) {
+		case map[string]any:
+			delete(p, tok)
+			return p, nil
+		case []any:
+			i, _ := arrayIndex(tok, len(p)-1)
+			return append(p[:i], p[i+1:]...), nil
+		}
+		return parent, nil
+	})
+	return root, removed, err
+}
+
+func deepCopy(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	err = unmarshal(data, &out)
+	return out, err
+}
+
+// unmarshal keeps numbers as json.Number so that "test" compares them as
+// written rather than after a round trip through float64.
+func unmarshal(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
@@ -0,0 +1,167 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{
+			name:  "add member",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz","value":"qux"}]`,
+			want:  `{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			name:  "add array element",
+			doc:   `{"foo":["bar","baz"]}`,
+			patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			want:  `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:  "append to array",
+			doc:   `{"foo":["bar"]}`,
+			patch: `[{"op":"add","path":"/foo/-","value":["abc"]}]`,
+			want:  `{"foo":["bar",["abc"]]}`,
+		},
+		{
+			name:  "remove array element",
+			doc:   `{"foo":["bar","qux","baz"]}`,
+			patch: `[{"op":"remove","path":"/foo/1"}]`,
+			want:  `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:  "replace",
+			doc:   `{"baz":"qux","foo":"bar"}`,
+			patch: `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			want:  `{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			name:  "move",
+			doc:   `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			want:  `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			name:  "copy",
+			doc:   `{"a":{"b":1}}`,
+			patch: `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`,
+			want:  `{"a":{"b":1},"c":{"b":2}}`,
+		},
+		{
+			name:  "test then replace",
+			doc:   `{"baz":"qux","n":1}`,
+			patch: `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/n","value":1},{"op":"replace","path":"/baz","value":null}]`,
+			want:  `{"baz":null,"n":1}`,
+		},
+		{
+			name:  "escaped pointer",
+			doc:   `{"a/b":1,"m~n":2}`,
+			patch: `[{"op":"remove","path":"/a~1b"},{"op":"remove","path":"/m~0n"}]`,
+			want:  `{}`,
+		},
+		{
+			name:  "replace whole document",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"replace","path":"","value":{"baz":1}}]`,
+			want:  `{"baz":1}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := Decode(strings.NewReader(tt.patch))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			got, err := patch.Apply([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		doc        string
+		patch      string
+		testFailed bool
+	}{
+		{"test mismatch", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, true},
+		{"remove missing member", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, false},
+		{"replace missing member", `{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":1}]`, false},
+		{"add past end of array", `{"foo":[]}`, `[{"op":"add","path":"/foo/1","value":1}]`, false},
+		{"leading zero index", `{"foo":[1,2]}`, `[{"op":"remove","path":"/foo/01"}]`, false},
+		{"missing parent", `{}`, `[{"op":"add","path":"/a/b","value":1}]`, false},
+		{"move into own child", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/c"}]`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := Decode(strings.NewReader(tt.patch))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			_, err = patch.Apply([]byte(tt.doc))
+			if err == nil {
+				t.Fatal("Apply() error = nil, want an error")
+			}
+			if got := errors.Is(err, ErrTestFailed); got != tt.testFailed {
+				t.Errorf("Apply() error = %v, want ErrTestFailed: %v", err, tt.testFailed)
+			}
+		})
+	}
+}
+
+func TestApplyIsAtomic(t *testing.T) {
+	patch, err := Decode(strings.NewReader(`[{"op":"add","path":"/a","value":1},{"op":"remove","path":"/missing"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := []byte(`{"b":2}`)
+	if _, err := patch.Apply(doc); err == nil {
+		t.Fatal("Apply() error = nil, want an error")
+	}
+	if string(doc) != `{"b":2}` {
+		t.Errorf("document modified to %s", doc)
+	}
+}
+
+func TestDecodeRejectsMalformedPatches(t *testing.T) {
+	for _, patch := range []string{
+		`{"op":"add"}`,
+		`[{"op":"frobnicate","path":"/a"}]`,
+		`[{"op":"add","path":"/a"}]`,
+		`[{"op":"remove","path":"a"}]`,
+		`[{"op":"move","path":"/a","from":"b"}]`,
+		`[{"op":"remove","path":"/a","extra":true}]`,
+	} {
+		if _, err := Decode(strings.NewReader(patch)); err == nil {
+			t.Errorf("Decode(%s) error = nil, want an error", patch)
+		}
+	}
+}
+
+func assertJSONEqual(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("invalid JSON %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,94 @@
+// Package lifecycle starts long-running components in registration order and
+// stops them in reverse, so that dependencies outlive their dependents.
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+type component struct {
+	name  string
+	start func(context.Context) error
+	stop  func(context.Context) error
+}
+
+type Manager struct {
+	stopTimeout time.Duration
+
+	mu         sync.Mutex
+	components []component
+	started    []component
+}
+
+// New returns a Manager that gives each component at most stopTimeout to
+// stop.
+func New(stopTimeout time.Duration) *Manager {
+	return &Manager{stopTimeout: stopTimeout}
+}
+
+// Register adds a component. Either function may be nil.
+func (m *Manager) Register(name string, start, stop func(context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, component{name: name, start: start, stop: stop})
+}
+
+// Start starts every registered component in order. If one fails, the
+// components already started are stopped and the error is returned.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	components := append([]component(nil), m.components...)
+	m.mu.Unlock()
+
+	for _, c := range components {
+		if c.start != nil {
+			if err := c.start(ctx); err != nil {
+				m.Stop(context.Background())
+				return fmt.Errorf("starting %s: %w", c.name, err)
+			}
+		}
+		m.mu.Lock()
+		m.started = append(m.started, c)
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// Stop stops started components in reverse order. Each stop gets its own
+// timeout; failures and timeouts are logged and don't block the rest.
+func (m *Manager) Stop(ctx context.Context) {
+	m.mu.Lock()
+	started := m.started
+	m.started = nil
+	m.mu.Unlock()
+
+	for i := len(started) - 1; i >= 0; i-- {
+		c := started[i]
+		if c.stop == nil {
+			continue
+		}
+		if err := m.stopOne(ctx, c); err != nil {
+			log.Printf("Error stopping %s: %v", c.name, err)
+		}
+	}
+}
+
+func (m *Manager) stopOne(ctx context.Context, c component) error {
+	ctx, cancel := context.WithTimeout(ctx, m.stopTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.stop(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s", m.stopTimeout)
+	}
+}
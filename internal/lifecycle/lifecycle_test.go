@@ -0,0 +1,94 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) func(context.Context) error {
+	return func(context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.events = append(r.events, event)
+		return nil
+	}
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func TestManager_OrderedStartStop(t *testing.T) {
+	var rec recorder
+	m := New(time.Second)
+	m.Register("db", rec.record("start db"), rec.record("stop db"))
+	m.Register("worker", rec.record("start worker"), rec.record("stop worker"))
+	m.Register("server", rec.record("start server"), rec.record("stop server"))
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	m.Stop(context.Background())
+
+	want := []string{"start db", "start worker", "start server", "stop server", "stop worker", "stop db"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestManager_StopTimeoutAndFailureDontBlockOthers(t *testing.T) {
+	var rec recorder
+	m := New(20 * time.Millisecond)
+	m.Register("db", nil, rec.record("stop db"))
+	m.Register("broken", nil, func(context.Context) error {
+		return errors.New("boom")
+	})
+	m.Register("hung", nil, func(context.Context) error {
+		select {}
+	})
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		m.Stop(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop() did not return after the hung component timed out")
+	}
+
+	if got, want := rec.get(), []string{"stop db"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestManager_StartFailureStopsStartedComponents(t *testing.T) {
+	var rec recorder
+	m := New(time.Second)
+	m.Register("db", rec.record("start db"), rec.record("stop db"))
+	m.Register("server", func(context.Context) error {
+		return errors.New("port in use")
+	}, rec.record("stop server"))
+
+	if err := m.Start(context.Background()); err == nil {
+		t.Fatal("Start() error = nil, want error")
+	}
+	if got, want := rec.get(), []string{"start db", "stop db"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,163 @@
+// Package markdown renders a small, safe subset of Markdown to HTML:
+// headings, paragraphs, unordered lists, fenced code blocks, emphasis,
+// inline code, and links. Raw HTML in the input is always escaped and links
+// are limited to http, https, and mailto URLs.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"strings"
+)
+
+// ToHTML renders src. It never passes input HTML through.
+func ToHTML(src string) string {
+	var b strings.Builder
+	var paragraph []string
+	inList := false
+	inCode := false
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			b.WriteString("<p>")
+			b.WriteString(renderInline(strings.Join(paragraph, " ")))
+			b.WriteString("</p>\n")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if inList {
+			b.WriteString("</ul>\n")
+			inList = false
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		if inCode {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				b.WriteString("</code></pre>\n")
+				inCode = false
+				continue
+			}
+			b.WriteString(html.EscapeString(line))
+			b.WriteString("\n")
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flushParagraph()
+			closeList()
+			b.WriteString("<pre><code>")
+			inCode = true
+		case trimmed == "":
+			flushParagraph()
+			closeList()
+		case headingLevel(trimmed) > 0:
+			flushParagraph()
+			closeList()
+			level := headingLevel(trimmed)
+			tag := "h" + string(rune('0'+level))
+			b.WriteString("<" + tag + ">")
+			b.WriteString(renderInline(strings.TrimSpace(trimmed[level:])))
+			b.WriteString("</" + tag + ">\n")
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			flushParagraph()
+			if !inList {
+				b.WriteString("<ul>\n")
+				inList = true
+			}
+			b.WriteString("<li>")
+			b.WriteString(renderInline(strings.TrimSpace(trimmed[2:])))
+			b.WriteString("</li>\n")
+		default:
+			closeList()
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+
+	flushParagraph()
+	closeList()
+	if inCode {
+		b.WriteString("</code></pre>\n")
+	}
+	return b.String()
+}
+
+// headingLevel returns 1-6 for an ATX heading line and 0 otherwise.
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level >= len(line) || line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+func renderInline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end >= 0 {
+				b.WriteString("<code>" + html.EscapeString(s[i+1:i+1+end]) + "</code>")
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(s[i:], "**"):
+			if end := strings.Index(s[i+2:], "**"); end > 0 {
+				b.WriteString("<strong>" + renderInline(s[i+2:i+2+end]) + "</strong>")
+				i += end + 4
+				continue
+			}
+		case s[i] == '*' || s[i] == '_':
+			if end := strings.IndexByte(s[i+1:], s[i]); end > 0 {
+				b.WriteString("<em>" + renderInline(s[i+1:i+1+end]) + "</em>")
+				i += end + 2
+				continue
+			}
+		case s[i] == '[':
+			if text, href, n, ok := parseLink(s[i:]); ok {
+				if safeURL(href) {
+					b.WriteString(`<a href="` + html.EscapeString(href) + `">` + renderInline(text) + "</a>")
+				} else {
+					b.WriteString(renderInline(text))
+				}
+				i += n
+				continue
+			}
+		}
+		b.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+	return b.String()
+}
+
+// parseLink parses "[text](href)" at the start of s, returning the number of
+// bytes consumed.
+func parseLink(s string) (text, href string, n int, ok bool) {
+	closeText := strings.Index(s, "](")
+	if closeText < 0 {
+		return "", "", 0, false
+	}
+	closeHref := strings.IndexByte(s[closeText+2:], ')')
+	if closeHref < 0 {
+		return "", "", 0, false
+	}
+	return s[1:closeText], s[closeText+2 : closeText+2+closeHref], closeText + 3 + closeHref, true
+}
+
+func safeURL(href string) bool {
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package markdown
+
+import "testing"
+
+func TestToHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"heading", "# Title", "<h1>Title</h1>\n"},
+		{"deeper heading", "### Sub *section*", "<h3>Sub <em>section</em></h3>\n"},
+		{"not a heading without space", "#hashtag", "<p>#hashtag</p>\n"},
+		{"paragraphs", "one\ntwo\n\nthree", "<p>one two</p>\n<p>three</p>\n"},
+		{"emphasis and code", "**bold** and `x < y`", "<p><strong>bold</strong> and <code>x &lt; y</code></p>\n"},
+		{"link", "see [the docs](https://example.com/a?b=1&c=2)", `<p>see <a href="https://example.com/a?b=1&amp;c=2">the docs</a></p>` + "\n"},
+		{"javascript link is dropped", "[click](javascript:alert%281%29)", "<p>click</p>\n"},
+		{"list", "- one\n- two", "<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n"},
+		{"code block", "```\n<b>raw</b>\n```", "<pre><code>&lt;b&gt;raw&lt;/b&gt;\n</code></pre>\n"},
+		{"embedded html is escaped", `<script>alert("x")</script>`, "<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;</p>\n"},
+		{"html inside link text", `[<img src=x onerror=alert(1)>](https://example.com)`, `<p><a href="https://example.com">&lt;img src=x onerror=alert(1)&gt;</a></p>` + "\n"},
+		{"quote in href is escaped", `[x](https://example.com/"onmouseover="alert(1))`, `<p><a href="https://example.com/&#34;onmouseover=&#34;alert(1">x</a>)</p>` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToHTML(tt.src); got != tt.want {
+				t.Errorf("ToHTML(%q) =\n%q\nwant\n%q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Package metrics implements the small subset of Prometheus-style counters
+// the server exports on /metrics, without pulling in a client library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// CounterVec is a set of counters sharing a name and a single label.
+type CounterVec struct {
+	name  string
+	help  string
+	label string
+
+	mu     sync.RWMutex
+	values map[string]*atomic.Uint64
+}
+
+// NewCounterVec returns a CounterVec with the given label values
+// pre-registered so they are exported as zero before their first increment.
+func NewCounterVec(name, help, label string, labelValues ...string) *CounterVec {
+	c := &CounterVec{
+		name:   name,
+		help:   help,
+		label:  label,
+		values: make(map[string]*atomic.Uint64),
+	}
+	for _, v := range labelValues {
+		c.values[v] = new(atomic.Uint64)
+	}
+	return c
+}
+
+func (c *CounterVec) Inc(labelValue string) {
+	c.mu.RLock()
+	counter, ok := c.values[labelValue]
+	c.mu.RUnlock()
+	if !ok {
+		c.mu.Lock()
+		if counter, ok = c.values[labelValue]; !ok {
+			counter = new(atomic.Uint64)
+			c.values[labelValue] = counter
+		}
+		c.mu.Unlock()
+	}
+	counter.Add(1)
+}
+
+func (c *CounterVec) Value(labelValue string) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if counter, ok := c.values[labelValue]; ok {
+		return counter.Load()
+	}
+	return 0
+}
+
+func (c *CounterVec) writeTo(w io.Writer) error {
+	c.mu.RLock()
+	labelValues := make([]string, 0, len(c.values))
+	for v := range c.values {
+		labelValues = append(labelValues, v)
+	}
+	c.mu.RUnlock()
+	sort.Strings(labelValues)
+
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name); err != nil {
+		return err
+	}
+	for _, v := range labelValues {
+		if _, err := fmt.Fprintf(w, "%s{%s=%q} %d\n", c.name, c.label, v, c.Value(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Registry struct {
+	mu       sync.Mutex
+	counters []*CounterVec
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) Register(c *CounterVec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counters = append(r.counters, c)
+}
+
+// Handler serves the registered counters in the Prometheus text format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		r.mu.Lock()
+		counters := append([]*CounterVec(nil), r.counters...)
+		r.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		for _, c := range counters {
+			if err := c.writeTo(w); err != nil {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,110 @@
+// Package nonce issues single-use tokens that expire after a fixed TTL. It
+// is safe for concurrent use.
+package nonce
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnknown means the nonce was never issued to the subject, or has
+	// been forgotten.
+	ErrUnknown = errors.New("nonce: unknown")
+	// ErrUsed means the nonce has already been consumed.
+	ErrUsed = errors.New("nonce: already used")
+	// ErrExpired means the nonce outlived its TTL before being consumed.
+	ErrExpired = errors.New("nonce: expired")
+)
+
+type Store struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]entry
+}
+
+type entry struct {
+	subject string
+	expires time.Time
+	used    bool
+}
+
+func New(ttl time.Duration) *Store {
+	return &Store{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]entry),
+	}
+}
+
+// Issue returns a fresh nonce for subject and the time it expires.
+func (s *Store) Issue(subject string) (string, time.Time, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	nonce := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.prune(now)
+	expires := now.Add(s.ttl)
+	s.entries[nonce] = entry{subject: subject, expires: expires}
+	return nonce, expires, nil
+}
+
+// Consume marks nonce as used if it was issued to subject and is still
+// valid. Otherwise it returns ErrUnknown, ErrUsed or ErrExpired.
+func (s *Store) Consume(subject, nonce string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[nonce]
+	switch {
+	case !ok || e.subject != subject:
+		return ErrUnknown
+	case e.used:
+		return ErrUsed
+	case !s.now().Before(e.expires):
+		return ErrExpired
+	}
+	e.used = true
+	s.entries[nonce] = e
+	return nil
+}
+
+// Lookup returns the subject nonce was issued to if it is still valid,
+// without consuming it. It suits nonces used as short-lived bearer tokens.
+// Otherwise it returns ErrUnknown, ErrUsed or ErrExpired.
+func (s *Store) Lookup(nonce string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[nonce]
+	switch {
+	case !ok:
+		return "", ErrUnknown
+	case e.used:
+		return "", ErrUsed
+	case !s.now().Before(e.expires):
+		return "", ErrExpired
+	}
+	return e.subject, nil
+}
+
+// prune forgets nonces that expired more than a TTL ago. Keeping them that
+// long lets a late replay be told apart from a made-up nonce.
+// It must be called with s.mu held.
+func (s *Store) prune(now time.Time) {
+	for nonce, e := range s.entries {
+		if now.Sub(e.expires) > s.ttl {
+			delete(s.entries, nonce)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package nonce
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConsumeOnce(t *testing.T) {
+	s := New(time.Minute)
+	n, _, err := s.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Consume("alice", n); err != nil {
+		t.Fatalf("first Consume() error = %v, want nil", err)
+	}
+	if err := s.Consume("alice", n); !errors.Is(err, ErrUsed) {
+		t.Errorf("replayed Consume() error = %v, want ErrUsed", err)
+	}
+}
+
+func TestConsumeWrongSubject(t *testing.T) {
+	s := New(time.Minute)
+	n, _, err := s.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Consume("bob", n); !errors.Is(err, ErrUnknown) {
+		t.Errorf("Consume(bob) error = %v, want ErrUnknown", err)
+	}
+	if err := s.Consume("alice", "made-up"); !errors.Is(err, ErrUnknown) {
+		t.Errorf("Consume(made-up) error = %v, want ErrUnknown", err)
+	}
+	if err := s.Consume("alice", n); err != nil {
+		t.Errorf("Consume(alice) error = %v, want nil", err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := New(time.Minute)
+	s.now = func() time.Time { return now }
+	n, _, err := s.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if subject, err := s.Lookup(n); err != nil || subject != "alice" {
+			t.Fatalf("Lookup() = %q, %v; want alice, nil", subject, err)
+		}
+	}
+	if _, err := s.Lookup("made-up"); !errors.Is(err, ErrUnknown) {
+		t.Errorf("Lookup(made-up) error = %v, want ErrUnknown", err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := s.Lookup(n); !errors.Is(err, ErrExpired) {
+		t.Errorf("Lookup() at TTL error = %v, want ErrExpired", err)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := New(time.Minute)
+	s.now = func() time.Time { return now }
+	n, expires, err := s.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := now.Add(time.Minute); !expires.Equal(want) {
+		t.Errorf("expires = %v, want %v", expires, want)
+	}
+
+	now = now.Add(time.Minute)
+	if err := s.Consume("alice", n); !errors.Is(err, ErrExpired) {
+		t.Errorf("Consume() at TTL error = %v, want ErrExpired", err)
+	}
+
+	// Pruning happens on Issue, once the nonce is more than a TTL past expiry.
+	now = now.Add(time.Minute + time.Second)
+	if _, _, err := s.Issue("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Consume("alice", n); !errors.Is(err, ErrUnknown) {
+		t.Errorf("Consume() after pruning error = %v, want ErrUnknown", err)
+	}
+}
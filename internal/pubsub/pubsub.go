@@ -0,0 +1,61 @@
+// Package pubsub fans out newly created notes to in-process subscribers.
+package pubsub
+
+import (
+	"sync"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// subscriberBuffer bounds how far a slow subscriber may fall behind before
+// notes are dropped for it rather than blocking the publisher.
+const subscriberBuffer = 16
+
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan database.Note]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[string]map[chan database.Note]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving notes published for userID and a
+// function that unsubscribes. The channel is never closed.
+func (b *Broker) Subscribe(userID string) (<-chan database.Note, func()) {
+	ch := make(chan database.Note, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[chan database.Note]struct{})
+	}
+	b.subscribers[userID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers[userID], ch)
+			if len(b.subscribers[userID]) == 0 {
+				delete(b.subscribers, userID)
+			}
+		})
+	}
+}
+
+// Publish delivers note to all subscribers of its owner without blocking.
+func (b *Broker) Publish(note database.Note) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[note.UserID] {
+		select {
+		case ch <- note:
+		default:
+		}
+	}
+}
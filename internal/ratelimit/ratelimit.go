@@ -0,0 +1,100 @@
+// Package ratelimit implements per-key token buckets.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Status is a bucket's state after a call to Allow.
+type Status struct {
+	// Allowed reports whether the request got a token.
+	Allowed bool
+	// Limit is the bucket's capacity.
+	Limit int
+	// Remaining is how many whole tokens are left.
+	Remaining int
+	// Reset is when the bucket will be full again.
+	Reset time.Time
+	// RetryAfter is how long until the next token, when Allowed is false.
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter gives every key a bucket of limit tokens that refills at an even
+// rate over window. It is safe for concurrent use.
+type Limiter struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+// New returns a Limiter allowing bursts of limit requests per key and limit
+// requests per window on average.
+func New(limit int, window time.Duration) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		window:  window,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from key's bucket if one is left.
+func (l *Limiter) Allow(key string) Status {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.last = now
+
+	status := Status{Limit: l.limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		status.Allowed = true
+	} else {
+		status.RetryAfter = l.perToken(1 - b.tokens)
+	}
+	status.Remaining = int(b.tokens)
+	status.Reset = now.Add(l.perToken(float64(l.limit) - b.tokens))
+	return status
+}
+
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	elapsed := now.Sub(b.last).Seconds()
+	return min(float64(l.limit), b.tokens+elapsed*float64(l.limit)/l.window.Seconds())
+}
+
+// perToken returns how long n tokens take to refill.
+func (l *Limiter) perToken(n float64) time.Duration {
+	return time.Duration(n * float64(l.window) / float64(l.limit))
+}
+
+// prune drops buckets that have refilled completely, at most once per
+// window, so idle keys don't accumulate. It must be called with l.mu held.
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.window {
+		return
+	}
+	l.lastPrune = now
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= float64(l.limit) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(3, time.Minute)
+	l.now = func() time.Time { return now }
+
+	for i, want := range []int{2, 1, 0} {
+		s := l.Allow("alice")
+		if !s.Allowed || s.Remaining != want || s.Limit != 3 {
+			t.Fatalf("request %d: %+v, want allowed with %d remaining", i+1, s, want)
+		}
+	}
+	if s := l.Allow("alice"); s.Allowed || s.RetryAfter != 20*time.Second || !s.Reset.Equal(now.Add(time.Minute)) {
+		t.Errorf("over the limit: %+v, want denied, retry after 20s, reset in a minute", s)
+	}
+	if s := l.Allow("bob"); !s.Allowed || s.Remaining != 2 {
+		t.Errorf("other key: %+v, want its own full bucket", s)
+	}
+
+	// One token refills every 20 seconds.
+	now = now.Add(20 * time.Second)
+	if s := l.Allow("alice"); !s.Allowed || s.Remaining != 0 {
+		t.Errorf("after 20s: %+v, want allowed with 0 remaining", s)
+	}
+}
+
+func TestPruneKeepsPartialBuckets(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	l.Allow("idle")
+	l.Allow("busy")
+	now = now.Add(time.Minute)
+	l.Allow("busy")
+	l.Allow("busy")
+	now = now.Add(time.Minute / 4)
+
+	// "idle" refilled long ago and is pruned; "busy" is still half empty.
+	now = now.Add(time.Minute)
+	l.Allow("other")
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("full bucket for idle key wasn't pruned")
+	}
+	if s := l.Allow("busy"); s.Remaining != 1 {
+		t.Errorf("busy key after pruning: %+v, want 1 remaining", s)
+	}
+}
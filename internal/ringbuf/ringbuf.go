@@ -0,0 +1,48 @@
+// Package ringbuf provides a fixed-size buffer that keeps the most recent
+// items, overwriting the oldest once full. It is safe for concurrent use.
+package ringbuf
+
+import "sync"
+
+type Buffer[T any] struct {
+	mu    sync.Mutex
+	items []T
+	next  int
+	full  bool
+}
+
+// New returns a buffer holding at most size items.
+func New[T any](size int) *Buffer[T] {
+	return &Buffer[T]{items: make([]T, size)}
+}
+
+// Add appends item, dropping the oldest item if the buffer is full.
+func (b *Buffer[T]) Add(item T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.items) == 0 {
+		return
+	}
+	b.items[b.next] = item
+	b.next = (b.next + 1) % len(b.items)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Recent returns a copy of the buffered items, newest first.
+func (b *Buffer[T]) Recent() []T {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := b.next
+	if b.full {
+		n = len(b.items)
+	}
+	out := make([]T, n)
+	for i := range out {
+		out[i] = b.items[(b.next-1-i+len(b.items))%len(b.items)]
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package ringbuf
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestRecentNewestFirst(t *testing.T) {
+	b := New[int](3)
+	if got := b.Recent(); len(got) != 0 {
+		t.Errorf("Recent() on empty buffer = %v, want []", got)
+	}
+
+	b.Add(1)
+	b.Add(2)
+	if got := b.Recent(); !slices.Equal(got, []int{2, 1}) {
+		t.Errorf("Recent() = %v, want [2 1]", got)
+	}
+}
+
+func TestOverwritesOldest(t *testing.T) {
+	b := New[int](3)
+	for i := 1; i <= 7; i++ {
+		b.Add(i)
+	}
+	if got := b.Recent(); !slices.Equal(got, []int{7, 6, 5}) {
+		t.Errorf("Recent() = %v, want [7 6 5]", got)
+	}
+}
+
+func TestZeroSize(t *testing.T) {
+	b := New[int](0)
+	b.Add(1)
+	if got := b.Recent(); len(got) != 0 {
+		t.Errorf("Recent() = %v, want []", got)
+	}
+}
+
+func TestConcurrentAdd(t *testing.T) {
+	b := New[int](10)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				b.Add(j)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := len(b.Recent()); got != 10 {
+		t.Errorf("len(Recent()) = %d, want 10", got)
+	}
+}
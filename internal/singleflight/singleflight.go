@@ -0,0 +1,63 @@
+// Package singleflight coalesces concurrent calls that share a key into one
+// execution whose result every caller receives. Nothing is kept once the
+// call returns, so it never serves a stale result. It is safe for
+// concurrent use.
+package singleflight
+
+import "sync"
+
+type call[T any] struct {
+	done chan struct{}
+	val  T
+}
+
+type Group[T any] struct {
+	mu      sync.Mutex
+	calls   map[string]*call[T]
+	pending int
+}
+
+func New[T any]() *Group[T] {
+	return &Group[T]{calls: make(map[string]*call[T])}
+}
+
+// Do runs fn unless a call for key is already in flight, in which case it
+// waits for that call and returns its result instead. shared reports
+// whether the result went to more than this caller's fn, false for the
+// caller that ran it.
+func (g *Group[T]) Do(key string, fn func() T) (val T, shared bool) {
+	g.mu.Lock()
+	g.pending++
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-c.done
+		g.finish()
+		return c.val, true
+	}
+	c := &call[T]{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+		g.finish()
+	}()
+	c.val = fn()
+	return c.val, false
+}
+
+// Pending reports how many Do calls are running or waiting on another.
+func (g *Group[T]) Pending() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.pending
+}
+
+func (g *Group[T]) finish() {
+	g.mu.Lock()
+	g.pending--
+	g.mu.Unlock()
+}
@@ -0,0 +1,95 @@
+package singleflight
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitPending polls until n calls are in flight on g.
+func waitPending[T any](t *testing.T, g *Group[T], n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for g.Pending() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Pending() = %d, want %d", g.Pending(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDoCoalescesConcurrentCalls(t *testing.T) {
+	g := New[int]()
+	release := make(chan struct{})
+	var runs atomic.Int32
+	fn := func() int {
+		runs.Add(1)
+		<-release
+		return 42
+	}
+
+	const n = 5
+	results := make([]int, n)
+	shared := make([]bool, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], shared[i] = g.Do("k", fn)
+		}()
+	}
+	waitPending(t, g, n)
+	close(release)
+	wg.Wait()
+
+	if got := runs.Load(); got != 1 {
+		t.Errorf("fn ran %d times, want 1", got)
+	}
+	sharedCount := 0
+	for i := range n {
+		if results[i] != 42 {
+			t.Errorf("caller %d got %d, want 42", i, results[i])
+		}
+		if shared[i] {
+			sharedCount++
+		}
+	}
+	if sharedCount != n-1 {
+		t.Errorf("%d callers got a shared result, want %d", sharedCount, n-1)
+	}
+	if got := g.Pending(); got != 0 {
+		t.Errorf("Pending() after all calls = %d, want 0", got)
+	}
+}
+
+func TestDoDoesNotCache(t *testing.T) {
+	g := New[int]()
+	calls := 0
+	for i := range 3 {
+		got, shared := g.Do("k", func() int { calls++; return calls })
+		if got != i+1 || shared {
+			t.Errorf("call %d = %d, %v; want %d, false", i, got, shared, i+1)
+		}
+	}
+}
+
+func TestDoSeparatesKeys(t *testing.T) {
+	g := New[string]()
+	release := make(chan struct{})
+	done := make(chan string)
+	go func() {
+		v, _ := g.Do("a", func() string { <-release; return "a" })
+		done <- v
+	}()
+	waitPending(t, g, 1)
+
+	if got, shared := g.Do("b", func() string { return "b" }); got != "b" || shared {
+		t.Errorf(`Do("b") = %q, %v while "a" is in flight; want "b", false`, got, shared)
+	}
+	close(release)
+	if got := <-done; got != "a" {
+		t.Errorf(`Do("a") = %q, want "a"`, got)
+	}
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// VacuumStats reports the database file size in bytes around a vacuum.
+type VacuumStats struct {
+	SizeBefore int64
+	SizeAfter  int64
+}
+
+// Maintainer is implemented by stores backed by a local SQLite file, where
+// VACUUM and ANALYZE can reclaim space and refresh planner statistics.
+type Maintainer interface {
+	Vacuum(ctx context.Context) (VacuumStats, error)
+}
+
+var _ Maintainer = (*SQL)(nil)
+
+// Vacuum runs VACUUM followed by ANALYZE on a dedicated connection. VACUUM
+// can't run inside a transaction and takes an exclusive lock for its
+// duration, so concurrent writers wait on SQLite's busy handling until it
+// finishes.
+func (s *SQL) Vacuum(ctx context.Context) (VacuumStats, error) {
+	if s.tx != nil {
+		return VacuumStats{}, errors.New("store: VACUUM can't run inside a transaction")
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return VacuumStats{}, err
+	}
+	defer conn.Close()
+
+	var stats VacuumStats
+	if stats.SizeBefore, err = databaseSize(ctx, conn); err != nil {
+		return VacuumStats{}, err
+	}
+	if _, err := conn.ExecContext(ctx, "VACUUM"); err != nil {
+		return VacuumStats{}, err
+	}
+	if _, err := conn.ExecContext(ctx, "ANALYZE"); err != nil {
+		return VacuumStats{}, err
+	}
+	if stats.SizeAfter, err = databaseSize(ctx, conn); err != nil {
+		return VacuumStats{}, err
+	}
+	return stats, nil
+}
+
+func databaseSize(ctx context.Context, conn *sql.Conn) (int64, error) {
+	var pageCount, pageSize int64
+	if err := conn.QueryRowContext(ctx, "PRAGMA page_count").Scan(&pageCount); err != nil {
+		return 0, err
+	}
+	if err := conn.QueryRowContext(ctx, "PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, err
+	}
+	return pageCount * pageSize, nil
+}
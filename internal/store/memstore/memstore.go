@@ -0,0 +1,788 @@
+// Package memstore is an in-memory store.Store intended for tests.
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+var ErrUniqueConstraint = errors.New("UNIQUE constraint failed")
+
+// errNameTaken names the column the way SQLite does, so that
+// store.IsUniqueViolation recognises it.
+var errNameTaken = fmt.Errorf("%w: users.name_normalized", ErrUniqueConstraint)
+
+// Store keeps rows in insertion order, which stands in for SQLite's rowid
+// wherever the SQL queries use it as a tie-breaker. Queries that return the
+// rowid itself read it from rowids, which deletions don't renumber.
+type Store struct {
+	mu        sync.Mutex
+	users     []database.User
+	notes     []database.Note
+	rowids    map[string]int64
+	lastRowid int64
+	revisions []database.NoteRevision
+	tags      []database.NoteTag
+	follows   []database.Follow
+
+	// txMu serializes InTx calls so a rollback only discards its own writes.
+	txMu sync.Mutex
+}
+
+var _ store.Store = (*Store)(nil)
+
+func New() *Store {
+	return &Store{rowids: make(map[string]int64)}
+}
+
+func (s *Store) InTx(ctx context.Context, fn func(store.Store) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.Lock()
+	users := append([]database.User(nil), s.users...)
+	notes := append([]database.Note(nil), s.notes...)
+	revisions := append([]database.NoteRevision(nil), s.revisions...)
+	tags := append([]database.NoteTag(nil), s.tags...)
+	follows := append([]database.Follow(nil), s.follows...)
+	rowids, lastRowid := maps.Clone(s.rowids), s.lastRowid
+	s.mu.Unlock()
+
+	if err := fn(s); err != nil {
+		s.mu.Lock()
+		s.users, s.notes, s.revisions, s.tags, s.follows = users, notes, revisions, tags, follows
+		// SQLite hands a rolled-back rowid out again.
+		s.rowids, s.lastRowid = rowids, lastRowid
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Ping always succeeds: there is nothing to reach.
+func (s *Store) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.ID == arg.ID || user.ApiKey == arg.ApiKey {
+			return ErrUniqueConstraint
+		}
+		if user.NameNormalized == arg.NameNormalized {
+			return errNameTaken
+		}
+	}
+	s.users = append(s.users, database.User(arg))
+	return nil
+}
+
+func (s *Store) GetUser(ctx context.Context, apiKey string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.ApiKey == apiKey {
+			return user, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+// DeleteNote leaves the note's revisions and tags in place, as SQLite does
+// without PRAGMA foreign_keys, so callers must delete them first.
+func (s *Store) DeleteNote(ctx context.Context, arg database.DeleteNoteParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.notes)
+	s.notes = slices.DeleteFunc(s.notes, func(note database.Note) bool {
+		return note.ID == arg.ID && note.UserID == arg.UserID
+	})
+	return int64(n - len(s.notes)), nil
+}
+
+func (s *Store) DeleteNotesForUser(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.notes = slices.DeleteFunc(s.notes, func(note database.Note) bool { return note.UserID == userID })
+	return nil
+}
+
+func (s *Store) DeleteNoteRevisions(ctx context.Context, noteID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revisions = slices.DeleteFunc(s.revisions, func(rev database.NoteRevision) bool { return rev.NoteID == noteID })
+	return nil
+}
+
+func (s *Store) DeleteNoteRevisionsForUser(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owned := s.noteIDsForUser(userID)
+	s.revisions = slices.DeleteFunc(s.revisions, func(rev database.NoteRevision) bool { return owned[rev.NoteID] })
+	return nil
+}
+
+func (s *Store) DeleteNoteTags(ctx context.Context, noteID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tags = slices.DeleteFunc(s.tags, func(tag database.NoteTag) bool { return tag.NoteID == noteID })
+	return nil
+}
+
+func (s *Store) DeleteNoteTagsForUser(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owned := s.noteIDsForUser(userID)
+	s.tags = slices.DeleteFunc(s.tags, func(tag database.NoteTag) bool { return owned[tag.NoteID] })
+	return nil
+}
+
+// noteIDsForUser must be called with s.mu held.
+func (s *Store) noteIDsForUser(userID string) map[string]bool {
+	owned := make(map[string]bool)
+	for _, note := range s.notes {
+		if note.UserID == userID {
+			owned[note.ID] = true
+		}
+	}
+	return owned
+}
+
+// DeleteUser removes only the user row, as SQLite does without PRAGMA
+// foreign_keys: notes, revisions, tags and follows are the caller's to
+// delete first.
+func (s *Store) DeleteUser(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users = slices.DeleteFunc(s.users, func(user database.User) bool { return user.ID == id })
+	return nil
+}
+
+// DeleteUserRenames does nothing: renames are only recorded by migrations,
+// which never run against the in-memory store.
+func (s *Store) DeleteUserRenames(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (s *Store) UpdateUserName(ctx context.Context, arg database.UpdateUserNameParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.NameNormalized == arg.NameNormalized && user.ID != arg.ID {
+			return errNameTaken
+		}
+	}
+	for i := range s.users {
+		if s.users[i].ID == arg.ID {
+			s.users[i].Name = arg.Name
+			s.users[i].NameNormalized = arg.NameNormalized
+			s.users[i].UpdatedAt = arg.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (s *Store) UpdateUserAPIKey(ctx context.Context, arg database.UpdateUserAPIKeyParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.ApiKey == arg.ApiKey && user.ID != arg.ID {
+			return ErrUniqueConstraint
+		}
+	}
+	for i := range s.users {
+		if s.users[i].ID == arg.ID {
+			s.users[i].ApiKey = arg.ApiKey
+			s.users[i].UpdatedAt = arg.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (s *Store) CreateNote(ctx context.Context, arg database.CreateNoteParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, note := range s.notes {
+		if note.ID == arg.ID {
+			return ErrUniqueConstraint
+		}
+	}
+	s.notes = append(s.notes, database.Note{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		Note:      arg.Note,
+		UserID:    arg.UserID,
+		Public:    arg.Public,
+		Metadata:  arg.Metadata,
+	})
+	s.lastRowid++
+	s.rowids[arg.ID] = s.lastRowid
+	return nil
+}
+
+func (s *Store) GetNote(ctx context.Context, id string) (database.Note, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, note := range s.notes {
+		if note.ID == id {
+			return note, nil
+		}
+	}
+	return database.Note{}, sql.ErrNoRows
+}
+
+func (s *Store) GetNotesForUser(ctx context.Context, arg database.GetNotesForUserParams) ([]database.Note, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.Note
+	for i := len(s.notes) - 1; i >= 0; i-- {
+		note := s.notes[i]
+		if s.listed(note, arg.UserID, arg.CreatedAfter, arg.CreatedBefore, arg.HasTags) {
+			items = append(items, note)
+		}
+	}
+	sortNewestFirst(items)
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].IsPinned && !items[j].IsPinned
+	})
+	return paginate(items, arg.Limit, arg.Offset), nil
+}
+
+func (s *Store) GetRecentNotesForUser(ctx context.Context, arg database.GetRecentNotesForUserParams) ([]database.Note, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.Note
+	for i := len(s.notes) - 1; i >= 0; i-- {
+		if note := s.notes[i]; note.UserID == arg.UserID {
+			items = append(items, note)
+		}
+	}
+	sortNewestFirst(items)
+	return paginate(items, arg.Limit, 0), nil
+}
+
+func (s *Store) CountNotesForUserFiltered(ctx context.Context, arg database.CountNotesForUserFilteredParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, note := range s.notes {
+		if s.listed(note, arg.UserID, arg.CreatedAfter, arg.CreatedBefore, arg.HasTags) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// listed applies the filters shared by GetNotesForUser and
+// CountNotesForUserFiltered. It must be called with s.mu held.
+func (s *Store) listed(note database.Note, userID, after, before string, hasTags sql.NullBool) bool {
+	if note.UserID != userID || note.CreatedAt < after || note.CreatedAt > before {
+		return false
+	}
+	return !hasTags.Valid || s.hasTags(note.ID) == hasTags.Bool
+}
+
+func (s *Store) SearchNotesForUser(ctx context.Context, arg database.SearchNotesForUserParams) ([]database.Note, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.Note
+	for i := len(s.notes) - 1; i >= 0; i-- {
+		note := s.notes[i]
+		if note.UserID == arg.UserID && like(arg.Pattern, note.Note) {
+			items = append(items, note)
+		}
+	}
+	sortNewestFirst(items)
+	return paginate(items, arg.Limit, arg.Offset), nil
+}
+
+func (s *Store) SearchUsersByNamePrefix(ctx context.Context, arg database.SearchUsersByNamePrefixParams) ([]database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.User
+	for _, user := range s.users {
+		if like(arg.Pattern, user.Name) {
+			items = append(items, user)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Name != items[j].Name {
+			return items[i].Name < items[j].Name
+		}
+		return items[i].ID < items[j].ID
+	})
+	return paginate(items, arg.Limit, arg.Offset), nil
+}
+
+func (s *Store) SearchNotesForUserAfter(ctx context.Context, arg database.SearchNotesForUserAfterParams) ([]database.SearchNotesForUserAfterRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.SearchNotesForUserAfterRow
+	for i := len(s.notes) - 1; i >= 0; i-- {
+		note := s.notes[i]
+		rowid := s.rowids[note.ID]
+		if note.UserID != arg.UserID || !like(arg.Pattern, note.Note) {
+			continue
+		}
+		if note.CreatedAt > arg.AfterCreatedAt || (note.CreatedAt == arg.AfterCreatedAt && rowid >= arg.AfterRowid) {
+			continue
+		}
+		items = append(items, database.SearchNotesForUserAfterRow{
+			Rowid:     rowid,
+			ID:        note.ID,
+			CreatedAt: note.CreatedAt,
+			UpdatedAt: note.UpdatedAt,
+			Note:      note.Note,
+			UserID:    note.UserID,
+			Public:    note.Public,
+			Metadata:  note.Metadata,
+			IsPinned:  note.IsPinned,
+		})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].CreatedAt > items[j].CreatedAt
+	})
+	return paginate(items, arg.Limit, 0), nil
+}
+
+func (s *Store) CountNotesMatchingForUser(ctx context.Context, arg database.CountNotesMatchingForUserParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, note := range s.notes {
+		if note.UserID == arg.UserID && like(arg.Pattern, note.Note) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *Store) UpdateNote(ctx context.Context, arg database.UpdateNoteParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.notes {
+		if s.notes[i].ID == arg.ID {
+			s.notes[i].Note = arg.Note
+			s.notes[i].UpdatedAt = arg.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (s *Store) SetNoteMetadata(ctx context.Context, arg database.SetNoteMetadataParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.notes {
+		if s.notes[i].ID == arg.ID {
+			s.notes[i].Metadata = arg.Metadata
+			s.notes[i].UpdatedAt = arg.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (s *Store) SetNotePinned(ctx context.Context, arg database.SetNotePinnedParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.notes {
+		if s.notes[i].ID == arg.ID {
+			s.notes[i].IsPinned = arg.IsPinned
+			s.notes[i].UpdatedAt = arg.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (s *Store) SetNotePublic(ctx context.Context, arg database.SetNotePublicParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.notes {
+		if s.notes[i].ID == arg.ID {
+			s.notes[i].Public = arg.Public
+			s.notes[i].UpdatedAt = arg.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (s *Store) CountNotesForUser(ctx context.Context, userID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, note := range s.notes {
+		if note.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *Store) GetLastNoteUpdateForUser(ctx context.Context, userID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var last string
+	for _, note := range s.notes {
+		if note.UserID == userID {
+			last = max(last, note.UpdatedAt)
+		}
+	}
+	return last, nil
+}
+
+func (s *Store) CountNotesForUserSince(ctx context.Context, arg database.CountNotesForUserSinceParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, note := range s.notes {
+		if note.UserID == arg.UserID && note.CreatedAt >= arg.CreatedAt {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *Store) CreateNoteRevision(ctx context.Context, arg database.CreateNoteRevisionParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revisions = append(s.revisions, database.NoteRevision(arg))
+	return nil
+}
+
+func (s *Store) GetNoteRevisions(ctx context.Context, noteID string) ([]database.NoteRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.NoteRevision
+	for i := len(s.revisions) - 1; i >= 0; i-- {
+		if s.revisions[i].NoteID == noteID {
+			items = append(items, s.revisions[i])
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].CreatedAt > items[j].CreatedAt
+	})
+	return items, nil
+}
+
+func (s *Store) GetNoteIDsForUser(ctx context.Context, arg database.GetNoteIDsForUserParams) ([]database.GetNoteIDsForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.GetNoteIDsForUserRow
+	for _, note := range s.notes {
+		if note.UserID == arg.UserID {
+			items = append(items, database.GetNoteIDsForUserRow{ID: note.ID, UpdatedAt: note.UpdatedAt})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+	return paginate(items, arg.Limit, arg.Offset), nil
+}
+
+func (s *Store) GetNoteVersionsForUser(ctx context.Context, userID string) ([]database.GetNoteVersionsForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.GetNoteVersionsForUserRow
+	for _, note := range s.notes {
+		if note.UserID == userID {
+			items = append(items, database.GetNoteVersionsForUserRow{ID: note.ID, UpdatedAt: note.UpdatedAt})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+	return items, nil
+}
+
+func (s *Store) AddNoteTag(ctx context.Context, arg database.AddNoteTagParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tag := range s.tags {
+		if tag == database.NoteTag(arg) {
+			return 0, nil
+		}
+	}
+	s.tags = append(s.tags, database.NoteTag(arg))
+	return 1, nil
+}
+
+func (s *Store) GetTagCountsForUser(ctx context.Context, userID string) ([]database.GetTagCountsForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]int64)
+	for _, tag := range s.tags {
+		if note, ok := s.noteByID(tag.NoteID); ok && note.UserID == userID {
+			counts[tag.Tag]++
+		}
+	}
+
+	var items []database.GetTagCountsForUserRow
+	for tag, count := range counts {
+		items = append(items, database.GetTagCountsForUserRow{Tag: tag, NoteCount: count})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].NoteCount != items[j].NoteCount {
+			return items[i].NoteCount > items[j].NoteCount
+		}
+		return items[i].Tag < items[j].Tag
+	})
+	return items, nil
+}
+
+func (s *Store) GetTagsForNote(ctx context.Context, noteID string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []string
+	for _, tag := range s.tags {
+		if tag.NoteID == noteID {
+			items = append(items, tag.Tag)
+		}
+	}
+	sort.Strings(items)
+	return items, nil
+}
+
+func (s *Store) GetTopTagsForUser(ctx context.Context, arg database.GetTopTagsForUserParams) ([]database.GetTopTagsForUserRow, error) {
+	counts, err := s.GetTagCountsForUser(ctx, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]database.GetTopTagsForUserRow, len(counts))
+	for i, count := range counts {
+		items[i] = database.GetTopTagsForUserRow(count)
+	}
+	return paginate(items, arg.Limit, 0), nil
+}
+
+func (s *Store) CreateFollow(ctx context.Context, arg database.CreateFollowParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isFollowing(arg.FollowerID, arg.FolloweeID) {
+		return 0, nil
+	}
+	s.follows = append(s.follows, database.Follow(arg))
+	return 1, nil
+}
+
+func (s *Store) DeleteFollow(ctx context.Context, arg database.DeleteFollowParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, follow := range s.follows {
+		if follow.FollowerID == arg.FollowerID && follow.FolloweeID == arg.FolloweeID {
+			s.follows = append(s.follows[:i:i], s.follows[i+1:]...)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *Store) DeleteFollowsForUser(ctx context.Context, arg database.DeleteFollowsForUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.follows = slices.DeleteFunc(s.follows, func(f database.Follow) bool {
+		return f.FollowerID == arg.FollowerID || f.FolloweeID == arg.FolloweeID
+	})
+	return nil
+}
+
+func (s *Store) GetFeedForUser(ctx context.Context, arg database.GetFeedForUserParams) ([]database.Note, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.Note
+	for i := len(s.notes) - 1; i >= 0; i-- {
+		note := s.notes[i]
+		if note.Public && s.isFollowing(arg.FollowerID, note.UserID) {
+			items = append(items, note)
+		}
+	}
+	sortNewestFirst(items)
+	return paginate(items, arg.Limit, arg.Offset), nil
+}
+
+// GetActivityForUser yields a created event per note and an updated one for
+// notes whose updated_at moved on, newest first as the query orders them.
+func (s *Store) GetActivityForUser(ctx context.Context, arg database.GetActivityForUserParams) ([]database.GetActivityForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.GetActivityForUserRow
+	for _, note := range s.notes {
+		if note.UserID != arg.UserID {
+			continue
+		}
+		items = append(items, database.GetActivityForUserRow{Type: "created", NoteID: note.ID, OccurredAt: note.CreatedAt})
+		if note.UpdatedAt != note.CreatedAt {
+			items = append(items, database.GetActivityForUserRow{Type: "updated", NoteID: note.ID, OccurredAt: note.UpdatedAt})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.OccurredAt != b.OccurredAt {
+			return a.OccurredAt > b.OccurredAt
+		}
+		if a.Type != b.Type {
+			return a.Type > b.Type
+		}
+		return a.NoteID > b.NoteID
+	})
+	return paginate(items, arg.Limit, arg.Offset), nil
+}
+
+// isFollowing must be called with s.mu held.
+func (s *Store) isFollowing(followerID, followeeID string) bool {
+	for _, follow := range s.follows {
+		if follow.FollowerID == followerID && follow.FolloweeID == followeeID {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTags must be called with s.mu held.
+func (s *Store) hasTags(noteID string) bool {
+	for _, tag := range s.tags {
+		if tag.NoteID == noteID {
+			return true
+		}
+	}
+	return false
+}
+
+// noteByID must be called with s.mu held.
+func (s *Store) noteByID(id string) (database.Note, bool) {
+	for _, note := range s.notes {
+		if note.ID == id {
+			return note, true
+		}
+	}
+	return database.Note{}, false
+}
+
+// like reports whether s matches a SQLite LIKE pattern with ESCAPE '\'. As in
+// SQLite, matching is case-insensitive for ASCII letters only.
+func like(pattern, s string) bool {
+	p, str := []rune(pattern), []rune(s)
+	var match func(pi, si int) bool
+	match = func(pi, si int) bool {
+		for pi < len(p) {
+			switch c := p[pi]; {
+			case c == '%':
+				for pi < len(p) && p[pi] == '%' {
+					pi++
+				}
+				if pi == len(p) {
+					return true
+				}
+				for ; si <= len(str); si++ {
+					if match(pi, si) {
+						return true
+					}
+				}
+				return false
+			case c == '_':
+				if si == len(str) {
+					return false
+				}
+			default:
+				if c == '\\' && pi+1 < len(p) {
+					pi++
+					c = p[pi]
+				}
+				if si == len(str) || asciiLower(c) != asciiLower(str[si]) {
+					return false
+				}
+			}
+			pi++
+			si++
+		}
+		return si == len(str)
+	}
+	return match(0, 0)
+}
+
+func asciiLower(r rune) rune {
+	if 'A' <= r && r <= 'Z' {
+		return r + 'a' - 'A'
+	}
+	return r
+}
+
+// sortNewestFirst orders notes by created_at descending. Callers pass notes
+// in reverse insertion order so that ties keep the rowid DESC tie-breaker.
+func sortNewestFirst(notes []database.Note) {
+	sort.SliceStable(notes, func(i, j int) bool {
+		return notes[i].CreatedAt > notes[j].CreatedAt
+	})
+}
+
+func paginate[T any](items []T, limit, offset int64) []T {
+	if offset >= int64(len(items)) {
+		return nil
+	}
+	items = items[offset:]
+	if limit >= 0 && limit < int64(len(items)) {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,51 @@
+package memstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+func TestInTxRollbackRestoresRowids(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	createNote := func(db store.Store, id string) error {
+		return db.CreateNote(ctx, database.CreateNoteParams{
+			ID:        id,
+			CreatedAt: "2024-01-01T00:00:00Z",
+			UpdatedAt: "2024-01-01T00:00:00Z",
+			Note:      "note " + id,
+			UserID:    "alice",
+		})
+	}
+
+	errRollback := errors.New("roll back")
+	err := s.InTx(ctx, func(tx store.Store) error {
+		if err := createNote(tx, "discarded"); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("InTx = %v, want %v", err, errRollback)
+	}
+	if err := createNote(s, "kept"); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := s.SearchNotesForUserAfter(ctx, database.SearchNotesForUserAfterParams{
+		UserID:         "alice",
+		Pattern:        "%",
+		AfterCreatedAt: "9999-12-31T23:59:59Z",
+		Limit:          10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].ID != "kept" || rows[0].Rowid != 1 {
+		t.Errorf("notes after rollback = %+v, want only kept, with rowid 1", rows)
+	}
+}
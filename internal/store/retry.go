@@ -0,0 +1,173 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// ErrBusy reports that a write kept failing with SQLite's "database is
+// locked" error after all retries.
+var ErrBusy = errors.New("database busy")
+
+// isLocked reports whether err is SQLite's SQLITE_BUSY. The libSQL client
+// only surfaces it as text.
+func isLocked(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "SQLITE_BUSY")
+}
+
+// WithRetry retries writes on s that fail because the database is locked, up
+// to attempts times in total, doubling the wait from backoff between tries.
+// Transactions are retried as a whole. Reads are passed through untouched.
+func WithRetry(s Store, attempts int, backoff time.Duration) Store {
+	return &retryStore{Store: s, attempts: attempts, backoff: backoff}
+}
+
+type retryStore struct {
+	Store
+	attempts int
+	backoff  time.Duration
+}
+
+func (s *retryStore) retry(ctx context.Context, op func() error) error {
+	wait := s.backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = op()
+		if !isLocked(err) {
+			return err
+		}
+		if attempt >= s.attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+	return fmt.Errorf("%w after %d attempts: %w", ErrBusy, s.attempts, err)
+}
+
+func (s *retryStore) AddNoteTag(ctx context.Context, arg database.AddNoteTagParams) (int64, error) {
+	var n int64
+	err := s.retry(ctx, func() error {
+		var err error
+		n, err = s.Store.AddNoteTag(ctx, arg)
+		return err
+	})
+	return n, err
+}
+
+func (s *retryStore) CreateFollow(ctx context.Context, arg database.CreateFollowParams) (int64, error) {
+	var n int64
+	err := s.retry(ctx, func() error {
+		var err error
+		n, err = s.Store.CreateFollow(ctx, arg)
+		return err
+	})
+	return n, err
+}
+
+func (s *retryStore) CreateNote(ctx context.Context, arg database.CreateNoteParams) error {
+	return s.retry(ctx, func() error { return s.Store.CreateNote(ctx, arg) })
+}
+
+func (s *retryStore) CreateNoteRevision(ctx context.Context, arg database.CreateNoteRevisionParams) error {
+	return s.retry(ctx, func() error { return s.Store.CreateNoteRevision(ctx, arg) })
+}
+
+func (s *retryStore) CreateUser(ctx context.Context, arg database.CreateUserParams) error {
+	return s.retry(ctx, func() error { return s.Store.CreateUser(ctx, arg) })
+}
+
+func (s *retryStore) DeleteFollow(ctx context.Context, arg database.DeleteFollowParams) (int64, error) {
+	var n int64
+	err := s.retry(ctx, func() error {
+		var err error
+		n, err = s.Store.DeleteFollow(ctx, arg)
+		return err
+	})
+	return n, err
+}
+
+func (s *retryStore) DeleteFollowsForUser(ctx context.Context, arg database.DeleteFollowsForUserParams) error {
+	return s.retry(ctx, func() error { return s.Store.DeleteFollowsForUser(ctx, arg) })
+}
+
+func (s *retryStore) DeleteNote(ctx context.Context, arg database.DeleteNoteParams) (int64, error) {
+	var n int64
+	err := s.retry(ctx, func() error {
+		var err error
+		n, err = s.Store.DeleteNote(ctx, arg)
+		return err
+	})
+	return n, err
+}
+
+func (s *retryStore) DeleteNoteRevisions(ctx context.Context, noteID string) error {
+	return s.retry(ctx, func() error { return s.Store.DeleteNoteRevisions(ctx, noteID) })
+}
+
+func (s *retryStore) DeleteNoteRevisionsForUser(ctx context.Context, userID string) error {
+	return s.retry(ctx, func() error { return s.Store.DeleteNoteRevisionsForUser(ctx, userID) })
+}
+
+func (s *retryStore) DeleteNoteTags(ctx context.Context, noteID string) error {
+	return s.retry(ctx, func() error { return s.Store.DeleteNoteTags(ctx, noteID) })
+}
+
+func (s *retryStore) DeleteNoteTagsForUser(ctx context.Context, userID string) error {
+	return s.retry(ctx, func() error { return s.Store.DeleteNoteTagsForUser(ctx, userID) })
+}
+
+func (s *retryStore) DeleteNotesForUser(ctx context.Context, userID string) error {
+	return s.retry(ctx, func() error { return s.Store.DeleteNotesForUser(ctx, userID) })
+}
+
+func (s *retryStore) DeleteUser(ctx context.Context, id string) error {
+	return s.retry(ctx, func() error { return s.Store.DeleteUser(ctx, id) })
+}
+
+func (s *retryStore) DeleteUserRenames(ctx context.Context, userID string) error {
+	return s.retry(ctx, func() error { return s.Store.DeleteUserRenames(ctx, userID) })
+}
+
+func (s *retryStore) SetNoteMetadata(ctx context.Context, arg database.SetNoteMetadataParams) error {
+	return s.retry(ctx, func() error { return s.Store.SetNoteMetadata(ctx, arg) })
+}
+
+func (s *retryStore) SetNotePinned(ctx context.Context, arg database.SetNotePinnedParams) error {
+	return s.retry(ctx, func() error { return s.Store.SetNotePinned(ctx, arg) })
+}
+
+func (s *retryStore) SetNotePublic(ctx context.Context, arg database.SetNotePublicParams) error {
+	return s.retry(ctx, func() error { return s.Store.SetNotePublic(ctx, arg) })
+}
+
+func (s *retryStore) UpdateNote(ctx context.Context, arg database.UpdateNoteParams) error {
+	return s.retry(ctx, func() error { return s.Store.UpdateNote(ctx, arg) })
+}
+
+func (s *retryStore) UpdateUserAPIKey(ctx context.Context, arg database.UpdateUserAPIKeyParams) error {
+	return s.retry(ctx, func() error { return s.Store.UpdateUserAPIKey(ctx, arg) })
+}
+
+func (s *retryStore) UpdateUserName(ctx context.Context, arg database.UpdateUserNameParams) error {
+	return s.retry(ctx, func() error { return s.Store.UpdateUserName(ctx, arg) })
+}
+
+// InTx hands fn the underlying transaction store: a locked statement inside
+// a transaction fails the whole attempt, which is then retried from BEGIN.
+func (s *retryStore) InTx(ctx context.Context, fn func(Store) error) error {
+	return s.retry(ctx, func() error { return s.Store.InTx(ctx, fn) })
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+var errLocked = errors.New("SQLite error: database is locked")
+
+// lockedStore fails the first failures CreateNote calls with errLocked.
+type lockedStore struct {
+	Store
+	failures int
+	calls    int
+}
+
+func (s *lockedStore) CreateNote(ctx context.Context, arg database.CreateNoteParams) error {
+	s.calls++
+	if s.calls <= s.failures {
+		return errLocked
+	}
+	return nil
+}
+
+func (s *lockedStore) InTx(ctx context.Context, fn func(Store) error) error {
+	return fn(s)
+}
+
+func TestWithRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		wantCalls int
+		wantBusy  bool
+	}{
+		{name: "succeeds first time", failures: 0, wantCalls: 1},
+		{name: "succeeds after retries", failures: 2, wantCalls: 3},
+		{name: "gives up", failures: 5, wantCalls: 3, wantBusy: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &lockedStore{failures: tt.failures}
+			s := WithRetry(inner, 3, time.Millisecond)
+
+			err := s.CreateNote(context.Background(), database.CreateNoteParams{})
+			if inner.calls != tt.wantCalls {
+				t.Errorf("CreateNote calls = %d, want %d", inner.calls, tt.wantCalls)
+			}
+			if got := errors.Is(err, ErrBusy); got != tt.wantBusy {
+				t.Errorf("CreateNote error = %v, want ErrBusy: %v", err, tt.wantBusy)
+			}
+		})
+	}
+}
+
+func TestWithRetryTransaction(t *testing.T) {
+	inner := &lockedStore{failures: 1}
+	s := WithRetry(inner, 3, time.Millisecond)
+
+	runs := 0
+	err := s.InTx(context.Background(), func(tx Store) error {
+		runs++
+		return tx.CreateNote(context.Background(), database.CreateNoteParams{})
+	})
+	if err != nil {
+		t.Fatalf("InTx error = %v, want nil", err)
+	}
+	if runs != 2 || inner.calls != 2 {
+		t.Errorf("transaction ran %d times with %d CreateNote calls, want 2 and 2", runs, inner.calls)
+	}
+}
+
+func TestWithRetryOtherErrors(t *testing.T) {
+	inner := &lockedStore{}
+	s := WithRetry(inner, 3, time.Millisecond)
+
+	want := errors.New("constraint failed")
+	err := s.InTx(context.Background(), func(Store) error { return want })
+	if !errors.Is(err, want) || errors.Is(err, ErrBusy) {
+		t.Errorf("InTx error = %v, want %v unchanged", err, want)
+	}
+}
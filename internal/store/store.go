@@ -0,0 +1,115 @@
+// Package store defines the persistence operations the HTTP handlers depend
+// on, so that handlers can run against either SQLite or an in-memory backend.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+type Store interface {
+	AddNoteTag(ctx context.Context, arg database.AddNoteTagParams) (int64, error)
+	CountNotesForUser(ctx context.Context, userID string) (int64, error)
+	CountNotesForUserFiltered(ctx context.Context, arg database.CountNotesForUserFilteredParams) (int64, error)
+	CountNotesForUserSince(ctx context.Context, arg database.CountNotesForUserSinceParams) (int64, error)
+	CountNotesMatchingForUser(ctx context.Context, arg database.CountNotesMatchingForUserParams) (int64, error)
+	CreateFollow(ctx context.Context, arg database.CreateFollowParams) (int64, error)
+	CreateNote(ctx context.Context, arg database.CreateNoteParams) error
+	CreateNoteRevision(ctx context.Context, arg database.CreateNoteRevisionParams) error
+	CreateUser(ctx context.Context, arg database.CreateUserParams) error
+	DeleteFollow(ctx context.Context, arg database.DeleteFollowParams) (int64, error)
+	DeleteFollowsForUser(ctx context.Context, arg database.DeleteFollowsForUserParams) error
+	DeleteNote(ctx context.Context, arg database.DeleteNoteParams) (int64, error)
+	DeleteNoteRevisions(ctx context.Context, noteID string) error
+	DeleteNoteRevisionsForUser(ctx context.Context, userID string) error
+	DeleteNoteTags(ctx context.Context, noteID string) error
+	DeleteNoteTagsForUser(ctx context.Context, userID string) error
+	DeleteNotesForUser(ctx context.Context, userID string) error
+	DeleteUser(ctx context.Context, id string) error
+	DeleteUserRenames(ctx context.Context, userID string) error
+	GetActivityForUser(ctx context.Context, arg database.GetActivityForUserParams) ([]database.GetActivityForUserRow, error)
+	GetFeedForUser(ctx context.Context, arg database.GetFeedForUserParams) ([]database.Note, error)
+	GetLastNoteUpdateForUser(ctx context.Context, userID string) (string, error)
+	GetNote(ctx context.Context, id string) (database.Note, error)
+	GetNoteIDsForUser(ctx context.Context, arg database.GetNoteIDsForUserParams) ([]database.GetNoteIDsForUserRow, error)
+	GetNoteRevisions(ctx context.Context, noteID string) ([]database.NoteRevision, error)
+	GetNoteVersionsForUser(ctx context.Context, userID string) ([]database.GetNoteVersionsForUserRow, error)
+	GetNotesForUser(ctx context.Context, arg database.GetNotesForUserParams) ([]database.Note, error)
+	GetRecentNotesForUser(ctx context.Context, arg database.GetRecentNotesForUserParams) ([]database.Note, error)
+	GetTagCountsForUser(ctx context.Context, userID string) ([]database.GetTagCountsForUserRow, error)
+	GetTagsForNote(ctx context.Context, noteID string) ([]string, error)
+	GetTopTagsForUser(ctx context.Context, arg database.GetTopTagsForUserParams) ([]database.GetTopTagsForUserRow, error)
+	GetUser(ctx context.Context, apiKey string) (database.User, error)
+	GetUserByID(ctx context.Context, id string) (database.User, error)
+	SearchNotesForUser(ctx context.Context, arg database.SearchNotesForUserParams) ([]database.Note, error)
+	SearchNotesForUserAfter(ctx context.Context, arg database.SearchNotesForUserAfterParams) ([]database.SearchNotesForUserAfterRow, error)
+	SearchUsersByNamePrefix(ctx context.Context, arg database.SearchUsersByNamePrefixParams) ([]database.User, error)
+	SetNoteMetadata(ctx context.Context, arg database.SetNoteMetadataParams) error
+	SetNotePinned(ctx context.Context, arg database.SetNotePinnedParams) error
+	SetNotePublic(ctx context.Context, arg database.SetNotePublicParams) error
+	UpdateNote(ctx context.Context, arg database.UpdateNoteParams) error
+	UpdateUserAPIKey(ctx context.Context, arg database.UpdateUserAPIKeyParams) error
+	UpdateUserName(ctx context.Context, arg database.UpdateUserNameParams) error
+
+	// InTx runs fn with a Store whose operations share a single transaction.
+	// The transaction is committed if fn returns nil and rolled back otherwise.
+	InTx(ctx context.Context, fn func(Store) error) error
+	// Ping checks that the database is reachable.
+	Ping(ctx context.Context) error
+}
+
+// SQL is the default Store, backed by the sqlc generated queries.
+type SQL struct {
+	*database.Queries
+	db *sql.DB
+	tx *sql.Tx
+}
+
+var _ Store = (*SQL)(nil)
+
+func NewSQL(db *sql.DB) *SQL {
+	return &SQL{
+		Queries: database.New(db),
+		db:      db,
+	}
+}
+
+func (s *SQL) InTx(ctx context.Context, fn func(Store) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(&SQL{
+		Queries: s.Queries.WithTx(tx),
+		db:      s.db,
+		tx:      tx,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQL) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// IsUniqueViolation reports whether err is SQLite's UNIQUE constraint failure
+// on column, written as "table.column". Like SQLITE_BUSY, the libSQL client
+// only surfaces it as text.
+func IsUniqueViolation(err error, column string) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "UNIQUE constraint failed") && strings.Contains(msg, column)
+}
@@ -0,0 +1,245 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// ErrTimeout reports that a single store operation ran past the per-query
+// timeout given to WithTimeout, as opposed to the caller's own deadline.
+var ErrTimeout = errors.New("database timeout")
+
+// WithTimeout bounds every operation on s by d. Deadline errors caused by that
+// bound are wrapped in ErrTimeout; errors from a caller context that expired
+// first are returned unchanged. A zero d returns s as is.
+func WithTimeout(s Store, d time.Duration) Store {
+	if d <= 0 {
+		return s
+	}
+	return &timeoutStore{inner: s, timeout: d}
+}
+
+type timeoutStore struct {
+	inner   Store
+	timeout time.Duration
+}
+
+var _ Store = (*timeoutStore)(nil)
+
+// run calls op with a context bounded by the store's timeout.
+func run[T any](s *timeoutStore, ctx context.Context, op func(context.Context) (T, error)) (T, error) {
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	res, err := op(opCtx)
+	if err != nil && errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		err = fmt.Errorf("%w after %s: %w", ErrTimeout, s.timeout, err)
+	}
+	return res, err
+}
+
+func runExec(s *timeoutStore, ctx context.Context, op func(context.Context) error) error {
+	_, err := run(s, ctx, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, op(ctx)
+	})
+	return err
+}
+
+func (s *timeoutStore) AddNoteTag(ctx context.Context, arg database.AddNoteTagParams) (int64, error) {
+	return run(s, ctx, func(ctx context.Context) (int64, error) { return s.inner.AddNoteTag(ctx, arg) })
+}
+
+func (s *timeoutStore) CountNotesForUser(ctx context.Context, userID string) (int64, error) {
+	return run(s, ctx, func(ctx context.Context) (int64, error) { return s.inner.CountNotesForUser(ctx, userID) })
+}
+
+func (s *timeoutStore) CountNotesForUserFiltered(ctx context.Context, arg database.CountNotesForUserFilteredParams) (int64, error) {
+	return run(s, ctx, func(ctx context.Context) (int64, error) { return s.inner.CountNotesForUserFiltered(ctx, arg) })
+}
+
+func (s *timeoutStore) CountNotesForUserSince(ctx context.Context, arg database.CountNotesForUserSinceParams) (int64, error) {
+	return run(s, ctx, func(ctx context.Context) (int64, error) { return s.inner.CountNotesForUserSince(ctx, arg) })
+}
+
+func (s *timeoutStore) CountNotesMatchingForUser(ctx context.Context, arg database.CountNotesMatchingForUserParams) (int64, error) {
+	return run(s, ctx, func(ctx context.Context) (int64, error) { return s.inner.CountNotesMatchingForUser(ctx, arg) })
+}
+
+func (s *timeoutStore) CreateFollow(ctx context.Context, arg database.CreateFollowParams) (int64, error) {
+	return run(s, ctx, func(ctx context.Context) (int64, error) { return s.inner.CreateFollow(ctx, arg) })
+}
+
+func (s *timeoutStore) CreateNote(ctx context.Context, arg database.CreateNoteParams) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.CreateNote(ctx, arg) })
+}
+
+func (s *timeoutStore) CreateNoteRevision(ctx context.Context, arg database.CreateNoteRevisionParams) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.CreateNoteRevision(ctx, arg) })
+}
+
+func (s *timeoutStore) CreateUser(ctx context.Context, arg database.CreateUserParams) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.CreateUser(ctx, arg) })
+}
+
+func (s *timeoutStore) DeleteFollow(ctx context.Context, arg database.DeleteFollowParams) (int64, error) {
+	return run(s, ctx, func(ctx context.Context) (int64, error) { return s.inner.DeleteFollow(ctx, arg) })
+}
+
+func (s *timeoutStore) DeleteFollowsForUser(ctx context.Context, arg database.DeleteFollowsForUserParams) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.DeleteFollowsForUser(ctx, arg) })
+}
+
+func (s *timeoutStore) DeleteNote(ctx context.Context, arg database.DeleteNoteParams) (int64, error) {
+	return run(s, ctx, func(ctx context.Context) (int64, error) { return s.inner.DeleteNote(ctx, arg) })
+}
+
+func (s *timeoutStore) DeleteNoteRevisions(ctx context.Context, noteID string) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.DeleteNoteRevisions(ctx, noteID) })
+}
+
+func (s *timeoutStore) DeleteNoteRevisionsForUser(ctx context.Context, userID string) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.DeleteNoteRevisionsForUser(ctx, userID) })
+}
+
+func (s *timeoutStore) DeleteNoteTags(ctx context.Context, noteID string) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.DeleteNoteTags(ctx, noteID) })
+}
+
+func (s *timeoutStore) DeleteNoteTagsForUser(ctx context.Context, userID string) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.DeleteNoteTagsForUser(ctx, userID) })
+}
+
+func (s *timeoutStore) DeleteNotesForUser(ctx context.Context, userID string) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.DeleteNotesForUser(ctx, userID) })
+}
+
+func (s *timeoutStore) DeleteUser(ctx context.Context, id string) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.DeleteUser(ctx, id) })
+}
+
+func (s *timeoutStore) DeleteUserRenames(ctx context.Context, userID string) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.DeleteUserRenames(ctx, userID) })
+}
+
+func (s *timeoutStore) GetActivityForUser(ctx context.Context, arg database.GetActivityForUserParams) ([]database.GetActivityForUserRow, error) {
+	return run(s, ctx, func(ctx context.Context) ([]database.GetActivityForUserRow, error) {
+		return s.inner.GetActivityForUser(ctx, arg)
+	})
+}
+
+func (s *timeoutStore) GetFeedForUser(ctx context.Context, arg database.GetFeedForUserParams) ([]database.Note, error) {
+	return run(s, ctx, func(ctx context.Context) ([]database.Note, error) { return s.inner.GetFeedForUser(ctx, arg) })
+}
+
+func (s *timeoutStore) GetLastNoteUpdateForUser(ctx context.Context, userID string) (string, error) {
+	return run(s, ctx, func(ctx context.Context) (string, error) { return s.inner.GetLastNoteUpdateForUser(ctx, userID) })
+}
+
+func (s *timeoutStore) GetNote(ctx context.Context, id string) (database.Note, error) {
+	return run(s, ctx, func(ctx context.Context) (database.Note, error) { return s.inner.GetNote(ctx, id) })
+}
+
+func (s *timeoutStore) GetNoteIDsForUser(ctx context.Context, arg database.GetNoteIDsForUserParams) ([]database.GetNoteIDsForUserRow, error) {
+	return run(s, ctx, func(ctx context.Context) ([]database.GetNoteIDsForUserRow, error) {
+		return s.inner.GetNoteIDsForUser(ctx, arg)
+	})
+}
+
+func (s *timeoutStore) GetNoteRevisions(ctx context.Context, noteID string) ([]database.NoteRevision, error) {
+	return run(s, ctx, func(ctx context.Context) ([]database.NoteRevision, error) {
+		return s.inner.GetNoteRevisions(ctx, noteID)
+	})
+}
+
+func (s *timeoutStore) GetNoteVersionsForUser(ctx context.Context, userID string) ([]database.GetNoteVersionsForUserRow, error) {
+	return run(s, ctx, func(ctx context.Context) ([]database.GetNoteVersionsForUserRow, error) {
+		return s.inner.GetNoteVersionsForUser(ctx, userID)
+	})
+}
+
+func (s *timeoutStore) GetNotesForUser(ctx context.Context, arg database.GetNotesForUserParams) ([]database.Note, error) {
+	return run(s, ctx, func(ctx context.Context) ([]database.Note, error) { return s.inner.GetNotesForUser(ctx, arg) })
+}
+
+func (s *timeoutStore) GetRecentNotesForUser(ctx context.Context, arg database.GetRecentNotesForUserParams) ([]database.Note, error) {
+	return run(s, ctx, func(ctx context.Context) ([]database.Note, error) {
+		return s.inner.GetRecentNotesForUser(ctx, arg)
+	})
+}
+
+func (s *timeoutStore) GetTagCountsForUser(ctx context.Context, userID string) ([]database.GetTagCountsForUserRow, error) {
+	return run(s, ctx, func(ctx context.Context) ([]database.GetTagCountsForUserRow, error) {
+		return s.inner.GetTagCountsForUser(ctx, userID)
+	})
+}
+
+func (s *timeoutStore) GetTagsForNote(ctx context.Context, noteID string) ([]string, error) {
+	return run(s, ctx, func(ctx context.Context) ([]string, error) { return s.inner.GetTagsForNote(ctx, noteID) })
+}
+
+func (s *timeoutStore) GetTopTagsForUser(ctx context.Context, arg database.GetTopTagsForUserParams) ([]database.GetTopTagsForUserRow, error) {
+	return run(s, ctx, func(ctx context.Context) ([]database.GetTopTagsForUserRow, error) {
+		return s.inner.GetTopTagsForUser(ctx, arg)
+	})
+}
+
+func (s *timeoutStore) GetUser(ctx context.Context, apiKey string) (database.User, error) {
+	return run(s, ctx, func(ctx context.Context) (database.User, error) { return s.inner.GetUser(ctx, apiKey) })
+}
+
+func (s *timeoutStore) GetUserByID(ctx context.Context, id string) (database.User, error) {
+	return run(s, ctx, func(ctx context.Context) (database.User, error) { return s.inner.GetUserByID(ctx, id) })
+}
+
+func (s *timeoutStore) SearchNotesForUser(ctx context.Context, arg database.SearchNotesForUserParams) ([]database.Note, error) {
+	return run(s, ctx, func(ctx context.Context) ([]database.Note, error) { return s.inner.SearchNotesForUser(ctx, arg) })
+}
+
+func (s *timeoutStore) SearchNotesForUserAfter(ctx context.Context, arg database.SearchNotesForUserAfterParams) ([]database.SearchNotesForUserAfterRow, error) {
+	return run(s, ctx, func(ctx context.Context) ([]database.SearchNotesForUserAfterRow, error) {
+		return s.inner.SearchNotesForUserAfter(ctx, arg)
+	})
+}
+
+func (s *timeoutStore) SearchUsersByNamePrefix(ctx context.Context, arg database.SearchUsersByNamePrefixParams) ([]database.User, error) {
+	return run(s, ctx, func(ctx context.Context) ([]database.User, error) { return s.inner.SearchUsersByNamePrefix(ctx, arg) })
+}
+
+func (s *timeoutStore) SetNoteMetadata(ctx context.Context, arg database.SetNoteMetadataParams) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.SetNoteMetadata(ctx, arg) })
+}
+
+func (s *timeoutStore) SetNotePinned(ctx context.Context, arg database.SetNotePinnedParams) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.SetNotePinned(ctx, arg) })
+}
+
+func (s *timeoutStore) SetNotePublic(ctx context.Context, arg database.SetNotePublicParams) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.SetNotePublic(ctx, arg) })
+}
+
+func (s *timeoutStore) UpdateNote(ctx context.Context, arg database.UpdateNoteParams) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.UpdateNote(ctx, arg) })
+}
+
+func (s *timeoutStore) UpdateUserAPIKey(ctx context.Context, arg database.UpdateUserAPIKeyParams) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.UpdateUserAPIKey(ctx, arg) })
+}
+
+func (s *timeoutStore) UpdateUserName(ctx context.Context, arg database.UpdateUserNameParams) error {
+	return runExec(s, ctx, func(ctx context.Context) error { return s.inner.UpdateUserName(ctx, arg) })
+}
+
+// InTx bounds each operation inside the transaction rather than the
+// transaction as a whole.
+func (s *timeoutStore) InTx(ctx context.Context, fn func(Store) error) error {
+	return s.inner.InTx(ctx, func(tx Store) error {
+		return fn(&timeoutStore{inner: tx, timeout: s.timeout})
+	})
+}
+
+func (s *timeoutStore) Ping(ctx context.Context) error {
+	return runExec(s, ctx, s.inner.Ping)
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// blockingStore blocks GetUser until its context is done.
+type blockingStore struct {
+	Store
+}
+
+func (blockingStore) GetUser(ctx context.Context, apiKey string) (database.User, error) {
+	<-ctx.Done()
+	return database.User{}, ctx.Err()
+}
+
+func (s blockingStore) InTx(ctx context.Context, fn func(Store) error) error {
+	return fn(s)
+}
+
+func TestWithTimeout(t *testing.T) {
+	s := WithTimeout(blockingStore{}, 10*time.Millisecond)
+
+	_, err := s.GetUser(context.Background(), "key")
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetUser error = %v, want ErrTimeout wrapping context.DeadlineExceeded", err)
+	}
+
+	err = s.InTx(context.Background(), func(tx Store) error {
+		_, err := tx.GetUser(context.Background(), "key")
+		return err
+	})
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("GetUser in transaction error = %v, want ErrTimeout", err)
+	}
+}
+
+func TestWithTimeoutCallerDeadline(t *testing.T) {
+	s := WithTimeout(blockingStore{}, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := s.GetUser(ctx, "key")
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrTimeout) {
+		t.Errorf("GetUser error = %v, want a bare context.DeadlineExceeded", err)
+	}
+}
+
+func TestWithTimeoutDisabled(t *testing.T) {
+	inner := blockingStore{}
+	if s := WithTimeout(inner, 0); s != Store(inner) {
+		t.Errorf("WithTimeout(s, 0) = %T, want s unchanged", s)
+	}
+}
@@ -0,0 +1,52 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func TestSeedUser(t *testing.T) {
+	s := NewStore(t)
+	alice := SeedUser(t, s, "alice")
+	bob := SeedUser(t, s, "bob")
+
+	got, err := s.GetUser(context.Background(), alice.ApiKey)
+	if err != nil {
+		t.Fatalf("GetUser() error = %v", err)
+	}
+	if got != alice {
+		t.Errorf("GetUser() = %+v, want %+v", got, alice)
+	}
+	if alice.ID == bob.ID || alice.ApiKey == bob.ApiKey {
+		t.Errorf("seeded users share an ID or API key: %+v, %+v", alice, bob)
+	}
+}
+
+func TestSeedNotes(t *testing.T) {
+	s := NewStore(t)
+	user := SeedUser(t, s, "alice")
+	ids := SeedNotes(t, s, user.ID, 3)
+
+	notes, err := s.GetNotesForUser(context.Background(), database.GetNotesForUserParams{
+		UserID:        user.ID,
+		CreatedBefore: time.Now().UTC().Add(time.Second).Format(time.RFC3339),
+		Limit:         10,
+	})
+	if err != nil {
+		t.Fatalf("GetNotesForUser() error = %v", err)
+	}
+	if len(notes) != len(ids) {
+		t.Fatalf("got %d notes, want %d", len(notes), len(ids))
+	}
+	// Newest first, so the listing runs backwards through ids.
+	for i, note := range notes {
+		j := len(ids) - 1 - i
+		if note.ID != ids[j] || note.Note != fmt.Sprintf("note %d", j) {
+			t.Errorf("notes[%d] = %s %q, want %s %q", i, note.ID, note.Note, ids[j], fmt.Sprintf("note %d", j))
+		}
+	}
+}
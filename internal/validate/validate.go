@@ -0,0 +1,169 @@
+// Package validate checks struct fields against rules declared in
+// `validate` struct tags, e.g. `validate:"required,max=100"`.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rule checks a single field. param is the text after "=" in the tag, if
+// any. A non-nil error's message is reported as the field's message.
+type Rule func(v reflect.Value, param string) error
+
+// FieldError describes one field that failed a rule. Field is the JSON name.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors is returned by Struct when any field fails validation.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + " " + fe.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+var (
+	mu    sync.RWMutex
+	rules = map[string]Rule{
+		"required": required,
+		"notblank": notBlank,
+		"min":      minLength,
+		"max":      maxLength,
+	}
+)
+
+// Register makes rule available to tags under name, replacing any rule
+// already registered with that name.
+func Register(name string, rule Rule) {
+	mu.Lock()
+	defer mu.Unlock()
+	rules[name] = rule
+}
+
+// Struct validates the exported fields of the struct s points to or holds.
+// Pointer fields are optional unless marked required. Only the first failing
+// rule of each field is reported. It panics on tags
+// naming unknown rules, which are programming errors.
+func Struct(s any) error {
+	v := reflect.Indirect(reflect.ValueOf(s))
+	if v.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validate: Struct called with %T", s))
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var errs Errors
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		for _, spec := range strings.Split(tag, ",") {
+			name, param, _ := strings.Cut(spec, "=")
+			rule, ok := rules[name]
+			if !ok {
+				panic(fmt.Sprintf("validate: unknown rule %q on %s.%s", name, t.Name(), field.Name))
+			}
+			// A nil pointer is an omitted optional field: only required
+			// applies to it. Other rules check the value pointed to.
+			if fv.Kind() == reflect.Pointer && name != "required" {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if err := rule(fv, param); err != nil {
+				errs = append(errs, FieldError{Field: jsonName(field), Message: err.Error()})
+				break
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func required(v reflect.Value, _ string) error {
+	if v.IsZero() {
+		return errors.New("is required")
+	}
+	return nil
+}
+
+func notBlank(v reflect.Value, _ string) error {
+	if v.Kind() == reflect.String && blank(v.String()) {
+		return errors.New("must not be blank")
+	}
+	return nil
+}
+
+// blank reports whether s has nothing a reader would see: only whitespace,
+// control characters and invisible format runes such as U+200B ZERO WIDTH
+// SPACE or variation selectors.
+func blank(s string) bool {
+	for _, r := range s {
+		if unicode.IsSpace(r) || unicode.IsControl(r) ||
+			unicode.Is(unicode.Cf, r) || unicode.Is(unicode.Variation_Selector, r) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func minLength(v reflect.Value, param string) error {
+	n := mustAtoi(param)
+	if length(v) < n {
+		return fmt.Errorf("must be at least %d characters", n)
+	}
+	return nil
+}
+
+func maxLength(v reflect.Value, param string) error {
+	n := mustAtoi(param)
+	if length(v) > n {
+		return fmt.Errorf("must be at most %d characters", n)
+	}
+	return nil
+}
+
+// length counts strings in runes so limits mean the same for every script.
+func length(v reflect.Value) int {
+	if v.Kind() == reflect.String {
+		return utf8.RuneCountInString(v.String())
+	}
+	return v.Len()
+}
+
+func mustAtoi(param string) int {
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		panic(fmt.Sprintf("validate: invalid rule parameter %q", param))
+	}
+	return n
+}
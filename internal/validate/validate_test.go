@@ -0,0 +1,116 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type signup struct {
+	Name     string  `json:"name" validate:"required,notblank,max=5"`
+	Handle   string  `json:"handle,omitempty" validate:"min=2,lowercase"`
+	Nickname string  `validate:"max=3"`
+	Bio      *string `json:"bio" validate:"notblank,max=4"`
+	Ignored  string
+}
+
+func ptr(s string) *string { return &s }
+
+func init() {
+	Register("lowercase", func(v reflect.Value, _ string) error {
+		if s := v.String(); s != strings.ToLower(s) {
+			return errors.New("must be lowercase")
+		}
+		return nil
+	})
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name  string
+		input signup
+		want  Errors
+	}{
+		{
+			name:  "valid",
+			input: signup{Name: "alice", Handle: "al", Nickname: "ål"},
+		},
+		{
+			name:  "required",
+			input: signup{Handle: "al"},
+			want:  Errors{{Field: "name", Message: "is required"}},
+		},
+		{
+			name:  "blank",
+			input: signup{Name: "   ", Handle: "al"},
+			want:  Errors{{Field: "name", Message: "must not be blank"}},
+		},
+		{
+			name:  "zero-width and control characters are blank",
+			input: signup{Name: "\u200b\u200d\x00\ufe0f", Handle: "al"},
+			want:  Errors{{Field: "name", Message: "must not be blank"}},
+		},
+		{
+			name:  "emoji are not blank",
+			input: signup{Name: "👩\u200d💻", Handle: "al"},
+		},
+		{
+			name:  "max length counts runes",
+			input: signup{Name: "ålice", Handle: "al", Nickname: "abcd"},
+			want:  Errors{{Field: "Nickname", Message: "must be at most 3 characters"}},
+		},
+		{
+			name:  "every failing field is reported",
+			input: signup{Name: "alexander", Handle: "A"},
+			want: Errors{
+				{Field: "name", Message: "must be at most 5 characters"},
+				{Field: "handle", Message: "must be at least 2 characters"},
+			},
+		},
+		{
+			name:  "optional pointer set",
+			input: signup{Name: "alice", Handle: "al", Bio: ptr("hello")},
+			want:  Errors{{Field: "bio", Message: "must be at most 4 characters"}},
+		},
+		{
+			name:  "optional pointer blank",
+			input: signup{Name: "alice", Handle: "al", Bio: ptr(" ")},
+			want:  Errors{{Field: "bio", Message: "must not be blank"}},
+		},
+		{
+			name:  "custom rule",
+			input: signup{Name: "alice", Handle: "Al"},
+			want:  Errors{{Field: "handle", Message: "must be lowercase"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Struct(&tt.input)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Struct() error = %v, want nil", err)
+				}
+				return
+			}
+			var got Errors
+			if !errors.As(err, &got) {
+				t.Fatalf("Struct() error = %v, want Errors", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Struct() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructUnknownRulePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Struct() did not panic on an unknown rule")
+		}
+	}()
+	Struct(struct {
+		Name string `validate:"frobnicate"`
+	}{})
+}
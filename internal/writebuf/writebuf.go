@@ -0,0 +1,112 @@
+// Package writebuf queues items in memory and hands them to a flush function
+// in batches from a background worker.
+package writebuf
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrFull is returned by Add when the queue is at capacity.
+	ErrFull = errors.New("writebuf: buffer full")
+	// ErrClosed is returned by Add once Stop has been called.
+	ErrClosed = errors.New("writebuf: buffer closed")
+)
+
+// Buffer batches items for flush. A batch is flushed as soon as it holds
+// maxBatch items, or when interval passes with a partial batch waiting.
+// Flushes run one at a time on a single worker. It is safe for concurrent
+// use.
+type Buffer[T any] struct {
+	flush    func([]T)
+	maxBatch int
+	interval time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan T
+	done   chan struct{}
+}
+
+// New returns a Buffer holding up to capacity unflushed items. The worker
+// doesn't run until Start.
+func New[T any](capacity, maxBatch int, interval time.Duration, flush func([]T)) *Buffer[T] {
+	return &Buffer[T]{
+		flush:    flush,
+		maxBatch: maxBatch,
+		interval: interval,
+		queue:    make(chan T, capacity),
+		done:     make(chan struct{}),
+	}
+}
+
+// Add queues item without blocking.
+func (b *Buffer[T]) Add(item T) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrClosed
+	}
+	select {
+	case b.queue <- item:
+		return nil
+	default:
+		return ErrFull
+	}
+}
+
+// Start launches the worker. It matches lifecycle.Manager's start signature.
+func (b *Buffer[T]) Start(context.Context) error {
+	go b.run()
+	return nil
+}
+
+// Stop refuses further items, then waits for the worker to flush everything
+// already queued, or for ctx to end.
+func (b *Buffer[T]) Stop(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Buffer[T]) run() {
+	defer close(b.done)
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	batch := make([]T, 0, b.maxBatch)
+	flush := func() {
+		if len(batch) > 0 {
+			b.flush(batch)
+			batch = make([]T, 0, b.maxBatch)
+		}
+	}
+	for {
+		select {
+		case item, ok := <-b.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, item)
+			if len(batch) >= b.maxBatch {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
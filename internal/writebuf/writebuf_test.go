@@ -0,0 +1,78 @@
+package writebuf
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]int
+}
+
+func (r *recorder) flush(batch []int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, slices.Clone(batch))
+}
+
+func (r *recorder) get() [][]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.batches)
+}
+
+func TestStopFlushesInBatches(t *testing.T) {
+	var rec recorder
+	b := New(10, 3, time.Hour, rec.flush)
+	for i := range 7 {
+		if err := b.Add(i); err != nil {
+			t.Fatalf("Add(%d) = %v", i, err)
+		}
+	}
+	b.Start(context.Background())
+	if err := b.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop = %v", err)
+	}
+
+	want := [][]int{{0, 1, 2}, {3, 4, 5}, {6}}
+	if got := rec.get(); !slices.EqualFunc(got, want, slices.Equal[[]int]) {
+		t.Errorf("batches = %v, want %v", got, want)
+	}
+	if err := b.Add(7); !errors.Is(err, ErrClosed) {
+		t.Errorf("Add after Stop = %v, want ErrClosed", err)
+	}
+}
+
+func TestIntervalFlushesPartialBatch(t *testing.T) {
+	var rec recorder
+	b := New(10, 100, 10*time.Millisecond, rec.flush)
+	b.Start(context.Background())
+	defer b.Stop(context.Background())
+
+	b.Add(1)
+	b.Add(2)
+	deadline := time.Now().Add(time.Second)
+	for len(rec.get()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("partial batch never flushed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := rec.get(); len(got) != 1 || !slices.Equal(got[0], []int{1, 2}) {
+		t.Errorf("batches = %v, want [[1 2]]", got)
+	}
+}
+
+func TestAddWhenFull(t *testing.T) {
+	b := New(2, 10, time.Hour, func([]int) {})
+	b.Add(1)
+	b.Add(2)
+	if err := b.Add(3); !errors.Is(err, ErrFull) {
+		t.Errorf("Add past capacity = %v, want ErrFull", err)
+	}
+}
@@ -2,35 +2,172 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+)
+
+type errorResponse struct {
+	Error string `json:"error"`
+	// Code is a stable machine readable reason, set for errors clients may
+	// want to tell apart, e.g. "database_timeout".
+	Code string `json:"code,omitempty"`
+	// Fields lists per-field problems for validation failures.
+	Fields validate.Errors `json:"fields,omitempty"`
+}
+
+const (
+	errCodeBodyRequired    = "body_required"
+	errCodeBodyTooLarge    = "body_too_large"
+	errCodeDatabaseBusy    = "database_busy"
+	errCodeDatabaseTimeout = "database_timeout"
+	errCodeHandlerTimeout  = "handler_timeout"
+	errCodeIDGeneration    = "id_generation_failed"
+	errCodeInvalidJSON     = "invalid_json"
+	errCodeKeyRevoked      = "key_revoked"
+	errCodeNameTaken       = "name_taken"
+	errCodeRateLimited     = "rate_limited"
+	errCodeServerBusy      = "server_busy"
+	errCodeValidation      = "validation_failed"
 )
 
+// respondWithError only logs server faults. 4XX responses are expected client
+// errors and logging them would drown out the 5XX ones. A logErr caused by a
+// store query timeout or a persistently locked database turns the response
+// into a 504 or a retryable 503, whatever code the handler asked for, and
+// one from reading past the route's body limit turns it into a 413. A
+// missing, corrupt gzip or malformed JSON body is always a 400.
 func respondWithError(w http.ResponseWriter, code int, msg string, logErr error) {
-	if logErr != nil {
-		log.Println(logErr)
+	var errCode string
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(logErr, errBodyRequired):
+		code = http.StatusBadRequest
+		errCode = errCodeBodyRequired
+		msg = "Request body is required"
+	case errors.Is(logErr, errInvalidGzip):
+		code = http.StatusBadRequest
+		msg = "Request body is not valid gzip"
+	case errors.Is(logErr, errInvalidJSON):
+		code = http.StatusBadRequest
+		errCode = errCodeInvalidJSON
+		msg = "Request body is not valid JSON"
+	case errors.As(logErr, &tooLarge):
+		code = http.StatusRequestEntityTooLarge
+		errCode = errCodeBodyTooLarge
+		msg = fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit)
+	case errors.Is(logErr, store.ErrTimeout):
+		code = http.StatusGatewayTimeout
+		errCode = errCodeDatabaseTimeout
+	case errors.Is(logErr, store.ErrBusy):
+		code = http.StatusServiceUnavailable
+		errCode = errCodeDatabaseBusy
+		w.Header().Set("Retry-After", "1")
+	case errors.Is(logErr, errIDGeneration):
+		errCode = errCodeIDGeneration
 	}
 	if code > 499 {
-		log.Printf("Responding with 5XX error: %s", msg)
-	}
-	type errorResponse struct {
-		Error string `json:"error"`
+		if logErr != nil {
+			log.Printf("Responding with 5XX error: %s: %v", msg, logErr)
+		} else {
+			log.Printf("Responding with 5XX error: %s", msg)
+		}
 	}
 	respondWithJSON(w, code, errorResponse{
 		Error: msg,
+		Code:  errCode,
 	})
 }
 
-func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
-	w.Header().Set("Content-Type", "application/json")
+// errBodyRequired is returned by decodeJSON when the body is empty or only
+// whitespace, as opposed to malformed.
+var errBodyRequired = errors.New("request body is required")
+
+// errInvalidJSON wraps decodeJSON's errors for a body that isn't JSON, is
+// cut short, or doesn't fit v's types.
+var errInvalidJSON = errors.New("request body is not valid JSON")
+
+// decodeJSON decodes the request body into v.
+func decodeJSON(r *http.Request, v any) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return errBodyRequired
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return fmt.Errorf("%w: %w", errInvalidJSON, err)
+	}
+	return err
+}
+
+// validateParams checks params against their validate struct tags. On
+// failure it responds with a 422 listing each invalid field and returns
+// false.
+func validateParams(w http.ResponseWriter, params any) bool {
+	return !respondInvalid(w, validate.Struct(params))
+}
+
+// respondInvalid responds with the same 422 as validateParams and returns
+// true if err holds validate.Errors, and does nothing otherwise.
+func respondInvalid(w http.ResponseWriter, err error) bool {
+	var fields validate.Errors
+	if !errors.As(err, &fields) {
+		return false
+	}
+	respondWithJSON(w, http.StatusUnprocessableEntity, errorResponse{
+		Error:  "Validation failed",
+		Code:   errCodeValidation,
+		Fields: fields,
+	})
+	return true
+}
+
+// respondWithJSON marshals payload before writing anything, so a payload
+// that can't be encoded produces a clean 500 rather than a partial body. An
+// errorResponse goes out as problem details when middlewareProblem asked.
+func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) error {
+	contentType := "application/json"
+	e, isError := payload.(errorResponse)
+	if pw, ok := problemWriterFor(w); ok && isError {
+		payload = pw.problem(code, e)
+		contentType = problemMediaType
+	} else if ew, ok := w.(*envelopeWriter); ok {
+		payload = ew.wrap(payload)
+	}
+	w.Header().Set("Content-Type", contentType)
 	dat, err := json.Marshal(payload)
 	if err != nil {
 		log.Printf("Error marshalling JSON: %s", err)
-		w.WriteHeader(500)
-		return
+		w.WriteHeader(http.StatusInternalServerError)
+		if _, err := w.Write([]byte(`{"error":"Couldn't encode response"}`)); err != nil {
+			log.Printf("Error writing response: %s", err)
+		}
+		return err
 	}
 	w.WriteHeader(code)
 	if _, err := w.Write(dat); err != nil {
 		log.Printf("Error writing response: %s", err)
+		return err
 	}
+	return nil
+}
+
+// respondCreated writes payload as a 201, pointing Location at the new
+// resource when it has its own URL.
+func respondCreated(w http.ResponseWriter, location string, payload any) error {
+	if location != "" {
+		w.Header().Set("Location", location)
+	}
+	return respondWithJSON(w, http.StatusCreated, payload)
+}
+
+// respondNoContent writes a bodiless 204. There is nothing to envelope.
+func respondNoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
 }
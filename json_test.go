@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+func TestRespondWithJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := respondWithJSON(rec, http.StatusCreated, map[string]string{"status": "ok"}); err != nil {
+		t.Fatalf("respondWithJSON() error = %v, want nil", err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if got, want := rec.Body.String(), `{"status":"ok"}`; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestRespondWithJSON_UnmarshalablePayload(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := respondWithJSON(rec, http.StatusOK, map[string]any{"events": make(chan int)})
+	if err == nil {
+		t.Fatal("respondWithJSON() error = nil, want marshal error")
+	}
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	body := decodeResponse[map[string]string](t, rec)
+	if body["error"] == "" {
+		t.Errorf("body = %v, want an error message", body)
+	}
+}
+
+func TestRespondWithErrorLogsOnlyServerErrors(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	respondWithError(httptest.NewRecorder(), http.StatusBadRequest, "Bad input", errors.New("client sent garbage"))
+	if logs.Len() != 0 {
+		t.Errorf("400 logged %q, want nothing", logs.String())
+	}
+
+	respondWithError(httptest.NewRecorder(), http.StatusInternalServerError, "Couldn't get notes", errors.New("disk on fire"))
+	if got := logs.String(); !strings.Contains(got, "Couldn't get notes") || !strings.Contains(got, "disk on fire") {
+		t.Errorf("500 logged %q, want message and cause", got)
+	}
+}
+
+func TestRespondWithErrorStoreFailures(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	tests := []struct {
+		name           string
+		err            error
+		wantCode       int
+		wantErrCode    string
+		wantRetryAfter string
+	}{
+		{"timeout", fmt.Errorf("get notes: %w", store.ErrTimeout), http.StatusGatewayTimeout, errCodeDatabaseTimeout, ""},
+		{"busy", fmt.Errorf("create note: %w", store.ErrBusy), http.StatusServiceUnavailable, errCodeDatabaseBusy, "1"},
+		{"other", errors.New("boom"), http.StatusInternalServerError, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			respondWithError(rec, http.StatusInternalServerError, "Couldn't do it", tt.err)
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+			if got := decodeResponse[errorResponse](t, rec).Code; got != tt.wantErrCode {
+				t.Errorf("code = %q, want %q", got, tt.wantErrCode)
+			}
+		})
+	}
+}
+
+func TestRespondCreated(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+	}{
+		{"with location", "/v1/notes/123"},
+		{"without location", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			if err := respondCreated(rec, tt.location, map[string]string{"id": "123"}); err != nil {
+				t.Fatalf("respondCreated() error = %v, want nil", err)
+			}
+			if rec.Code != http.StatusCreated {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+			}
+			if got := rec.Header().Get("Location"); got != tt.location {
+				t.Errorf("Location = %q, want %q", got, tt.location)
+			}
+			if got, want := rec.Body.String(), `{"id":"123"}`; got != want {
+				t.Errorf("body = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestRespondNoContent(t *testing.T) {
+	rec := httptest.NewRecorder()
+	respondNoContent(rec)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("body = %q, want empty", rec.Body)
+	}
+}
+
+func TestCreateRequestValidation(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+
+	tests := []struct {
+		name      string
+		path      string
+		body      map[string]string
+		wantField string
+		wantMsg   string
+	}{
+		{"user name required", "/v1/users", map[string]string{}, "name", "is required"},
+		{"user name blank", "/v1/users", map[string]string{"name": " \t"}, "name", "must not be blank"},
+		{"user name too long", "/v1/users", map[string]string{"name": strings.Repeat("a", 101)}, "name", "must be at most 100 characters"},
+		{"note required", "/v1/notes", map[string]string{"note": ""}, "note", "is required"},
+		{"note too long", "/v1/notes", map[string]string{"note": strings.Repeat("é", 10001)}, "note", "must be at most 10000 characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, h, http.MethodPost, tt.path, user.ApiKey, tt.body)
+			if rec.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+			}
+			got := decodeResponse[errorResponse](t, rec)
+			if got.Code != errCodeValidation || len(got.Fields) != 1 ||
+				got.Fields[0].Field != tt.wantField || got.Fields[0].Message != tt.wantMsg {
+				t.Errorf("body = %+v, want %s %q", got, tt.wantField, tt.wantMsg)
+			}
+		})
+	}
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/notes", user.ApiKey, map[string]string{"note": strings.Repeat("é", 10000)})
+	if rec.Code != http.StatusCreated {
+		t.Errorf("note at the limit status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// errKeyRevoked is returned by authenticate for a key on KeyDenylist.
+var errKeyRevoked = errors.New("api key revoked")
+
+// keyDenylist holds the SHA-256 hashes, hex encoded, of API keys that must
+// be refused before they reach the database. Only hashes are kept, so
+// listing the denylist never exposes a usable key. It's safe for concurrent
+// use.
+type keyDenylist struct {
+	mu     sync.RWMutex
+	hashes map[string]struct{}
+}
+
+// newKeyDenylist builds a denylist from hashes, as checked by
+// parseKeyHashes.
+func newKeyDenylist(hashes []string) (*keyDenylist, error) {
+	d := &keyDenylist{}
+	if err := d.replace(hashes); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// denied reports whether apiKey is on the denylist.
+func (d *keyDenylist) denied(apiKey string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if len(d.hashes) == 0 {
+		return false
+	}
+	_, ok := d.hashes[hashAPIKey(apiKey)]
+	return ok
+}
+
+// replace swaps the whole denylist for hashes. A malformed hash leaves the
+// current list untouched.
+func (d *keyDenylist) replace(hashes []string) error {
+	set, err := parseKeyHashes(hashes)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.hashes = set
+	d.mu.Unlock()
+	return nil
+}
+
+// list returns the denied hashes, sorted.
+func (d *keyDenylist) list() []string {
+	d.mu.RLock()
+	hashes := make([]string, 0, len(d.hashes))
+	for hash := range d.hashes {
+		hashes = append(hashes, hash)
+	}
+	d.mu.RUnlock()
+	sort.Strings(hashes)
+	return hashes
+}
+
+// parseKeyHashes checks that every entry is a hex SHA-256 hash, as printed
+// by sha256sum, and lowercases them to match hashAPIKey. Errors name the
+// entry by position only, in case a raw key was pasted in by mistake.
+func parseKeyHashes(hashes []string) (map[string]struct{}, error) {
+	set := make(map[string]struct{}, len(hashes))
+	for i, hash := range hashes {
+		hash = strings.ToLower(strings.TrimSpace(hash))
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != 32 {
+			return nil, fmt.Errorf("entry %d is not a hex SHA-256 hash", i+1)
+		}
+		set[hash] = struct{}{}
+	}
+	return set, nil
+}
+
+// handlerAdminKeyDenylistGet lists the hashes of the denied API keys.
+func (cfg *apiConfig) handlerAdminKeyDenylistGet(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Hashes []string `json:"hashes"`
+	}
+	respondWithJSON(w, http.StatusOK, response{Hashes: cfg.KeyDenylist.list()})
+}
+
+// handlerAdminKeyDenylistPut replaces the denylist, taking effect on the
+// next request. The list lives in memory, so a restart goes back to
+// API_KEY_DENYLIST: add leaked keys there too until they are rotated out.
+func (cfg *apiConfig) handlerAdminKeyDenylistPut(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Hashes []string `json:"hashes"`
+	}
+
+	var params parameters
+	if err := decodeJSON(r, &params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if err := cfg.KeyDenylist.replace(params.Hashes); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	cfg.handlerAdminKeyDenylistGet(w, r)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultLanguage is negotiated when no languages are configured.
+const defaultLanguage = "en"
+
+type languageKey struct{}
+
+// middlewareLanguage negotiates the response language from Accept-Language
+// against cfg.Languages and stores it for languageFromContext. The first
+// configured language is the fallback for a missing, malformed or entirely
+// unsupported header.
+func (cfg *apiConfig) middlewareLanguage(next http.Handler) http.Handler {
+	supported := cfg.Languages
+	if len(supported) == 0 {
+		supported = []string{defaultLanguage}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := negotiateLanguage(r.Header.Get("Accept-Language"), supported)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), languageKey{}, lang)))
+	})
+}
+
+// languageFromContext returns the negotiated language, or defaultLanguage
+// outside middlewareLanguage.
+func languageFromContext(ctx context.Context) string {
+	if lang, ok := ctx.Value(languageKey{}).(string); ok {
+		return lang
+	}
+	return defaultLanguage
+}
+
+type languageRange struct {
+	tag string
+	q   float64
+}
+
+// negotiateLanguage picks the supported tag best matching header, trying
+// ranges by descending quality. A range matches a tag exactly, or by primary
+// subtag alone, so "en-GB" finds "en" and "pt" finds "pt-BR". Tags compare
+// case-insensitively and the configured spelling is returned. Malformed
+// ranges are skipped rather than failing the request.
+func negotiateLanguage(header string, supported []string) string {
+	var ranges []languageRange
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !validLanguageRange(tag) {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				continue
+			}
+			q = parsed
+		} else if params != "" {
+			continue
+		}
+		if q > 0 {
+			ranges = append(ranges, languageRange{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, lr := range ranges {
+		if lr.tag == "*" {
+			return supported[0]
+		}
+		for _, tag := range supported {
+			if strings.EqualFold(tag, lr.tag) {
+				return tag
+			}
+		}
+		primary, _, _ := strings.Cut(lr.tag, "-")
+		for _, tag := range supported {
+			candidate, _, _ := strings.Cut(strings.ToLower(tag), "-")
+			if candidate == primary {
+				return tag
+			}
+		}
+	}
+	return supported[0]
+}
+
+// validLanguageRange reports whether tag is "*" or 1-8 letters followed by
+// any number of "-" and 1-8 alphanumerics, per RFC 4647.
+func validLanguageRange(tag string) bool {
+	if tag == "*" {
+		return true
+	}
+	for i, sub := range strings.Split(tag, "-") {
+		if len(sub) < 1 || len(sub) > 8 {
+			return false
+		}
+		for _, c := range sub {
+			letter := c >= 'a' && c <= 'z'
+			if !letter && (i == 0 || c < '0' || c > '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
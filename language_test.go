@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddlewareLanguage(t *testing.T) {
+	cfg := &apiConfig{Languages: []string{"en", "fr", "pt-BR"}}
+	var got string
+	h := cfg.middlewareLanguage(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = languageFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"missing", "", "en"},
+		{"supported", "fr", "fr"},
+		{"case insensitive", "PT-br", "pt-BR"},
+		{"region falls back to primary", "fr-CA", "fr"},
+		{"primary finds region", "pt", "pt-BR"},
+		{"highest quality wins", "en;q=0.5, fr;q=0.9", "fr"},
+		{"unsupported preferred first", "de, fr;q=0.3", "fr"},
+		{"unsupported falls back", "de, ja;q=0.8", "en"},
+		{"zero quality excluded", "fr;q=0, pt", "pt-BR"},
+		{"wildcard", "de, *;q=0.5", "en"},
+		{"malformed falls back", ";;;, q=, 1234", "en"},
+		{"malformed entries skipped", "fr;q=abc, not_a_tag, pt;q=0.2", "pt-BR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Accept-Language", tt.header)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("language for %q = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLanguageDefaults(t *testing.T) {
+	if got := languageFromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()); got != defaultLanguage {
+		t.Errorf("without middleware = %q, want %q", got, defaultLanguage)
+	}
+
+	var got string
+	h := (&apiConfig{}).middlewareLanguage(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = languageFromContext(r.Context())
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "fr")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if got != defaultLanguage {
+		t.Errorf("with no configured languages = %q, want %q", got, defaultLanguage)
+	}
+}
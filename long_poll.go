@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// parseWait reads the optional wait query parameter of a long poll, e.g.
+// wait=20s. Waits above MaxListWait are clamped to it, so a zero
+// MaxListWait turns long polling off.
+func (cfg *apiConfig) parseWait(r *http.Request) (time.Duration, error) {
+	v, err := queryValue(r.URL.Query(), "wait")
+	if err != nil || v == "" {
+		return 0, err
+	}
+	wait, err := time.ParseDuration(v)
+	if err != nil || wait < 0 {
+		return 0, fmt.Errorf("wait must be a non-negative duration such as 20s, got %q", v)
+	}
+	return min(wait, cfg.MaxListWait), nil
+}
+
+// waitForNewNote holds a long poll until a note is created for user or wait
+// elapses, and reports whether one was. It uses the same broker as the note
+// stream. If the request's If-Modified-Since is already out of date there
+// is something new to send, so it returns at once.
+func (cfg *apiConfig) waitForNewNote(r *http.Request, user database.User, wait time.Duration) (bool, error) {
+	// Subscribe first so a note created during the check isn't missed.
+	events, unsubscribe := cfg.NoteEvents.Subscribe(user.ID)
+	defer unsubscribe()
+
+	if r.Header.Get("If-Modified-Since") != "" {
+		lastModified, err := cfg.lastNoteUpdate(r, user)
+		if err != nil {
+			return false, err
+		}
+		if !unchangedSince(r, lastModified) {
+			return true, nil
+		}
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-events:
+		return true, nil
+	case <-timer.C:
+		return false, nil
+	case <-r.Context().Done():
+		return false, r.Context().Err()
+	}
+}
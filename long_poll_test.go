@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/pubsub"
+)
+
+func TestNotesListLongPoll(t *testing.T) {
+	var broker *pubsub.Broker
+	h, db := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.MaxListWait = 5 * time.Second
+		broker = cfg.NoteEvents
+	})
+	user := createTestUser(t, h, "alice")
+	first := createTestNote(t, h, user.ApiKey, "first")
+	lastModified := doRequest(t, h, http.MethodGet, "/v1/notes", user.ApiKey, nil).Header().Get("Last-Modified")
+
+	poll := func(wait string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/notes?wait="+wait, nil)
+		req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+		req.Header.Set("If-Modified-Since", lastModified)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("new note", func(t *testing.T) {
+		note := database.Note{
+			ID:        "0e3c1f27-5d1b-4a8e-9c2f-6b7a8d9e0f10",
+			CreatedAt: first.CreatedAt.UTC().Format(time.RFC3339),
+			UpdatedAt: first.CreatedAt.UTC().Format(time.RFC3339),
+			Note:      "second",
+			UserID:    user.ID,
+			Metadata:  emptyNoteMetadata,
+		}
+		err := db.CreateNote(context.Background(), database.CreateNoteParams{
+			ID:        note.ID,
+			CreatedAt: note.CreatedAt,
+			UpdatedAt: note.UpdatedAt,
+			Note:      note.Note,
+			UserID:    note.UserID,
+			Metadata:  note.Metadata,
+		})
+		if err != nil {
+			t.Fatalf("seeding note: %v", err)
+		}
+
+		done := make(chan *httptest.ResponseRecorder)
+		go func() { done <- poll("5s") }()
+		// The poll may not have subscribed yet, so keep announcing the note
+		// until it answers.
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		var rec *httptest.ResponseRecorder
+		for rec == nil {
+			select {
+			case rec = <-done:
+			case <-ticker.C:
+				broker.Publish(note)
+			}
+		}
+
+		// The seeded note shares the first one's second, so only the wake
+		// up, not If-Modified-Since, says the list changed.
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		if notes := decodeResponse[[]Note](t, rec); len(notes) != 2 {
+			t.Errorf("got %d notes, want 2", len(notes))
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		lastModified = doRequest(t, h, http.MethodGet, "/v1/notes", user.ApiKey, nil).Header().Get("Last-Modified")
+		start := time.Now()
+		rec := poll("50ms")
+		if rec.Code != http.StatusNotModified {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotModified)
+		}
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("answered after %s, want at least the 50ms wait", elapsed)
+		}
+	})
+
+	t.Run("already changed", func(t *testing.T) {
+		lastModified = time.Unix(0, 0).UTC().Format(http.TimeFormat)
+		start := time.Now()
+		rec := poll("5s")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("answered after %s, want no wait for a stale If-Modified-Since", elapsed)
+		}
+	})
+
+	t.Run("invalid wait", func(t *testing.T) {
+		for _, wait := range []string{"soon", "-1s"} {
+			if rec := poll(wait); rec.Code != http.StatusBadRequest {
+				t.Errorf("wait=%s: status = %d, want %d", wait, rec.Code, http.StatusBadRequest)
+			}
+		}
+	})
+}
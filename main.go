@@ -1,25 +1,146 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"embed"
+	"errors"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
+	// The Docker image ships without zoneinfo, which the tz parameter needs.
+	_ "time/tzdata"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/cors"
 	"github.com/joho/godotenv"
 
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/cache"
+	"github.com/bootdotdev/learn-cicd-starter/internal/clientip"
 	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/flags"
+	"github.com/bootdotdev/learn-cicd-starter/internal/lifecycle"
+	"github.com/bootdotdev/learn-cicd-starter/internal/nonce"
+	"github.com/bootdotdev/learn-cicd-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-cicd-starter/internal/ratelimit"
+	"github.com/bootdotdev/learn-cicd-starter/internal/ringbuf"
+	"github.com/bootdotdev/learn-cicd-starter/internal/singleflight"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
 
 	_ "github.com/tursodatabase/libsql-client-go/libsql"
 )
 
 type apiConfig struct {
-	DB *database.Queries
+	DB store.Store
+	// ReadDB serves GET and HEAD requests when a read replica is
+	// configured. Nil sends every request to DB; see dbFor.
+	ReadDB     store.Store
+	NoteEvents *pubsub.Broker
+	Metrics    *apiMetrics
+	ReadOnly   bool
+
+	// ResponseEnvelope wraps responses as {"data": ..., "meta": ...} unless
+	// the client opts out.
+	ResponseEnvelope bool
+	// DecompressRequests accepts gzip request bodies, see
+	// middlewareGzipRequest.
+	DecompressRequests bool
+	// Languages are the tags middlewareLanguage negotiates between, the
+	// first being the fallback. Empty means just defaultLanguage.
+	Languages []string
+	// ProblemDetails writes errors as application/problem+json even when
+	// the client didn't ask for it.
+	ProblemDetails bool
+
+	DefaultPageSize int
+	MaxPageSize     int
+	// RecentNotes is how many notes GET /v1/notes/recent returns.
+	RecentNotes int
+	// MaxTagsPerNote caps how many distinct tags one note can carry.
+	MaxTagsPerNote int
+	// MaxBulkTagNotes caps how many notes POST /v1/notes/search/tag may
+	// tag at once.
+	MaxBulkTagNotes int
+
+	// ClientIPs resolves the real client address behind TRUSTED_PROXIES.
+	ClientIPs *clientip.Resolver
+
+	// UserCache caches auth lookups by API key hash. Nil disables it.
+	UserCache *cache.LRU[database.User]
+	// Flags gates newer endpoints, see requireFeature.
+	Flags *flags.Set
+	// RateLimiter limits authenticated requests per user. Nil disables it.
+	RateLimiter *ratelimit.Limiter
+	// AuthFailures keeps the most recent failed authentications for
+	// GET /admin/auth/failures. Nil disables recording.
+	AuthFailures *ringbuf.Buffer[authFailure]
+	// KeyDenylist refuses revoked API keys before the database lookup. Nil
+	// disables it.
+	KeyDenylist *keyDenylist
+	// Impersonations holds the tokens issued by
+	// POST /admin/impersonate/{userID}. Nil disables impersonation.
+	Impersonations *nonce.Store
+	// Coalescer shares one response between concurrent identical GET
+	// requests, see serveCoalesced. Nil disables coalescing.
+	Coalescer *singleflight.Group[*recordedResponse]
+	// RequestLog logs each request, sampling those that succeed. Nil
+	// disables request logging.
+	RequestLog *requestLogger
+	// NoteWrites buffers POST /v1/notes for batched inserts. Nil writes
+	// each note in its own request.
+	NoteWrites *noteWrites
+	// DeleteTokens holds the nonces that guard account deletion. Nil
+	// disables the delete endpoints.
+	DeleteTokens *nonce.Store
+
+	// RequestTimeout bounds each /v1 request. Zero disables it.
+	RequestTimeout time.Duration
+	// MaxConcurrentRequests caps requests in flight, see
+	// middlewareConcurrency. Zero disables the cap.
+	MaxConcurrentRequests int
+	// StreamWriteTimeout replaces the server's WriteTimeout for the note
+	// stream, and ExportWriteTimeout for CSV exports, long polls and batch
+	// imports. Zero removes the deadline.
+	StreamWriteTimeout time.Duration
+	ExportWriteTimeout time.Duration
+	// MaxListWait caps how long GET /v1/notes?wait= long polls. Zero
+	// disables long polling.
+	MaxListWait time.Duration
+	// MaxBodyBytes caps request bodies on routes without their own limit.
+	// Zero disables the cap.
+	MaxBodyBytes int64
+	// BatchBodyBytes caps POST /v1/notes/batch, which carries many notes.
+	BatchBodyBytes int64
+
+	// Random is the entropy source for new IDs and API keys. Nil means
+	// crypto/rand.
+	Random io.Reader
+	// APIKeys generates new API keys. Nil means auth.DefaultKeyGenerator.
+	APIKeys *auth.KeyGenerator
+
+	// AdminAPIKey guards the /admin routes. Empty disables them.
+	AdminAPIKey string
+	// AllowedHosts lists the hostnames accepted in the Host header. Empty
+	// accepts any host.
+	AllowedHosts []string
+	// CORSOrigins lists the origins allowed to make cross-origin requests.
+	// Empty disables CORS, so browsers only allow same-origin requests.
+	CORSOrigins []string
+	// Maintenance runs VACUUM for POST /admin/maintenance/vacuum. It's only
+	// possible on a local SQLite file, which libsql opens through a sqlite
+	// driver this binary doesn't link, so it's nil in production and the
+	// route answers 501.
+	Maintenance store.Maintainer
+	// DBStats reports DB's connection pool, for GET /admin/db/stats. Nil
+	// disables the route.
+	DBStats       func() sql.DBStats
+	maintenanceMu sync.Mutex
 }
 
 //go:embed static/*
@@ -31,16 +152,94 @@ func main() {
 		log.Printf("warning: assuming default configuration. .env unreadable: %v", err)
 	}
 
-	port := os.Getenv("PORT")
+	port := envString("PORT", "")
 	if port == "" {
 		log.Fatal("PORT environment variable is not set")
 	}
 
-	apiCfg := apiConfig{}
+	maxHeaderBytes := envPositiveInt("MAX_HEADER_BYTES", 16<<10)
+
+	lc := lifecycle.New(envDuration("SHUTDOWN_TIMEOUT", 10*time.Second))
+
+	apiCfg := apiConfig{
+		NoteEvents:            pubsub.NewBroker(),
+		Metrics:               newAPIMetrics(),
+		ReadOnly:              envBool("READ_ONLY", false),
+		ResponseEnvelope:      envBool("RESPONSE_ENVELOPE", false),
+		ProblemDetails:        envBool("PROBLEM_DETAILS", false),
+		DecompressRequests:    envBool("DECOMPRESS_REQUESTS", false),
+		DefaultPageSize:       envPositiveInt("DEFAULT_PAGE_SIZE", defaultPageSize),
+		MaxPageSize:           envPositiveInt("MAX_PAGE_SIZE", maxPageSize),
+		MaxTagsPerNote:        envPositiveInt("MAX_TAGS_PER_NOTE", defaultMaxTagsPerNote),
+		MaxBulkTagNotes:       envPositiveInt("MAX_BULK_TAG_NOTES", defaultMaxBulkTagNotes),
+		RecentNotes:           min(envPositiveInt("RECENT_NOTES", defaultRecentNotes), maxRecentNotes),
+		RequestTimeout:        envDuration("REQUEST_TIMEOUT", 30*time.Second),
+		MaxListWait:           envDuration("NOTES_MAX_WAIT", 20*time.Second),
+		StreamWriteTimeout:    envDuration("STREAM_WRITE_TIMEOUT", 0),
+		ExportWriteTimeout:    envDuration("EXPORT_WRITE_TIMEOUT", 10*time.Minute),
+		MaxConcurrentRequests: envPositiveInt("MAX_CONCURRENT_REQUESTS", 0),
+		MaxBodyBytes:          int64(envPositiveInt("MAX_BODY_BYTES", 1<<20)),
+		BatchBodyBytes:        int64(envPositiveInt("MAX_BATCH_BODY_BYTES", 8<<20)),
+		AdminAPIKey:           envString("ADMIN_API_KEY", ""),
+		AllowedHosts:          envList("ALLOWED_HOSTS"),
+		CORSOrigins:           envList("CORS_ALLOWED_ORIGINS"),
+		Languages:             envList("SUPPORTED_LANGUAGES"),
+		DeleteTokens:          nonce.New(envDuration("DELETE_TOKEN_TTL", 5*time.Minute)),
+		AuthFailures:          ringbuf.New[authFailure](envPositiveInt("AUTH_FAILURE_LOG_SIZE", 100)),
+	}
+	if apiCfg.RequestTimeout > 0 && apiCfg.MaxListWait >= apiCfg.RequestTimeout {
+		log.Fatalf("NOTES_MAX_WAIT (%s) must be below REQUEST_TIMEOUT (%s)", apiCfg.MaxListWait, apiCfg.RequestTimeout)
+	}
+	keyDenylist, err := newKeyDenylist(envList("API_KEY_DENYLIST"))
+	if err != nil {
+		log.Fatalf("API_KEY_DENYLIST: %v", err)
+	}
+	apiCfg.KeyDenylist = keyDenylist
+	if envBool("COALESCE_REQUESTS", false) {
+		apiCfg.Coalescer = singleflight.New[*recordedResponse]()
+	}
+	if envBool("REQUEST_LOG", false) {
+		apiCfg.RequestLog = newRequestLogger(envPositiveInt("REQUEST_LOG_SAMPLE_RATE", 1))
+	}
+	if apiCfg.AdminAPIKey != "" {
+		apiCfg.Impersonations = nonce.New(envDuration("IMPERSONATION_TTL", 15*time.Minute))
+	}
+	if len(apiCfg.CORSOrigins) == 0 && envString("ENV", "") == "development" {
+		apiCfg.CORSOrigins = devCORSOrigins
+		log.Println("Allowing cross-origin requests from any origin (ENV=development)")
+	}
+	apiCfg.Flags = featureFlagsFromEnv(lc)
+	if apiCfg.DefaultPageSize > apiCfg.MaxPageSize {
+		log.Fatalf("DEFAULT_PAGE_SIZE (%d) must not exceed MAX_PAGE_SIZE (%d)", apiCfg.DefaultPageSize, apiCfg.MaxPageSize)
+	}
+	if apiCfg.ReadOnly {
+		log.Println("Running in read-only mode")
+	}
+	auth.MaxAPIKeyLen = envPositiveInt("MAX_API_KEY_LEN", auth.DefaultMaxAPIKeyLen)
+	if len(apiCfg.AdminAPIKey) > auth.MaxAPIKeyLen {
+		log.Fatalf("ADMIN_API_KEY is longer than MAX_API_KEY_LEN (%d)", auth.MaxAPIKeyLen)
+	}
+	apiCfg.APIKeys, err = auth.NewKeyGenerator(
+		envPositiveInt("API_KEY_LENGTH", auth.DefaultKeyLength),
+		envString("API_KEY_ALPHABET", auth.URLSafeAlphabet),
+	)
+	if err != nil {
+		log.Fatalf("API_KEY_LENGTH, API_KEY_ALPHABET: %v", err)
+	}
+	apiCfg.ClientIPs, err = clientip.NewResolver(envString("TRUSTED_PROXIES", ""))
+	if err != nil {
+		log.Fatalf("TRUSTED_PROXIES: %v", err)
+	}
+	if ttl := envDuration("USER_CACHE_TTL", 0); ttl > 0 {
+		apiCfg.UserCache = cache.New[database.User](envPositiveInt("USER_CACHE_SIZE", 1024), ttl)
+	}
+	if limit := envPositiveInt("RATE_LIMIT", 0); limit > 0 {
+		apiCfg.RateLimiter = ratelimit.New(limit, envDuration("RATE_LIMIT_WINDOW", time.Minute))
+	}
 
 	// https://github.com/libsql/libsql-client-go/#open-a-connection-to-sqld
 	// libsql://[your-database].turso.io?authToken=[your-auth-token]
-	dbURL := os.Getenv("DATABASE_URL")
+	dbURL := envString("DATABASE_URL", "")
 	if dbURL == "" {
 		log.Println("DATABASE_URL environment variable is not set")
 		log.Println("Running without CRUD endpoints")
@@ -49,21 +248,107 @@ func main() {
 		if err != nil {
 			log.Fatal(err)
 		}
-		dbQueries := database.New(db)
-		apiCfg.DB = dbQueries
+		sqlStore := store.NewSQL(db)
+		apiCfg.DB = store.WithRetry(
+			store.WithTimeout(sqlStore, envDuration("DB_TIMEOUT", 10*time.Second)),
+			3, 50*time.Millisecond,
+		)
+		apiCfg.DBStats = db.Stats
+		lc.Register("database", nil, func(context.Context) error {
+			return db.Close()
+		})
 		log.Println("Connected to database!")
+
+		if replicaURL := envString("DATABASE_READ_URL", ""); replicaURL != "" {
+			replica, err := sql.Open("libsql", replicaURL)
+			if err != nil {
+				log.Fatal(err)
+			}
+			apiCfg.ReadDB = store.WithTimeout(store.NewSQL(replica), envDuration("DB_TIMEOUT", 10*time.Second))
+			lc.Register("read replica", nil, func(context.Context) error {
+				return replica.Close()
+			})
+			log.Println("Reading from replica")
+		}
 	}
 
-	router := chi.NewRouter()
+	if envBool("STARTUP_SELF_TEST", false) && apiCfg.DB != nil {
+		// Registered before the server, so a failure aborts startup
+		// before any traffic is served.
+		lc.Register("startup self-test", apiCfg.selfTest, nil)
+	}
+
+	if capacity := envPositiveInt("NOTES_WRITE_BUFFER", 0); capacity > 0 && apiCfg.DB != nil {
+		apiCfg.NoteWrites = newNoteWrites(&apiCfg, capacity, envDuration("NOTES_FLUSH_INTERVAL", 100*time.Millisecond))
+		// Registered after the database and before the server, so it stops
+		// once no more notes can arrive and flushes while the DB is open.
+		lc.Register("note write buffer", apiCfg.NoteWrites.buf.Start, apiCfg.NoteWrites.buf.Stop)
+	}
+
+	srv := newServer(":"+port, newRouter(&apiCfg), serverTimeoutsFromEnv(), maxHeaderBytes)
+	tlsFiles, err := serverTLSFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	serverErr := make(chan error, 1)
+	lc.Register("http server", func(context.Context) error {
+		go func() {
+			if err := listenAndServe(srv, tlsFiles); !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}()
+		return nil
+	}, srv.Shutdown)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := lc.Start(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if tlsFiles.enabled() {
+		log.Printf("Serving TLS on port: %s\n", port)
+	} else {
+		log.Printf("Serving on port: %s\n", port)
+	}
+
+	// A server that stopped on its own is a failure, so it still shuts the
+	// rest down cleanly but exits non-zero for the supervisor to notice.
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		log.Println("Shutting down")
+	case err := <-serverErr:
+		log.Printf("Server stopped: %v", err)
+		exitCode = 1
+	}
+	lc.Stop(context.Background())
+	if exitCode != 0 {
+		stop()
+		os.Exit(exitCode)
+	}
+}
 
-	router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"*"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: false,
-		MaxAge:           300,
-	}))
+// devCORSOrigins lets any site call the API. It is only used with
+// ENV=development and no CORS_ALLOWED_ORIGINS.
+var devCORSOrigins = []string{"https://*", "http://*"}
+
+func newRouter(apiCfg *apiConfig) http.Handler {
+	router := chi.NewRouter()
+	base := newChain(middlewareRequestID, apiCfg.middlewareRequestLog, apiCfg.middlewareLanguage, apiCfg.middlewareAllowedHosts, apiCfg.middlewareConcurrency)
+	if len(apiCfg.CORSOrigins) > 0 {
+		base = base.Append(cors.Handler(cors.Options{
+			AllowedOrigins:   apiCfg.CORSOrigins,
+			AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowedHeaders:   []string{"*"},
+			ExposedHeaders:   []string{"Link", "X-Request-ID"},
+			AllowCredentials: false,
+			MaxAge:           300,
+			// Let middlewareOptions answer, so preflights get its 204.
+			OptionsPassthrough: true,
+		}))
+	}
+	router.Use(base.Append(middlewareOptions(router), middlewareHead(router))...)
 
 	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		f, err := staticFiles.Open("static/index.html")
@@ -77,24 +362,90 @@ func main() {
 		}
 	})
 
+	router.Handle("/metrics", apiCfg.Metrics.registry.Handler())
+	router.Get("/readyz", apiCfg.handlerReadyz)
+
 	v1Router := chi.NewRouter()
+	v1Router.Use(newChain(
+		apiCfg.middlewareGzipRequest,
+		apiCfg.middlewareMaxBody,
+		apiCfg.middlewareProblem,
+		apiCfg.middlewareEnvelope,
+		apiCfg.middlewareReadOnly,
+		middlewareRequireJSON,
+	)...)
+	// Routes register on timed unless they are meant to outlive
+	// RequestTimeout, like the note stream.
+	timed := v1Router.With(apiCfg.middlewareTimeout)
 
 	if apiCfg.DB != nil {
-		v1Router.Post("/users", apiCfg.handlerUsersCreate)
-		v1Router.Get("/users", apiCfg.middlewareAuth(apiCfg.handlerUsersGet))
-		v1Router.Get("/notes", apiCfg.middlewareAuth(apiCfg.handlerNotesGet))
-		v1Router.Post("/notes", apiCfg.middlewareAuth(apiCfg.handlerNotesCreate))
+		timed.Post("/users", withBodyLimit(userBodyLimit, apiCfg.handlerUsersCreate))
+		timed.Get("/users", apiCfg.middlewareAuth(apiCfg.handlerUsersGet))
+		timed.Patch("/users", withBodyLimit(userBodyLimit, apiCfg.middlewareAuth(apiCfg.handlerUsersUpdate)))
+		if apiCfg.DeleteTokens != nil {
+			timed.Get("/users/me/delete-token", apiCfg.middlewareAuth(apiCfg.handlerUsersDeleteToken))
+			timed.Delete("/users/me", apiCfg.middlewareAuth(apiCfg.handlerUsersDelete))
+		}
+		timed.Get("/auth/verify", apiCfg.handlerAuthVerify)
+		timed.Post("/users/{userID}/follow", apiCfg.middlewareAuth(apiCfg.handlerFollowCreate))
+		timed.Delete("/users/{userID}/follow", apiCfg.middlewareAuth(apiCfg.handlerFollowDelete))
+		timed.Get("/feed", apiCfg.middlewareAuth(apiCfg.handlerFeedGet))
+		timed.Get("/activity", apiCfg.middlewareAuth(apiCfg.handlerActivityGet))
+		v1Router.Get("/notes", apiCfg.withTimeoutUnless(wantsCSV, withNoteListWriteTimeout(apiCfg.ExportWriteTimeout, apiCfg.middlewareAuth(apiCfg.handlerNotesGet))))
+		timed.Post("/notes", apiCfg.middlewareAuth(apiCfg.handlerNotesCreate))
+		timed.Post("/notes/batch", apiCfg.requireFeature(flagNotesBatch, withBodyLimit(apiCfg.BatchBodyBytes, withWriteTimeout(apiCfg.ExportWriteTimeout, apiCfg.middlewareAuth(apiCfg.handlerNotesBatchCreate)))))
+		timed.Get("/notes/recent", apiCfg.middlewareAuth(apiCfg.handlerNotesRecent))
+		timed.Get("/notes/by-day", apiCfg.middlewareAuth(apiCfg.handlerNotesByDay))
+		timed.Get("/notes/stats", apiCfg.middlewareAuth(apiCfg.handlerNotesStats))
+		timed.Get("/notes/checksum", apiCfg.middlewareAuth(apiCfg.handlerNotesChecksum))
+		timed.Get("/notes/ids", apiCfg.middlewareAuth(apiCfg.handlerNotesIDs))
+		timed.Get("/notes/search", apiCfg.middlewareAuth(apiCfg.handlerNotesSearch))
+		timed.Get("/notes/search/count", apiCfg.middlewareAuth(apiCfg.handlerNotesSearchCount))
+		timed.Post("/notes/search/tag", apiCfg.middlewareAuth(apiCfg.handlerNotesSearchTag))
+		v1Router.Get("/notes/stream", withWriteTimeout(apiCfg.StreamWriteTimeout, apiCfg.middlewareAuth(apiCfg.handlerNotesStream)))
+		if apiCfg.NoteWrites != nil {
+			timed.Get("/notes/writes/{noteID}", apiCfg.middlewareAuth(apiCfg.handlerNoteWriteGet))
+		}
+		timed.Get("/notes/{noteID}", apiCfg.middlewareAuth(apiCfg.handlerNoteGet))
+		timed.Patch("/notes/{noteID}", apiCfg.middlewareAuth(apiCfg.handlerNotesUpdate))
+		timed.Get("/notes/{noteID}/history", apiCfg.middlewareAuth(apiCfg.handlerNotesHistory))
+		timed.Post("/notes/{noteID}/duplicate", apiCfg.middlewareAuth(apiCfg.handlerNotesDuplicate))
+		timed.Post("/notes/{noteID}/merge", apiCfg.middlewareAuth(apiCfg.handlerNotesMerge))
+		timed.Post("/notes/{noteID}/publish", apiCfg.middlewareAuth(apiCfg.handlerNoteSetPublic(true)))
+		timed.Post("/notes/{noteID}/unpublish", apiCfg.middlewareAuth(apiCfg.handlerNoteSetPublic(false)))
+		timed.Post("/notes/{noteID}/pin", apiCfg.requireFeature(flagNotesPin, apiCfg.middlewareAuth(apiCfg.handlerNoteSetPinned(true))))
+		timed.Post("/notes/{noteID}/unpin", apiCfg.requireFeature(flagNotesPin, apiCfg.middlewareAuth(apiCfg.handlerNoteSetPinned(false))))
+		timed.Get("/tags", apiCfg.middlewareAuth(apiCfg.handlerTagsGet))
+		timed.Post("/tags/{tag}/assign", apiCfg.middlewareAuth(apiCfg.handlerTagsAssign))
 	}
 
-	v1Router.Get("/healthz", handlerReadiness)
+	timed.Get("/healthz", handlerReadiness)
+	timed.Get("/postman.json", handlerPostmanCollection(router))
 
-	router.Mount("/v1", v1Router)
-	srv := &http.Server{
-		Addr:              ":" + port,
-		Handler:           router,
-		ReadHeaderTimeout: 60 * time.Second,
+	if apiCfg.DB != nil {
+		router.Get("/public/notes/{noteID}", apiCfg.handlerPublicNoteGet)
 	}
 
-	log.Printf("Serving on port: %s\n", port)
-	log.Fatal(srv.ListenAndServe())
+	router.Mount("/v1", v1Router)
+
+	if apiCfg.AdminAPIKey != "" && apiCfg.DB != nil {
+		adminRouter := chi.NewRouter()
+		adminRouter.Use(newChain(apiCfg.middlewareProblem, apiCfg.middlewareAdmin, apiCfg.middlewareReadOnly)...)
+		adminRouter.Post("/maintenance/vacuum", apiCfg.handlerAdminVacuum)
+		adminRouter.Get("/users", apiCfg.handlerAdminUsersSearch)
+		adminRouter.Get("/auth/failures", apiCfg.handlerAdminAuthFailures)
+		if apiCfg.KeyDenylist != nil {
+			adminRouter.Get("/keys/denylist", apiCfg.handlerAdminKeyDenylistGet)
+			adminRouter.Put("/keys/denylist", apiCfg.handlerAdminKeyDenylistPut)
+		}
+		if apiCfg.Impersonations != nil {
+			adminRouter.Post("/impersonate/{userID}", apiCfg.handlerAdminImpersonate)
+		}
+		if apiCfg.DBStats != nil {
+			adminRouter.Get("/db/stats", apiCfg.handlerAdminDBStats)
+		}
+		adminRouter.Get("/config", handlerAdminConfig)
+		router.Mount("/admin", adminRouter)
+	}
+	return router
 }
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/clientip"
+	"github.com/bootdotdev/learn-cicd-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store/memstore"
+	"github.com/bootdotdev/learn-cicd-starter/internal/testutil"
+)
+
+func newTestRouter(t *testing.T, opts ...func(*apiConfig)) (http.Handler, *memstore.Store) {
+	t.Helper()
+	db := testutil.NewStore(t)
+	clientIPs, err := clientip.NewResolver("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &apiConfig{
+		DB:              db,
+		NoteEvents:      pubsub.NewBroker(),
+		Metrics:         newAPIMetrics(),
+		ClientIPs:       clientIPs,
+		DefaultPageSize: defaultPageSize,
+		RecentNotes:     defaultRecentNotes,
+		MaxTagsPerNote:  defaultMaxTagsPerNote,
+		MaxBulkTagNotes: defaultMaxBulkTagNotes,
+		MaxPageSize:     maxPageSize,
+		// As in production, so handlers see TimeoutHandler's writer.
+		RequestTimeout: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return newRouter(cfg), db
+}
+
+func doRequest(t *testing.T, h http.Handler, method, path, apiKey string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("encoding request body: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+apiKey)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeResponse[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.NewDecoder(rec.Body).Decode(&v); err != nil {
+		t.Fatalf("decoding response body %q: %v", rec.Body.String(), err)
+	}
+	return v
+}
+
+func createTestUser(t *testing.T, h http.Handler, name string) User {
+	t.Helper()
+	rec := doRequest(t, h, http.MethodPost, "/v1/users", "", map[string]string{"name": name})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /v1/users status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	return decodeResponse[User](t, rec)
+}
+
+func createTestNote(t *testing.T, h http.Handler, apiKey, body string) Note {
+	t.Helper()
+	rec := doRequest(t, h, http.MethodPost, "/v1/notes", apiKey, map[string]string{"note": body})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /v1/notes status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	return decodeResponse[Note](t, rec)
+}
+
+func TestCORS(t *testing.T) {
+	corsHeaders := func(h http.Handler, method, origin string) http.Header {
+		t.Helper()
+		req := httptest.NewRequest(method, "/v1/healthz", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		got := http.Header{}
+		for name, values := range rec.Header() {
+			if strings.HasPrefix(name, "Access-Control-") {
+				got[name] = values
+			}
+		}
+		return got
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		h, _ := newTestRouter(t)
+		for _, method := range []string{http.MethodGet, http.MethodOptions} {
+			if got := corsHeaders(h, method, "https://evil.example"); len(got) > 0 {
+				t.Errorf("%s emitted CORS headers %v, want none", method, got)
+			}
+		}
+	})
+
+	t.Run("allow-list", func(t *testing.T) {
+		h, _ := newTestRouter(t, func(cfg *apiConfig) {
+			cfg.CORSOrigins = []string{"https://app.example.com"}
+		})
+		if got := corsHeaders(h, http.MethodGet, "https://app.example.com").Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("allowed origin: Access-Control-Allow-Origin = %q", got)
+		}
+		if got := corsHeaders(h, http.MethodGet, "https://evil.example").Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("other origin: Access-Control-Allow-Origin = %q, want none", got)
+		}
+	})
+
+	t.Run("development", func(t *testing.T) {
+		h, _ := newTestRouter(t, func(cfg *apiConfig) {
+			cfg.CORSOrigins = devCORSOrigins
+		})
+		if got := corsHeaders(h, http.MethodGet, "http://localhost:3000").Get("Access-Control-Allow-Origin"); got != "http://localhost:3000" {
+			t.Errorf("Access-Control-Allow-Origin = %q, want the request origin", got)
+		}
+	})
+}
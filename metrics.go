@@ -0,0 +1,44 @@
+package main
+
+import "github.com/bootdotdev/learn-cicd-starter/internal/metrics"
+
+// Auth outcomes recorded by middlewareAuth. They deliberately never include
+// any part of the presented key.
+const (
+	authOutcomeSuccess       = "success"
+	authOutcomeMissingHeader = "missing_header"
+	authOutcomeMalformed     = "malformed"
+	authOutcomeUnknownKey    = "unknown_key"
+	authOutcomeLookupError   = "lookup_error"
+	authOutcomeRevoked       = "revoked"
+
+	authOutcomeImpersonated       = "impersonated"
+	authOutcomeImpersonationWrite = "impersonation_write"
+)
+
+// apiMetrics groups the counters exported on /metrics.
+type apiMetrics struct {
+	registry     *metrics.Registry
+	authOutcomes *metrics.CounterVec
+}
+
+func newAPIMetrics() *apiMetrics {
+	m := &apiMetrics{
+		registry: metrics.NewRegistry(),
+		authOutcomes: metrics.NewCounterVec(
+			"notely_auth_requests_total",
+			"Authentication attempts by outcome.",
+			"outcome",
+			authOutcomeSuccess,
+			authOutcomeMissingHeader,
+			authOutcomeMalformed,
+			authOutcomeUnknownKey,
+			authOutcomeLookupError,
+			authOutcomeRevoked,
+			authOutcomeImpersonated,
+			authOutcomeImpersonationWrite,
+		),
+	}
+	m.registry.Register(m.authOutcomes)
+	return m
+}
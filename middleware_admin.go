@@ -0,0 +1,25 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+)
+
+// middlewareAdmin only lets through requests carrying AdminAPIKey, in the
+// same ApiKey header format as user keys.
+func (cfg *apiConfig) middlewareAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey, err := auth.GetAPIKey(r.Header)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Couldn't find api key", err)
+			return
+		}
+		if cfg.AdminAPIKey == "" || subtle.ConstantTimeCompare([]byte(apiKey), []byte(cfg.AdminAPIKey)) != 1 {
+			respondWithError(w, http.StatusForbidden, "Admin access required", nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
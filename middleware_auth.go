@@ -1,7 +1,15 @@
 package main
 
 import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
 	"github.com/bootdotdev/learn-cicd-starter/internal/database"
@@ -9,20 +17,175 @@ import (
 
 type authedHandler func(http.ResponseWriter, *http.Request, database.User)
 
+// tokenKind is what a request authenticated with.
+type tokenKind string
+
+const (
+	tokenAPIKey        tokenKind = "api_key"
+	tokenImpersonation tokenKind = "impersonation"
+)
+
+// errUserLookup wraps failures to resolve a well-formed key to a user, as
+// opposed to a missing or malformed header.
+var errUserLookup = errors.New("couldn't get user")
+
 func (cfg *apiConfig) middlewareAuth(handler authedHandler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		apiKey, err := auth.GetAPIKey(r.Header)
+		user, kind, err := cfg.authenticate(r)
+		if errors.Is(err, errImpersonationReadOnly) {
+			respondWithError(w, http.StatusForbidden, "Impersonation tokens are read-only", err)
+			return
+		}
+		if errors.Is(err, errKeyRevoked) {
+			respondWithJSON(w, http.StatusUnauthorized, errorResponse{
+				Error: "API key has been revoked",
+				Code:  errCodeKeyRevoked,
+			})
+			return
+		}
+		if errors.Is(err, errUserLookup) {
+			respondWithError(w, http.StatusNotFound, "Couldn't get user", err)
+			return
+		}
 		if err != nil {
 			respondWithError(w, http.StatusUnauthorized, "Couldn't find api key", err)
 			return
 		}
-
-		user, err := cfg.DB.GetUser(r.Context(), apiKey)
-		if err != nil {
-			respondWithError(w, http.StatusNotFound, "Couldn't get user", err)
+		if !cfg.allowRequest(w, user) {
 			return
 		}
 
-		handler(w, r, user)
+		cfg.serveCoalesced(w, r, user, kind, handler)
+	}
+}
+
+// allowRequest takes a token from the user's rate limit bucket and reports
+// its state in X-RateLimit-* headers. Once the bucket is empty it responds
+// with 429 and returns false.
+func (cfg *apiConfig) allowRequest(w http.ResponseWriter, user database.User) bool {
+	if cfg.RateLimiter == nil {
+		return true
+	}
+	status := cfg.RateLimiter.Allow(user.ID)
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(status.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(status.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(status.Reset.Unix(), 10))
+	if status.Allowed {
+		return true
+	}
+	retryAfter := int(math.Ceil(status.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	respondWithJSON(w, http.StatusTooManyRequests, errorResponse{
+		Error: "Rate limit exceeded",
+		Code:  errCodeRateLimited,
+	})
+	return false
+}
+
+// authenticate resolves the request's API key, or an impersonation token,
+// to a user and the kind of token used, recording the outcome in the auth
+// metrics. Failures are also kept in AuthFailures. Keys on KeyDenylist are
+// refused before any lookup.
+func (cfg *apiConfig) authenticate(r *http.Request) (database.User, tokenKind, error) {
+	apiKey, err := auth.GetAPIKey(r.Header)
+	if err != nil {
+		if errors.Is(err, auth.ErrNoAuthHeaderIncluded) {
+			cfg.authFailed(r, authOutcomeMissingHeader, "")
+		} else {
+			cfg.authFailed(r, authOutcomeMalformed, "")
+		}
+		return database.User{}, "", err
+	}
+	if cfg.KeyDenylist != nil && cfg.KeyDenylist.denied(apiKey) {
+		cfg.authFailed(r, authOutcomeRevoked, apiKey)
+		return database.User{}, "", errKeyRevoked
+	}
+
+	if user, ok, err := cfg.authenticateImpersonation(r, apiKey); ok {
+		switch {
+		case errors.Is(err, errImpersonationReadOnly):
+			cfg.authFailed(r, authOutcomeImpersonationWrite, apiKey)
+			return database.User{}, "", err
+		case err != nil:
+			cfg.authFailed(r, authOutcomeLookupError, apiKey)
+			return database.User{}, "", fmt.Errorf("%w: %w", errUserLookup, err)
+		}
+		cfg.Metrics.authOutcomes.Inc(authOutcomeImpersonated)
+		return user, tokenImpersonation, nil
+	}
+
+	user, err := cfg.getUserByAPIKey(r, apiKey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			cfg.authFailed(r, authOutcomeUnknownKey, apiKey)
+		} else {
+			cfg.authFailed(r, authOutcomeLookupError, apiKey)
+		}
+		return database.User{}, "", fmt.Errorf("%w: %w", errUserLookup, err)
+	}
+
+	cfg.Metrics.authOutcomes.Inc(authOutcomeSuccess)
+	return user, tokenAPIKey, nil
+}
+
+// getUserByAPIKey looks the user up through UserCache when it is enabled.
+// The cache is keyed by a hash of the key so raw keys aren't kept in memory.
+func (cfg *apiConfig) getUserByAPIKey(r *http.Request, apiKey string) (database.User, error) {
+	if cfg.UserCache == nil {
+		return cfg.dbFor(r).GetUser(r.Context(), apiKey)
+	}
+
+	cacheKey := hashAPIKey(apiKey)
+	if user, ok := cfg.UserCache.Get(cacheKey); ok {
+		return user, nil
+	}
+	user, err := cfg.dbFor(r).GetUser(r.Context(), apiKey)
+	if err != nil {
+		return database.User{}, err
+	}
+	cfg.UserCache.Add(cacheKey, user)
+	return user, nil
+}
+
+func hashAPIKey(apiKey string) string {
+	hash := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(hash[:])
+}
+
+// authFailure is one failed authentication, as listed by
+// GET /admin/auth/failures.
+type authFailure struct {
+	Timestamp time.Time `json:"timestamp"`
+	IP        string    `json:"ip"`
+	KeyPrefix string    `json:"key_prefix,omitempty"`
+	Reason    string    `json:"reason"`
+}
+
+// authFailureKeyPrefix is how much of a rejected key is kept: enough to
+// spot a client retrying the same stale key, far too little to use it.
+const authFailureKeyPrefix = 6
+
+func (cfg *apiConfig) authFailed(r *http.Request, reason, apiKey string) {
+	cfg.Metrics.authOutcomes.Inc(reason)
+	if cfg.AuthFailures == nil {
+		return
+	}
+	if len(apiKey) > authFailureKeyPrefix {
+		apiKey = apiKey[:authFailureKeyPrefix]
+	}
+	cfg.AuthFailures.Add(authFailure{
+		Timestamp: time.Now().UTC(),
+		IP:        cfg.clientIP(r),
+		KeyPrefix: apiKey,
+		Reason:    reason,
+	})
+}
+
+// clientIP is the request's client address, resolved through ClientIPs
+// when it is set.
+func (cfg *apiConfig) clientIP(r *http.Request) string {
+	if cfg.ClientIPs == nil {
+		return r.RemoteAddr
 	}
+	return cfg.ClientIPs.ClientIP(r)
 }
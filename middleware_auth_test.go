@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/cache"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/ratelimit"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+// countingStore counts GetUser calls that reach the underlying store.
+type countingStore struct {
+	store.Store
+	getUserCalls int
+}
+
+func (s *countingStore) GetUser(ctx context.Context, apiKey string) (database.User, error) {
+	s.getUserCalls++
+	return s.Store.GetUser(ctx, apiKey)
+}
+
+func TestMiddlewareAuthUserCache(t *testing.T) {
+	var counter *countingStore
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		counter = &countingStore{Store: cfg.DB}
+		cfg.DB = counter
+		cfg.UserCache = cache.New[database.User](8, time.Minute)
+	})
+	user := createTestUser(t, h, "alice")
+	counter.getUserCalls = 0
+
+	for i := 0; i < 3; i++ {
+		rec := doRequest(t, h, http.MethodGet, "/v1/users", user.ApiKey, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /v1/users status = %d, want %d", rec.Code, http.StatusOK)
+		}
+	}
+	if counter.getUserCalls != 1 {
+		t.Errorf("store GetUser calls = %d, want 1", counter.getUserCalls)
+	}
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/users", "unknown-key", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /v1/users with unknown key status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestMiddlewareAuthOutcomeMetrics(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+
+	requests := []struct {
+		outcome string
+		header  string
+		value   string
+	}{
+		{authOutcomeSuccess, "Authorization", "ApiKey " + user.ApiKey},
+		{authOutcomeMissingHeader, "", ""},
+		{authOutcomeMalformed, "Authorization", "Bearer " + user.ApiKey},
+		{authOutcomeUnknownKey, "Authorization", "ApiKey not-a-real-key"},
+		{authOutcomeUnknownKey, "X-API-Key", "also-not-real"},
+	}
+	for _, req := range requests {
+		r := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+		if req.header != "" {
+			r.Header.Set(req.header, req.value)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	rec := doRequest(t, h, http.MethodGet, "/metrics", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	body := rec.Body.String()
+	for _, line := range []string{
+		`notely_auth_requests_total{outcome="success"} 1`,
+		`notely_auth_requests_total{outcome="missing_header"} 1`,
+		`notely_auth_requests_total{outcome="malformed"} 1`,
+		`notely_auth_requests_total{outcome="unknown_key"} 2`,
+		`notely_auth_requests_total{outcome="lookup_error"} 0`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("/metrics missing %q in:\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, user.ApiKey) || strings.Contains(body, "not-a-real-key") {
+		t.Errorf("/metrics leaks key material:\n%s", body)
+	}
+}
+
+func TestMiddlewareAuthRateLimitHeaders(t *testing.T) {
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.RateLimiter = ratelimit.New(3, time.Minute)
+	})
+	alice := createTestUser(t, h, "alice")
+	bob := createTestUser(t, h, "bob")
+
+	for _, want := range []string{"2", "1", "0"} {
+		rec := doRequest(t, h, http.MethodGet, "/v1/users", alice.ApiKey, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		if got := rec.Header().Get("X-RateLimit-Limit"); got != "3" {
+			t.Errorf("X-RateLimit-Limit = %q, want 3", got)
+		}
+		if got := rec.Header().Get("X-RateLimit-Remaining"); got != want {
+			t.Errorf("X-RateLimit-Remaining = %q, want %q", got, want)
+		}
+		reset, err := strconv.ParseInt(rec.Header().Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil || reset < time.Now().Unix() || reset > time.Now().Add(time.Minute).Unix()+1 {
+			t.Errorf("X-RateLimit-Reset = %q, want a Unix time within the next minute", rec.Header().Get("X-RateLimit-Reset"))
+		}
+	}
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/users", alice.ApiKey, nil)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("over the limit: status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if got := rec.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("over the limit: X-RateLimit-Remaining = %q, want 0", got)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "20" {
+		t.Errorf("Retry-After = %q, want 20", got)
+	}
+	if body := decodeResponse[errorResponse](t, rec); body.Code != errCodeRateLimited {
+		t.Errorf("error code = %q, want %q", body.Code, errCodeRateLimited)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/v1/users", bob.ApiKey, nil)
+	if got := rec.Header().Get("X-RateLimit-Remaining"); got != "2" {
+		t.Errorf("other user: X-RateLimit-Remaining = %q, want 2", got)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/v1/users", "", nil)
+	if got := rec.Header().Get("X-RateLimit-Limit"); got != "" {
+		t.Errorf("unauthenticated request got X-RateLimit-Limit %q", got)
+	}
+}
+
+func TestMiddlewareAuthKeyDenylist(t *testing.T) {
+	var counter *countingStore
+	var denylist *keyDenylist
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		counter = &countingStore{Store: cfg.DB}
+		cfg.DB = counter
+		denylist, _ = newKeyDenylist(nil)
+		cfg.KeyDenylist = denylist
+	})
+	leaked := createTestUser(t, h, "alice")
+	other := createTestUser(t, h, "bob")
+	if err := denylist.replace([]string{strings.ToUpper(hashAPIKey(leaked.ApiKey))}); err != nil {
+		t.Fatal(err)
+	}
+	counter.getUserCalls = 0
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/users", leaked.ApiKey, nil)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("denied key status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if got := decodeResponse[errorResponse](t, rec).Code; got != errCodeKeyRevoked {
+		t.Errorf("denied key code = %q, want %q", got, errCodeKeyRevoked)
+	}
+	if counter.getUserCalls != 0 {
+		t.Errorf("denied key reached the store %d times, want 0", counter.getUserCalls)
+	}
+
+	if rec := doRequest(t, h, http.MethodGet, "/v1/users", other.ApiKey, nil); rec.Code != http.StatusOK {
+		t.Errorf("normal key status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if !strings.Contains(doRequest(t, h, http.MethodGet, "/metrics", "", nil).Body.String(), `notely_auth_requests_total{outcome="revoked"} 1`) {
+		t.Error("/metrics doesn't count the revoked key")
+	}
+}
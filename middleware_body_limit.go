@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+	"net/http"
+)
+
+// userBodyLimit caps the user endpoints, whose bodies are a name and a flag.
+const userBodyLimit = 4 << 10
+
+// limitedBody is a request body capped by http.MaxBytesReader. It keeps the
+// raw body so that withBodyLimit can apply a different cap.
+type limitedBody struct {
+	io.ReadCloser
+	raw io.ReadCloser
+}
+
+func limitBody(w http.ResponseWriter, r *http.Request, limit int64) {
+	raw := r.Body
+	if lb, ok := raw.(*limitedBody); ok {
+		raw = lb.raw
+	}
+	r.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, raw, limit), raw: raw}
+}
+
+// middlewareMaxBody caps every request body at MaxBodyBytes. Reading past
+// the cap fails with an *http.MaxBytesError, which respondWithError turns
+// into a 413.
+func (cfg *apiConfig) middlewareMaxBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.MaxBodyBytes > 0 && r.Body != nil {
+			limitBody(w, r, cfg.MaxBodyBytes)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withBodyLimit overrides MaxBodyBytes for one route, either way. A limit of
+// zero removes the cap.
+func withBodyLimit(limit int64, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch lb, ok := r.Body.(*limitedBody); {
+		case limit > 0 && r.Body != nil:
+			limitBody(w, r, limit)
+		case ok:
+			r.Body = lb.raw
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimitsPerRoute(t *testing.T) {
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.MaxBodyBytes = 1 << 10
+		cfg.BatchBodyBytes = 16 << 10
+	})
+	user := createTestUser(t, h, "alice")
+
+	batchOf := func(n, size int) map[string][]map[string]string {
+		notes := make([]map[string]string, n)
+		for i := range notes {
+			notes[i] = map[string]string{"note": strings.Repeat("x", size)}
+		}
+		return map[string][]map[string]string{"notes": notes}
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       any
+		wantStatus int
+	}{
+		{"note under global limit", http.MethodPost, "/v1/notes", map[string]string{"note": strings.Repeat("x", 500)}, http.StatusCreated},
+		{"note over global limit", http.MethodPost, "/v1/notes", map[string]string{"note": strings.Repeat("x", 2000)}, http.StatusRequestEntityTooLarge},
+		{"batch over global but under its own limit", http.MethodPost, "/v1/notes/batch", batchOf(8, 1000), http.StatusCreated},
+		{"batch over its own limit", http.MethodPost, "/v1/notes/batch", batchOf(20, 1000), http.StatusRequestEntityTooLarge},
+		// The user limit is above the global one, so this reaches validation.
+		{"user under its own limit", http.MethodPost, "/v1/users", map[string]string{"name": strings.Repeat("x", 2000)}, http.StatusUnprocessableEntity},
+		{"user over its own limit", http.MethodPost, "/v1/users", map[string]string{"name": strings.Repeat("x", 5000)}, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		rec := doRequest(t, h, tt.method, tt.path, user.ApiKey, tt.body)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+			continue
+		}
+		if tt.wantStatus == http.StatusRequestEntityTooLarge {
+			if code := decodeResponse[errorResponse](t, rec).Code; code != errCodeBodyTooLarge {
+				t.Errorf("%s: code = %q, want %q", tt.name, code, errCodeBodyTooLarge)
+			}
+		}
+	}
+}
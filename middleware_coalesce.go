@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// recordedResponse is a complete response, kept to be replayed to every
+// request coalesced onto the one that produced it.
+type recordedResponse struct {
+	code   int
+	header http.Header
+	body   []byte
+}
+
+func (resp *recordedResponse) writeTo(w http.ResponseWriter) {
+	for k, v := range resp.header {
+		w.Header()[k] = slices.Clone(v)
+	}
+	w.WriteHeader(resp.code)
+	if _, err := w.Write(resp.body); err != nil {
+		log.Printf("Error writing response: %s", err)
+	}
+}
+
+// responseRecorder buffers a response instead of sending it.
+type responseRecorder struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	if rec.code == 0 {
+		rec.code = code
+	}
+}
+
+func (rec *responseRecorder) Write(p []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return rec.body.Write(p)
+}
+
+// coalescedHeaders are the request headers a response can depend on, so
+// requests only share a response when these match too.
+var coalescedHeaders = []string{
+	"Accept",
+	"Accept-Language",
+	"If-Modified-Since",
+	"If-None-Match",
+	"If-Range",
+	"Range",
+}
+
+// serveCoalesced runs handler for an authenticated request, sharing one run,
+// and so one set of queries, between concurrent identical GET requests from
+// the same user and token kind when Coalescer is set. middlewareAuth calls it
+// once the request has passed authentication and rate limiting, so every
+// waiter is checked and counted as if it ran on its own. The first request
+// runs while the others wait for its response; nothing is kept afterwards,
+// so a request arriving later runs again. A waiting request may therefore
+// see data read just before it arrived, never older. If the first request's
+// own context ends first, the waiters run their handlers themselves rather
+// than share a cut-short response.
+//
+// Streams and CSV exports never finish into a single response, and
+// enveloped and problem+json responses are built on the writer the handler
+// is given, so those requests always run on their own.
+func (cfg *apiConfig) serveCoalesced(w http.ResponseWriter, r *http.Request, user database.User, kind tokenKind, handler authedHandler) {
+	if cfg.Coalescer == nil || !cfg.coalescable(r) {
+		handler(w, r, user)
+		return
+	}
+	resp, shared := cfg.Coalescer.Do(coalesceKey(r, user, kind), func() *recordedResponse {
+		rec := &responseRecorder{header: make(http.Header)}
+		handler(rec, r, user)
+		if r.Context().Err() != nil {
+			return nil
+		}
+		if rec.code == 0 {
+			rec.code = http.StatusOK
+		}
+		return &recordedResponse{code: rec.code, header: rec.header, body: rec.body.Bytes()}
+	})
+	switch {
+	case resp != nil:
+		resp.writeTo(w)
+	case shared:
+		handler(w, r, user)
+	}
+}
+
+func (cfg *apiConfig) coalescable(r *http.Request) bool {
+	return r.Method == http.MethodGet &&
+		!strings.HasSuffix(r.URL.Path, "/notes/stream") &&
+		!wantsCSV(r) &&
+		!wantsEnvelope(r, cfg.ResponseEnvelope) &&
+		!wantsProblem(r, cfg.ProblemDetails)
+}
+
+// coalesceKey identifies a request by its user, the kind of token it
+// authenticated with, its URL and the coalescedHeaders. Keying on the kind
+// keeps an impersonation token from sharing a response with the user's own
+// key.
+func coalesceKey(r *http.Request, user database.User, kind tokenKind) string {
+	parts := []string{user.ID, string(kind), r.Method, r.URL.RequestURI()}
+	for _, name := range coalescedHeaders {
+		parts = append(parts, r.Header.Get(name))
+	}
+	return strings.Join(parts, "\n")
+}
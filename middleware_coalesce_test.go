@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/singleflight"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+// gatedSearchStore counts searches and holds each one until release is
+// closed.
+type gatedSearchStore struct {
+	store.Store
+	release  chan struct{}
+	searches atomic.Int32
+}
+
+func (s *gatedSearchStore) SearchNotesForUser(ctx context.Context, arg database.SearchNotesForUserParams) ([]database.Note, error) {
+	s.searches.Add(1)
+	<-s.release
+	return s.Store.SearchNotesForUser(ctx, arg)
+}
+
+func TestMiddlewareCoalesce(t *testing.T) {
+	gated := &gatedSearchStore{release: make(chan struct{})}
+	var group *singleflight.Group[*recordedResponse]
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		gated.Store = cfg.DB
+		cfg.DB = gated
+		group = singleflight.New[*recordedResponse]()
+		cfg.Coalescer = group
+	})
+	close(gated.release)
+	user := createTestUser(t, h, "alice")
+	createTestNote(t, h, user.ApiKey, "invoice")
+	gated.release = make(chan struct{})
+	gated.searches.Store(0)
+
+	const n = 8
+	recs := make([]*httptest.ResponseRecorder, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recs[i] = doRequest(t, h, http.MethodGet, "/v1/notes/search?q=invoice", user.ApiKey, nil)
+		}()
+	}
+	deadline := time.Now().Add(time.Second)
+	for group.Pending() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of %d requests in flight", group.Pending(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(gated.release)
+	wg.Wait()
+
+	if got := gated.searches.Load(); got != 1 {
+		t.Errorf("store searched %d times for %d concurrent requests, want 1", got, n)
+	}
+	for i, rec := range recs {
+		if rec.Code != http.StatusOK || rec.Body.String() != recs[0].Body.String() {
+			t.Errorf("request %d = %d %s, want the shared 200 %s", i, rec.Code, rec.Body, recs[0].Body)
+		}
+		if got := rec.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("request %d Content-Type = %q, want application/json", i, got)
+		}
+	}
+
+	// Nothing is cached: a later identical request searches again.
+	doRequest(t, h, http.MethodGet, "/v1/notes/search?q=invoice", user.ApiKey, nil)
+	if got := gated.searches.Load(); got != 2 {
+		t.Errorf("store searched %d times after a sequential repeat, want 2", got)
+	}
+}
+
+func TestCoalesceKey(t *testing.T) {
+	user := database.User{ID: "1d0d4a52-7c1f-4b7e-9d65-0f3a5b8c2e11"}
+	r := httptest.NewRequest(http.MethodGet, "/v1/notes?limit=5", nil)
+	base := coalesceKey(r, user, tokenAPIKey)
+
+	if coalesceKey(r, user, tokenImpersonation) == base {
+		t.Error("impersonation token shares a key with the user's API key")
+	}
+	if coalesceKey(r, database.User{ID: "another"}, tokenAPIKey) == base {
+		t.Error("different users share a key")
+	}
+	r.Header.Set("If-Range", `"v1"`)
+	if coalesceKey(r, user, tokenAPIKey) == base {
+		t.Error("If-Range doesn't change the key")
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// middlewareConcurrency answers 503 with Retry-After once
+// MaxConcurrentRequests requests are in flight, instead of letting them
+// queue on the database pool. Health checks and metrics always get
+// through, and note streams are left out because they stay open for as long
+// as the client listens. Zero disables the limit.
+func (cfg *apiConfig) middlewareConcurrency(next http.Handler) http.Handler {
+	if cfg.MaxConcurrentRequests <= 0 {
+		return next
+	}
+	slots := make(chan struct{}, cfg.MaxConcurrentRequests)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/healthz" || r.URL.Path == "/readyz" || r.URL.Path == "/metrics" || strings.HasSuffix(r.URL.Path, "/notes/stream") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+			next.ServeHTTP(w, r)
+		default:
+			w.Header().Set("Retry-After", "1")
+			respondWithJSON(w, http.StatusServiceUnavailable, errorResponse{
+				Error: "Server is at capacity",
+				Code:  errCodeServerBusy,
+			})
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestMiddlewareConcurrency(t *testing.T) {
+	cfg := &apiConfig{MaxConcurrentRequests: 2}
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	h := cfg.middlewareConcurrency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			entered <- struct{}{}
+			<-release
+		}
+	}))
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.MaxConcurrentRequests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rec := serve("/slow"); rec.Code != http.StatusOK {
+				t.Errorf("in-flight request status = %d, want %d", rec.Code, http.StatusOK)
+			}
+		}()
+		<-entered
+	}
+
+	for i := 0; i < 3; i++ {
+		rec := serve("/v1/notes")
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("request past the limit: status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+		}
+		if got := rec.Header().Get("Retry-After"); got != "1" {
+			t.Errorf("Retry-After = %q, want 1", got)
+		}
+		if got := decodeResponse[errorResponse](t, rec).Code; got != errCodeServerBusy {
+			t.Errorf("code = %q, want %q", got, errCodeServerBusy)
+		}
+	}
+	for _, path := range []string{"/v1/healthz", "/metrics"} {
+		if rec := serve(path); rec.Code != http.StatusOK {
+			t.Errorf("%s while saturated: status = %d, want %d", path, rec.Code, http.StatusOK)
+		}
+	}
+
+	close(release)
+	wg.Wait()
+	if rec := serve("/v1/notes"); rec.Code != http.StatusOK {
+		t.Errorf("after the slow requests finished: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/jsonpatch"
+)
+
+// middlewareRequireJSON rejects POST, PUT, and PATCH requests whose body isn't
+// declared as application/json, or application/json-patch+json for PATCH.
+// Parameters such as charset are ignored and bodiless requests are let
+// through for the handler to judge.
+func middlewareRequireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			if r.ContentLength == 0 {
+				break
+			}
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if r.Method == http.MethodPatch && mediaType == jsonpatch.MediaType {
+				break
+			}
+			if err != nil || mediaType != "application/json" {
+				respondWithError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json", err)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareRequireJSON(t *testing.T) {
+	h, _ := newTestRouter(t)
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{"json", http.MethodPost, "application/json", `{"name":"alice"}`, http.StatusCreated},
+		{"json with charset", http.MethodPost, "application/json; charset=utf-8", `{"name":"bob"}`, http.StatusCreated},
+		{"missing content type", http.MethodPost, "", `{"name":"carol"}`, http.StatusUnsupportedMediaType},
+		{"wrong content type", http.MethodPost, "text/plain", `{"name":"dave"}`, http.StatusUnsupportedMediaType},
+		{"form content type", http.MethodPost, "application/x-www-form-urlencoded", `name=erin`, http.StatusUnsupportedMediaType},
+		{"json patch on post", http.MethodPost, "application/json-patch+json", `[]`, http.StatusUnsupportedMediaType},
+		{"delete without body", http.MethodDelete, "", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/v1/users", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareGzipRequest(t *testing.T) {
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.DecompressRequests = true
+		cfg.MaxBodyBytes = 1 << 10
+	})
+	user := createTestUser(t, h, "alice")
+
+	gzipped := func(v any) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if err := json.NewEncoder(zw).Encode(v); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	post := func(body []byte, encoding string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/v1/notes", bytes.NewReader(body))
+		req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+		req.Header.Set("Content-Type", "application/json")
+		if encoding != "" {
+			req.Header.Set("Content-Encoding", encoding)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("gzip body", func(t *testing.T) {
+		rec := post(gzipped(map[string]string{"note": "compressed"}), "gzip")
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+		}
+		if note := decodeResponse[Note](t, rec); note.Note != "compressed" {
+			t.Errorf("note = %q, want %q", note.Note, "compressed")
+		}
+	})
+
+	t.Run("decompressed past the limit", func(t *testing.T) {
+		// A few hundred compressed bytes that inflate to 100KB.
+		body := gzipped(map[string]string{"note": strings.Repeat("x", 100<<10)})
+		if len(body) >= 1<<10 {
+			t.Fatalf("compressed body is %d bytes, want it under the limit", len(body))
+		}
+		rec := post(body, "gzip")
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+		}
+		if code := decodeResponse[errorResponse](t, rec).Code; code != errCodeBodyTooLarge {
+			t.Errorf("code = %q, want %q", code, errCodeBodyTooLarge)
+		}
+	})
+
+	t.Run("corrupt gzip", func(t *testing.T) {
+		if rec := post([]byte(`{"note":"not gzip"}`), "gzip"); rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("plain body", func(t *testing.T) {
+		rec := post([]byte(`{"note":"plain"}`), "")
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusCreated)
+		}
+		if note := decodeResponse[Note](t, rec); note.Note != "plain" {
+			t.Errorf("note = %q, want %q", note.Note, "plain")
+		}
+	})
+}
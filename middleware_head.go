@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+// headWriter discards a response body while counting it, and holds the
+// status line back until the handler returns, so the Content-Length of the
+// body that GET would have sent can still be set. A flush sends the headers
+// as they are, without Content-Length, since the body isn't finished.
+type headWriter struct {
+	http.ResponseWriter
+	code      int
+	n         int
+	committed bool
+}
+
+func (w *headWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	w.n += len(p)
+	return len(p), nil
+}
+
+func (w *headWriter) Flush() {
+	w.commit()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *headWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *headWriter) commit() {
+	if w.committed {
+		return
+	}
+	w.committed = true
+	w.WriteHeader(http.StatusOK)
+	w.ResponseWriter.WriteHeader(w.code)
+}
+
+// finish sends the held status line, with the counted Content-Length unless
+// the handler set one or the status has no body.
+func (w *headWriter) finish() {
+	if w.committed {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if w.code >= http.StatusOK && w.code != http.StatusNoContent && w.code != http.StatusNotModified &&
+		w.Header().Get("Content-Length") == "" {
+		w.Header().Set("Content-Length", strconv.Itoa(w.n))
+	}
+	w.commit()
+}
+
+// middlewareHead answers HEAD on every path in routes that has a GET route
+// but no HEAD route of its own, by running the GET handler and dropping its
+// body. Headers, status and Content-Length all match what GET would send.
+// Handlers still see the HEAD method, so they can skip work that only
+// produces body.
+func middlewareHead(routes chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rctx := chi.RouteContext(r.Context())
+			if r.Method != http.MethodHead || rctx == nil ||
+				routes.Match(chi.NewRouteContext(), http.MethodHead, r.URL.Path) ||
+				!routes.Match(chi.NewRouteContext(), http.MethodGet, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rctx.RouteMethod = http.MethodGet
+			hw := &headWriter{ResponseWriter: w}
+			next.ServeHTTP(hw, r)
+			hw.finish()
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestMiddlewareHead(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "first")
+	createTestNote(t, h, user.ApiKey, "second")
+
+	for _, path := range []string{
+		"/v1/notes/" + note.ID,
+		"/v1/notes?limit=1",
+		"/v1/notes/" + note.ID + "/history",
+	} {
+		t.Run(path, func(t *testing.T) {
+			get := doRequest(t, h, http.MethodGet, path, user.ApiKey, nil)
+			head := doRequest(t, h, http.MethodHead, path, user.ApiKey, nil)
+			if head.Code != get.Code {
+				t.Fatalf("HEAD status = %d, want GET's %d", head.Code, get.Code)
+			}
+			if head.Body.Len() != 0 {
+				t.Errorf("HEAD body = %q, want empty", head.Body)
+			}
+			if got, want := head.Header().Get("Content-Length"), strconv.Itoa(get.Body.Len()); got != want {
+				t.Errorf("HEAD Content-Length = %q, want %q", got, want)
+			}
+			for _, name := range []string{"Content-Type", "Last-Modified", "Link", "X-Pagination-Limit", "X-Pagination-Offset"} {
+				if got, want := head.Header().Get(name), get.Header().Get(name); got != want {
+					t.Errorf("HEAD %s = %q, want GET's %q", name, got, want)
+				}
+			}
+		})
+	}
+
+	if rec := doRequest(t, h, http.MethodHead, "/v1/notes/"+note.ID, "", nil); rec.Code != http.StatusUnauthorized || rec.Body.Len() != 0 {
+		t.Errorf("unauthenticated HEAD = %d with %d byte body, want 401 with none", rec.Code, rec.Body.Len())
+	}
+	if rec := doRequest(t, h, http.MethodHead, "/v1/users/"+user.ID+"/follow", user.ApiKey, nil); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("HEAD on a route without GET status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
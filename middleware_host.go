@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// middlewareAllowedHosts rejects requests whose Host header is missing or
+// not in AllowedHosts. Entries are hostnames, matched case-insensitively and
+// regardless of port. An empty list disables the check.
+func (cfg *apiConfig) middlewareAllowedHosts(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(cfg.AllowedHosts) > 0 && !cfg.hostAllowed(r.Host) {
+			respondWithError(w, http.StatusBadRequest, "Host not allowed", nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (cfg *apiConfig) hostAllowed(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	if host == "" {
+		return false
+	}
+	for _, allowed := range cfg.AllowedHosts {
+		if strings.EqualFold(host, allowed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAllowedHosts(t *testing.T) {
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.AllowedHosts = []string{"api.example.com", "localhost"}
+	})
+
+	tests := []struct {
+		host       string
+		wantStatus int
+	}{
+		{"api.example.com", http.StatusOK},
+		{"API.Example.com:8080", http.StatusOK},
+		{"localhost:8080", http.StatusOK},
+		{"evil.example.com", http.StatusBadRequest},
+		{"example.com", http.StatusBadRequest},
+		{"", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/v1/healthz", nil)
+		req.Host = tt.host
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("Host %q status = %d, want %d", tt.host, rec.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestAllowedHostsEmptyListDisablesCheck(t *testing.T) {
+	h, _ := newTestRouter(t)
+
+	for _, host := range []string{"anything.example", ""} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/healthz", nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("Host %q status = %d, want %d", host, rec.Code, http.StatusOK)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi"
+)
+
+// routeMethods are the methods middlewareOptions looks for on a path.
+var routeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// middlewareOptions answers OPTIONS for every route in routes with a 204
+// whose Allow header lists the methods the path supports, so preflight
+// requests succeed whether or not CORS is configured. It runs ahead of
+// authentication, as browsers send preflights without credentials. Paths
+// without any route get a 404.
+func middlewareOptions(routes chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowed := []string{http.MethodOptions}
+			for _, method := range routeMethods {
+				// middlewareHead serves HEAD wherever there is a GET.
+				if routes.Match(chi.NewRouteContext(), method, r.URL.Path) ||
+					method == http.MethodHead && slices.Contains(allowed, http.MethodGet) {
+					allowed = append(allowed, method)
+				}
+			}
+			if len(allowed) == 1 {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOptionsOnEveryRoute(t *testing.T) {
+	const noteID = "6f1c2d3e-4b5a-4c6d-8e7f-9a0b1c2d3e4f"
+	h, _ := newTestRouter(t)
+
+	tests := []struct {
+		path      string
+		wantCode  int
+		wantAllow string
+	}{
+		{"/v1/notes", http.StatusNoContent, "OPTIONS, GET, HEAD, POST"},
+		{"/v1/notes/" + noteID, http.StatusNoContent, "OPTIONS, GET, HEAD, PATCH"},
+		{"/v1/notes/" + noteID + "/pin", http.StatusNoContent, "OPTIONS, POST"},
+		{"/v1/users", http.StatusNoContent, "OPTIONS, GET, HEAD, POST, PATCH"},
+		{"/v1/healthz", http.StatusNoContent, "OPTIONS, GET, HEAD"},
+		{"/public/notes/" + noteID, http.StatusNoContent, "OPTIONS, GET, HEAD"},
+		{"/v1/nothing-here", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		// No credentials, as a browser preflight would send.
+		rec := doRequest(t, h, http.MethodOptions, tt.path, "", nil)
+		if rec.Code != tt.wantCode {
+			t.Errorf("OPTIONS %s status = %d, want %d", tt.path, rec.Code, tt.wantCode)
+		}
+		if got := rec.Header().Get("Allow"); got != tt.wantAllow {
+			t.Errorf("OPTIONS %s Allow = %q, want %q", tt.path, got, tt.wantAllow)
+		}
+	}
+}
+
+func TestOptionsPreflightWithCORS(t *testing.T) {
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.CORSOrigins = []string{"https://app.example.com"}
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/v1/feed", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("preflight status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the request origin", got)
+	}
+	if got := rec.Header().Get("Allow"); got != "OPTIONS, GET, HEAD" {
+		t.Errorf("Allow = %q, want %q", got, "OPTIONS, GET, HEAD")
+	}
+}
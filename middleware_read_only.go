@@ -0,0 +1,17 @@
+package main
+
+import "net/http"
+
+func (cfg *apiConfig) middlewareReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.ReadOnly {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+			default:
+				respondWithError(w, http.StatusServiceUnavailable, "service in read-only mode", nil)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestReadOnlyModeBlocksWrites(t *testing.T) {
+	var cfg *apiConfig
+	h, _ := newTestRouter(t, func(c *apiConfig) {
+		cfg = c
+	})
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "before maintenance")
+	cfg.ReadOnly = true
+
+	tests := []struct {
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{http.MethodPost, "/v1/users", http.StatusServiceUnavailable},
+		{http.MethodPost, "/v1/notes", http.StatusServiceUnavailable},
+		{http.MethodPut, "/v1/notes", http.StatusServiceUnavailable},
+		{http.MethodPatch, "/v1/notes/" + note.ID, http.StatusServiceUnavailable},
+		{http.MethodDelete, "/v1/notes/" + note.ID, http.StatusServiceUnavailable},
+		{http.MethodGet, "/v1/notes", http.StatusOK},
+		{http.MethodGet, "/v1/notes/" + note.ID + "/history", http.StatusOK},
+		{http.MethodGet, "/v1/healthz", http.StatusOK},
+	}
+	for _, tt := range tests {
+		rec := doRequest(t, h, tt.method, tt.path, user.ApiKey, map[string]string{"note": "during maintenance"})
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, rec.Code, tt.wantStatus)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// requestLogger logs one line per request. Responses under 400 are sampled,
+// one in sampleRate, by a shared counter: the first such request is logged,
+// then every sampleRate-th after it, so the same traffic always logs the
+// same lines. Client and server errors are always logged.
+type requestLogger struct {
+	sampleRate uint64
+	seen       atomic.Uint64
+	logf       func(format string, args ...any)
+}
+
+// newRequestLogger logs to the standard logger. A sampleRate of 1 logs
+// every request.
+func newRequestLogger(sampleRate int) *requestLogger {
+	return &requestLogger{sampleRate: uint64(max(sampleRate, 1)), logf: log.Printf}
+}
+
+// sampled reports whether a response with code should be logged.
+func (l *requestLogger) sampled(code int) bool {
+	if code >= http.StatusBadRequest {
+		return true
+	}
+	return (l.seen.Add(1)-1)%l.sampleRate == 0
+}
+
+// statusWriter remembers the status code written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// middlewareRequestLog logs each request's method, path, status, duration
+// and request ID when RequestLog is set. The query string is left out, as
+// it can carry tokens.
+func (cfg *apiConfig) middlewareRequestLog(next http.Handler) http.Handler {
+	if cfg.RequestLog == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+		if sw.code == 0 {
+			sw.code = http.StatusOK
+		}
+		if cfg.RequestLog.sampled(sw.code) {
+			cfg.RequestLog.logf("%s %s %d %s request_id=%s",
+				r.Method, r.URL.Path, sw.code, time.Since(start).Round(time.Microsecond), requestIDFromContext(r.Context()))
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareRequestLogSampling(t *testing.T) {
+	var lines []string
+	logger := newRequestLogger(3)
+	logger.logf = func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	cfg := &apiConfig{RequestLog: logger}
+	h := middlewareRequestID(cfg.middlewareRequestLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte("ok"))
+		}
+	})))
+	request := func(path string) {
+		t.Helper()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path+"?token=secret", nil))
+	}
+
+	for range 9 {
+		request("/ok")
+	}
+	if len(lines) != 3 {
+		t.Fatalf("logged %d of 9 successes at 1 in 3, want 3:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	if !strings.HasPrefix(lines[0], "GET /ok 200 ") || !strings.Contains(lines[0], "request_id=") {
+		t.Errorf("log line = %q, want method, path, status and request ID", lines[0])
+	}
+	if strings.Contains(lines[0], "secret") {
+		t.Errorf("log line = %q includes the query string", lines[0])
+	}
+
+	lines = nil
+	for range 3 {
+		request("/missing")
+		request("/broken")
+	}
+	if len(lines) != 6 {
+		t.Fatalf("logged %d of 6 errors, want all:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	for i, want := range []string{"GET /missing 404 ", "GET /broken 500 "} {
+		if !strings.HasPrefix(lines[i], want) {
+			t.Errorf("log line %d = %q, want prefix %q", i, lines[i], want)
+		}
+	}
+}
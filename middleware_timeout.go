@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// middlewareTimeout fails requests that run past RequestTimeout with a 503
+// and code handler_timeout. Handlers keep running until they notice their
+// context is done, but anything they write afterwards is discarded.
+//
+// The timeout's own 503 is written straight to the connection, so it keeps
+// the plain errorResponse shape even when the envelope or problem details
+// are on.
+//
+// It is a per-route middleware: routes that are long lived by design, like
+// the note stream, opt out by registering without it, and withTimeoutUnless
+// opts out single requests. Their store queries are still bounded one by
+// one.
+func (cfg *apiConfig) middlewareTimeout(next http.Handler) http.Handler {
+	if cfg.RequestTimeout <= 0 {
+		return next
+	}
+	body, err := json.Marshal(errorResponse{
+		Error: "Request timed out",
+		Code:  errCodeHandlerTimeout,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner := http.HandlerFunc(func(tw http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(withResponseModes(w, tw), r)
+		})
+		http.TimeoutHandler(inner, cfg.RequestTimeout, string(body)).ServeHTTP(timeoutHeaderWriter{w}, r)
+	})
+}
+
+// withResponseModes carries the envelope and problem details modes set on
+// outer over to tw, the writer TimeoutHandler gives the handler. That writer
+// doesn't unwrap, so without this respondWithJSON and setResponseMeta would
+// never see them. The problemWriter goes underneath, as middlewareProblem
+// runs before middlewareEnvelope.
+func withResponseModes(outer, tw http.ResponseWriter) http.ResponseWriter {
+	if pw, ok := problemWriterFor(outer); ok {
+		tw = &problemWriter{ResponseWriter: tw, instance: pw.instance}
+	}
+	if ew, ok := envelopeWriterFor(outer); ok {
+		tw = &envelopeWriter{ResponseWriter: tw, meta: ew.meta}
+	}
+	return tw
+}
+
+// withTimeoutUnless is middlewareTimeout for one route, skipped for the
+// requests untimed reports, such as CSV exports, which TimeoutHandler would
+// buffer whole.
+func (cfg *apiConfig) withTimeoutUnless(untimed func(*http.Request) bool, next http.HandlerFunc) http.HandlerFunc {
+	timed := cfg.middlewareTimeout(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if untimed(r) {
+			next(w, r)
+			return
+		}
+		timed.ServeHTTP(w, r)
+	}
+}
+
+// timeoutHeaderWriter labels TimeoutHandler's own 503 body as JSON. A
+// handler's response reaches it with the handler's headers already copied
+// in, so those are sent as the handler set them.
+type timeoutHeaderWriter struct {
+	http.ResponseWriter
+}
+
+func (w timeoutHeaderWriter) WriteHeader(code int) {
+	if code == http.StatusServiceUnavailable && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w timeoutHeaderWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
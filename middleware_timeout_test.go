@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+// slowNotesStore blocks GetNotesForUser until its context is done.
+type slowNotesStore struct {
+	store.Store
+}
+
+func (slowNotesStore) GetNotesForUser(ctx context.Context, arg database.GetNotesForUserParams) ([]database.Note, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestTimeouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		wrap     func(cfg *apiConfig)
+		wantCode int
+		wantErr  string
+	}{
+		{
+			name: "database timeout",
+			wrap: func(cfg *apiConfig) {
+				cfg.RequestTimeout = time.Minute
+				cfg.DB = store.WithTimeout(slowNotesStore{cfg.DB}, 10*time.Millisecond)
+			},
+			wantCode: http.StatusGatewayTimeout,
+			wantErr:  errCodeDatabaseTimeout,
+		},
+		{
+			name: "handler timeout",
+			wrap: func(cfg *apiConfig) {
+				cfg.RequestTimeout = 10 * time.Millisecond
+				cfg.DB = store.WithTimeout(slowNotesStore{cfg.DB}, time.Minute)
+			},
+			wantCode: http.StatusServiceUnavailable,
+			wantErr:  errCodeHandlerTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestRouter(t, tt.wrap)
+			user := createTestUser(t, h, "alice")
+
+			rec := doRequest(t, h, http.MethodGet, "/v1/notes", user.ApiKey, nil)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			if got := decodeResponse[errorResponse](t, rec).Code; got != tt.wantErr {
+				t.Errorf("code = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTimeoutKeepsHandlerHeaders(t *testing.T) {
+	h, _ := newTestRouter(t, withDeleteTokens(time.Minute), func(cfg *apiConfig) {
+		cfg.RequestTimeout = time.Minute
+	})
+	user := createTestUser(t, h, "alice")
+
+	rec := deleteUser(t, h, user.ApiKey, issueDeleteToken(t, h, user.ApiKey))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "" {
+		t.Errorf("timed 204 Content-Type = %q, want none", ct)
+	}
+}
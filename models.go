@@ -1,7 +1,10 @@
 package main
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/bootdotdev/learn-cicd-starter/internal/database"
 )
@@ -33,12 +36,25 @@ func databaseUserToUser(user database.User) (User, error) {
 	}, nil
 }
 
+// In returns the user with its timestamps in loc.
+func (u User) In(loc *time.Location) User {
+	u.CreatedAt = u.CreatedAt.In(loc)
+	u.UpdatedAt = u.UpdatedAt.In(loc)
+	return u
+}
+
 type Note struct {
-	ID        string    `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Note      string    `json:"note"`
-	UserID    string    `json:"user_id"`
+	ID        string          `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Note      string          `json:"note"`
+	UserID    string          `json:"user_id"`
+	Public    bool            `json:"public"`
+	Metadata  json.RawMessage `json:"metadata"`
+	Pinned    bool            `json:"pinned"`
+	// CharCount and WordCount are derived from Note on every read.
+	CharCount int `json:"char_count"`
+	WordCount int `json:"word_count"`
 }
 
 func databaseNoteToNote(post database.Note) (Note, error) {
@@ -57,9 +73,28 @@ func databaseNoteToNote(post database.Note) (Note, error) {
 		UpdatedAt: updatedAt,
 		Note:      post.Note,
 		UserID:    post.UserID,
+		Public:    post.Public,
+		Metadata:  json.RawMessage(post.Metadata),
+		Pinned:    post.IsPinned,
+		CharCount: utf8.RuneCountInString(post.Note),
+		WordCount: len(strings.Fields(post.Note)),
 	}, nil
 }
 
+// In returns the note with its timestamps in loc.
+func (n Note) In(loc *time.Location) Note {
+	n.CreatedAt = n.CreatedAt.In(loc)
+	n.UpdatedAt = n.UpdatedAt.In(loc)
+	return n
+}
+
+func notesIn(notes []Note, loc *time.Location) []Note {
+	for i := range notes {
+		notes[i] = notes[i].In(loc)
+	}
+	return notes
+}
+
 func databasePostsToPosts(notes []database.Note) ([]Note, error) {
 	result := make([]Note, len(notes))
 	for i, note := range notes {
@@ -72,3 +107,67 @@ func databasePostsToPosts(notes []database.Note) ([]Note, error) {
 	}
 	return result, nil
 }
+
+type NoteRevision struct {
+	ID        string    `json:"id"`
+	NoteID    string    `json:"note_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Note      string    `json:"note"`
+}
+
+func databaseNoteRevisionsToNoteRevisions(revisions []database.NoteRevision) ([]NoteRevision, error) {
+	result := make([]NoteRevision, len(revisions))
+	for i, revision := range revisions {
+		createdAt, err := time.Parse(time.RFC3339, revision.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = NoteRevision{
+			ID:        revision.ID,
+			NoteID:    revision.NoteID,
+			CreatedAt: createdAt,
+			Note:      revision.Note,
+		}
+	}
+	return result, nil
+}
+
+type TagCount struct {
+	Tag       string `json:"tag"`
+	NoteCount int64  `json:"note_count"`
+}
+
+func databaseTagCountsToTagCounts(rows []database.GetTagCountsForUserRow) []TagCount {
+	result := make([]TagCount, len(rows))
+	for i, row := range rows {
+		result[i] = TagCount{
+			Tag:       row.Tag,
+			NoteCount: row.NoteCount,
+		}
+	}
+	return result
+}
+
+// ActivityEvent is one entry of GET /v1/activity: a note being created or
+// updated.
+type ActivityEvent struct {
+	Type      string    `json:"type"`
+	NoteID    string    `json:"note_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func databaseActivityToActivity(rows []database.GetActivityForUserRow, loc *time.Location) ([]ActivityEvent, error) {
+	result := make([]ActivityEvent, len(rows))
+	for i, row := range rows {
+		timestamp, err := time.Parse(time.RFC3339, row.OccurredAt)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = ActivityEvent{
+			Type:      row.Type,
+			NoteID:    row.NoteID,
+			Timestamp: timestamp.In(loc),
+		}
+	}
+	return result, nil
+}
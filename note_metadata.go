@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// emptyNoteMetadata is stored for notes created without metadata.
+const emptyNoteMetadata = "{}"
+
+// Metadata is meant for small extras such as a color or a pinned flag, not
+// for content, so both its encoded size and its number of keys are capped.
+const (
+	maxNoteMetadataBytes = 2 << 10
+	maxNoteMetadataKeys  = 20
+)
+
+var errNoteMetadataNotObject = errors.New("metadata must be a JSON object")
+
+// parseNoteMetadata checks a client supplied metadata value and returns the
+// form it is stored in. Objects are re-encoded compactly with sorted keys,
+// so the stored size doesn't depend on the client's formatting and an
+// unchanged value always compares equal.
+func parseNoteMetadata(raw json.RawMessage) (string, error) {
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) == 0 || trimmed[0] != '{' {
+		return "", errNoteMetadataNotObject
+	}
+	var obj map[string]any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
+		return "", errNoteMetadataNotObject
+	}
+	if len(obj) > maxNoteMetadataKeys {
+		return "", fmt.Errorf("metadata can have at most %d keys, got %d", maxNoteMetadataKeys, len(obj))
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxNoteMetadataBytes {
+		return "", fmt.Errorf("metadata must be at most %d bytes encoded, got %d", maxNoteMetadataBytes, len(data))
+	}
+	return string(data), nil
+}
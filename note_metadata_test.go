@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNotesMetadata(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/notes", user.ApiKey, map[string]any{
+		"note":     "hello",
+		"metadata": json.RawMessage(`{ "pinned": true, "color": "red" }`),
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	note := decodeResponse[Note](t, rec)
+	if got := string(note.Metadata); got != `{"color":"red","pinned":true}` {
+		t.Errorf("created metadata = %s, want it stored compactly with sorted keys", got)
+	}
+
+	if plain := createTestNote(t, h, user.ApiKey, "plain"); string(plain.Metadata) != "{}" {
+		t.Errorf("metadata without one set = %s, want {}", plain.Metadata)
+	}
+
+	rec = doRequest(t, h, http.MethodPatch, "/v1/notes/"+note.ID, user.ApiKey, map[string]any{
+		"metadata": map[string]string{"color": "blue"},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if got := decodeResponse[Note](t, rec); string(got.Metadata) != `{"color":"blue"}` || got.Note != "hello" {
+		t.Errorf("updated note = %s %q, want metadata replaced and body kept", got.Metadata, got.Note)
+	}
+
+	rec = doRequest(t, h, http.MethodPatch, "/v1/notes/"+note.ID, user.ApiKey, map[string]string{"note": "edited"})
+	if got := decodeResponse[Note](t, rec); string(got.Metadata) != `{"color":"blue"}` || got.Note != "edited" {
+		t.Errorf("note after body update = %s %q, want metadata kept", got.Metadata, got.Note)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/v1/notes/"+note.ID, user.ApiKey, nil)
+	if got := decodeResponse[Note](t, rec); string(got.Metadata) != `{"color":"blue"}` {
+		t.Errorf("GET metadata = %s, want {\"color\":\"blue\"}", got.Metadata)
+	}
+}
+
+func TestNotesMetadataRejected(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "hello")
+
+	tooManyKeys := map[string]int{}
+	for i := range maxNoteMetadataKeys + 1 {
+		tooManyKeys[fmt.Sprintf("k%d", i)] = i
+	}
+	tests := []struct {
+		name     string
+		metadata any
+		wantMsg  string
+	}{
+		{"array", json.RawMessage(`["pinned"]`), "metadata must be a JSON object"},
+		{"string", "pinned", "metadata must be a JSON object"},
+		{"null", json.RawMessage(`null`), "metadata must be a JSON object"},
+		{"too many keys", tooManyKeys, "metadata can have at most"},
+		{"too large", map[string]string{"color": strings.Repeat("r", maxNoteMetadataBytes)}, "metadata must be at most"},
+	}
+	for _, tt := range tests {
+		for _, req := range []struct {
+			method, path string
+		}{
+			{http.MethodPost, "/v1/notes"},
+			{http.MethodPatch, "/v1/notes/" + note.ID},
+		} {
+			rec := doRequest(t, h, req.method, req.path, user.ApiKey, map[string]any{"note": "x", "metadata": tt.metadata})
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("%s %s %s: status = %d, want %d", tt.name, req.method, req.path, rec.Code, http.StatusBadRequest)
+				continue
+			}
+			if msg := decodeResponse[map[string]string](t, rec)["error"]; !strings.HasPrefix(msg, tt.wantMsg) {
+				t.Errorf("%s %s %s: error = %q, want prefix %q", tt.name, req.method, req.path, msg, tt.wantMsg)
+			}
+		}
+	}
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes/"+note.ID, user.ApiKey, nil)
+	if got := decodeResponse[Note](t, rec); got.Note != "hello" || string(got.Metadata) != "{}" {
+		t.Errorf("note after rejected updates = %q %s, want it unchanged", got.Note, got.Metadata)
+	}
+}
+
+func TestNotesMetadataJSONPatch(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	note := createTestNote(t, h, user.ApiKey, "hello")
+
+	rec := doJSONPatch(t, h, note.ID, user.ApiKey, `[{"op": "add", "path": "/metadata/pinned", "value": true}]`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if got := decodeResponse[Note](t, rec); string(got.Metadata) != `{"pinned":true}` {
+		t.Errorf("patched metadata = %s, want {\"pinned\":true}", got.Metadata)
+	}
+
+	rec = doJSONPatch(t, h, note.ID, user.ApiKey, `[{"op": "replace", "path": "/metadata", "value": [1]}]`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("non-object metadata patch status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
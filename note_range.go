@@ -0,0 +1,32 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// wantsNoteRange reports whether r asks for part of a note body. Other range
+// units are ignored, as RFC 9110 requires, and get the JSON note.
+func wantsNoteRange(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Range"), "bytes=")
+}
+
+// serveNoteRange answers a byte Range request against the note's body as
+// UTF-8 text/plain, so a client can fetch part of a huge note. Offsets count
+// bytes, not characters, and may split a multi-byte character.
+// http.ServeContent writes the 206 with Content-Range, the 416 for an
+// unsatisfiable range and the full text when If-Range no longer matches.
+// The strong ETag hashes the body, since updated_at only has second
+// precision.
+func serveNoteRange(w http.ResponseWriter, r *http.Request, note database.Note) {
+	sum := sha256.Sum256([]byte(note.Note))
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	modified, _ := time.Parse(time.RFC3339, note.UpdatedAt)
+	http.ServeContent(w, r, "", modified, strings.NewReader(note.Note))
+}
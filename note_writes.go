@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+	"github.com/bootdotdev/learn-cicd-starter/internal/writebuf"
+)
+
+const (
+	noteWritePending = "pending"
+	noteWriteFailed  = "failed"
+	noteWriteDone    = "written"
+)
+
+// failedNoteWriteTTL is how long a failed write stays visible on its status
+// URL. Pending writes are kept until flushed, written ones not at all: the
+// note itself is the record.
+const failedNoteWriteTTL = 10 * time.Minute
+
+type noteWrite struct {
+	userID   string
+	state    string
+	failedAt time.Time
+}
+
+// noteWrites is the buffered-write mode of POST /v1/notes. Accepted notes
+// wait in memory and are inserted in batched transactions by a background
+// worker, so bursts cost one transaction per batch rather than per note.
+// Stopping the buffer flushes whatever is queued, but a crash loses it.
+type noteWrites struct {
+	buf *writebuf.Buffer[database.CreateNoteParams]
+
+	mu     sync.Mutex
+	writes map[string]noteWrite
+}
+
+func newNoteWrites(cfg *apiConfig, capacity int, interval time.Duration) *noteWrites {
+	nw := &noteWrites{writes: make(map[string]noteWrite)}
+	nw.buf = writebuf.New(capacity, maxBatchSize, interval, func(batch []database.CreateNoteParams) {
+		cfg.flushNotes(nw, batch)
+	})
+	return nw
+}
+
+func (nw *noteWrites) add(params database.CreateNoteParams) error {
+	nw.mu.Lock()
+	nw.writes[params.ID] = noteWrite{userID: params.UserID, state: noteWritePending}
+	nw.mu.Unlock()
+
+	err := nw.buf.Add(params)
+	if err != nil {
+		nw.mu.Lock()
+		delete(nw.writes, params.ID)
+		nw.mu.Unlock()
+	}
+	return err
+}
+
+// state returns the state of userID's write id, if it is still tracked.
+func (nw *noteWrites) state(id, userID string) (string, bool) {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+	write, ok := nw.writes[id]
+	if !ok || write.userID != userID {
+		return "", false
+	}
+	return write.state, true
+}
+
+// finish records the outcome of id and forgets expired failures.
+func (nw *noteWrites) finish(id string, err error) {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+
+	now := time.Now()
+	for other, write := range nw.writes {
+		if write.state == noteWriteFailed && now.Sub(write.failedAt) > failedNoteWriteTTL {
+			delete(nw.writes, other)
+		}
+	}
+	if err == nil {
+		delete(nw.writes, id)
+		return
+	}
+	write := nw.writes[id]
+	write.state = noteWriteFailed
+	write.failedAt = now
+	nw.writes[id] = write
+}
+
+// flushNotes inserts batch in one transaction. If that fails, it retries
+// each note on its own so that one bad row doesn't fail its neighbours.
+func (cfg *apiConfig) flushNotes(nw *noteWrites, batch []database.CreateNoteParams) {
+	ctx := context.Background()
+	var notes []database.Note
+	err := cfg.DB.InTx(ctx, func(tx store.Store) error {
+		notes = notes[:0]
+		for _, params := range batch {
+			if err := tx.CreateNote(ctx, params); err != nil {
+				return err
+			}
+			note, err := tx.GetNote(ctx, params.ID)
+			if err != nil {
+				return err
+			}
+			notes = append(notes, note)
+		}
+		return nil
+	})
+	if err == nil {
+		for _, note := range notes {
+			nw.finish(note.ID, nil)
+			cfg.NoteEvents.Publish(note)
+		}
+		return
+	}
+
+	log.Printf("Couldn't flush %d buffered notes together, retrying one by one: %s", len(batch), err)
+	for _, params := range batch {
+		err := cfg.DB.CreateNote(ctx, params)
+		nw.finish(params.ID, err)
+		if err != nil {
+			log.Printf("Couldn't write buffered note %s: %s", params.ID, err)
+			continue
+		}
+		if note, err := cfg.DB.GetNote(ctx, params.ID); err == nil {
+			cfg.NoteEvents.Publish(note)
+		}
+	}
+}
+
+// acceptNote queues a note for the next flush and answers 202, pointing at
+// its status URL.
+func (cfg *apiConfig) acceptNote(w http.ResponseWriter, params database.CreateNoteParams) {
+	type response struct {
+		ID        string `json:"id"`
+		Status    string `json:"status"`
+		StatusURL string `json:"status_url"`
+	}
+
+	err := cfg.NoteWrites.add(params)
+	if errors.Is(err, writebuf.ErrFull) || errors.Is(err, writebuf.ErrClosed) {
+		w.Header().Set("Retry-After", "1")
+		respondWithJSON(w, http.StatusServiceUnavailable, errorResponse{
+			Error: "Note write buffer is full",
+			Code:  errCodeServerBusy,
+		})
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't queue note", err)
+		return
+	}
+
+	statusURL := "/v1/notes/writes/" + params.ID
+	w.Header().Set("Location", statusURL)
+	respondWithJSON(w, http.StatusAccepted, response{
+		ID:        params.ID,
+		Status:    noteWritePending,
+		StatusURL: statusURL,
+	})
+}
+
+// handlerNoteWriteGet reports whether a buffered note has been written. It
+// reads the primary, since a replica may not have the note yet.
+func (cfg *apiConfig) handlerNoteWriteGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	type response struct {
+		ID      string `json:"id"`
+		Status  string `json:"status"`
+		NoteURL string `json:"note_url,omitempty"`
+	}
+
+	id, ok := parseUUIDParam(w, r, "noteID")
+	if !ok {
+		return
+	}
+	state, ok := cfg.NoteWrites.state(id, user.ID)
+	if !ok {
+		_, err := getOwnedNote(r.Context(), cfg.DB, id, user.ID)
+		if errors.Is(err, errNoteNotFound) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find note write", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get note write", err)
+			return
+		}
+		state = noteWriteDone
+	}
+
+	resp := response{ID: id, Status: state}
+	if state == noteWriteDone {
+		resp.NoteURL = "/v1/notes/" + id
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+	"github.com/google/uuid"
+)
+
+// txCountingStore counts the transactions run against it.
+type txCountingStore struct {
+	store.Store
+	txs int
+}
+
+func (s *txCountingStore) InTx(ctx context.Context, fn func(store.Store) error) error {
+	s.txs++
+	return s.Store.InTx(ctx, fn)
+}
+
+func newBufferedRouter(t *testing.T, interval time.Duration, opts ...func(*apiConfig)) (http.Handler, *apiConfig) {
+	t.Helper()
+	var cfg *apiConfig
+	h, _ := newTestRouter(t, append(opts, func(c *apiConfig) {
+		c.NoteWrites = newNoteWrites(c, 10, interval)
+		cfg = c
+	})...)
+	return h, cfg
+}
+
+func noteWriteStatus(t *testing.T, h http.Handler, apiKey, id string) string {
+	t.Helper()
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes/writes/"+id, apiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET status URL = %d, want %d", rec.Code, http.StatusOK)
+	}
+	return decodeResponse[struct {
+		Status string `json:"status"`
+	}](t, rec).Status
+}
+
+func TestNoteWritesAccepted(t *testing.T) {
+	h, _ := newBufferedRouter(t, time.Hour)
+	alice := createTestUser(t, h, "alice")
+	bob := createTestUser(t, h, "bob")
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/notes", alice.ApiKey, map[string]string{"note": "queued"})
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("POST status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	body := decodeResponse[struct {
+		ID        string `json:"id"`
+		Status    string `json:"status"`
+		StatusURL string `json:"status_url"`
+	}](t, rec)
+	if body.Status != noteWritePending || body.StatusURL != "/v1/notes/writes/"+body.ID || rec.Header().Get("Location") != body.StatusURL {
+		t.Errorf("POST = %+v, Location %q; want pending with matching status URL", body, rec.Header().Get("Location"))
+	}
+
+	if got := noteWriteStatus(t, h, alice.ApiKey, body.ID); got != noteWritePending {
+		t.Errorf("status = %q, want %q", got, noteWritePending)
+	}
+	if notes := listNotes(t, h, alice.ApiKey); len(notes) != 0 {
+		t.Errorf("listed %d notes before the flush, want 0", len(notes))
+	}
+	if rec := doRequest(t, h, http.MethodGet, body.StatusURL, bob.ApiKey, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("other user's status URL = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestNoteWritesBatchedFlush(t *testing.T) {
+	var counter *txCountingStore
+	h, cfg := newBufferedRouter(t, 10*time.Millisecond, func(cfg *apiConfig) {
+		counter = &txCountingStore{Store: cfg.DB}
+		cfg.DB = counter
+	})
+	user := createTestUser(t, h, "alice")
+
+	var ids []string
+	for _, body := range []string{"one", "two", "three"} {
+		rec := doRequest(t, h, http.MethodPost, "/v1/notes", user.ApiKey, map[string]string{"note": body})
+		ids = append(ids, decodeResponse[struct {
+			ID string `json:"id"`
+		}](t, rec).ID)
+	}
+	cfg.NoteWrites.buf.Start(context.Background())
+	t.Cleanup(func() { cfg.NoteWrites.buf.Stop(context.Background()) })
+
+	deadline := time.Now().Add(time.Second)
+	for noteWriteStatus(t, h, user.ApiKey, ids[2]) != noteWriteDone {
+		if time.Now().After(deadline) {
+			t.Fatal("buffered notes were never flushed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if notes := listNotes(t, h, user.ApiKey); len(notes) != 3 {
+		t.Errorf("listed %d notes after the flush, want 3", len(notes))
+	}
+	if counter.txs != 1 {
+		t.Errorf("flush ran %d transactions, want 1", counter.txs)
+	}
+}
+
+func TestNoteWritesFlushOnShutdown(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	// The batch transaction fails, so the flush falls back to one insert
+	// per note.
+	h, cfg := newBufferedRouter(t, time.Hour, func(cfg *apiConfig) {
+		cfg.DB = &flakyCreateStore{Store: cfg.DB}
+	})
+	user := createTestUser(t, h, "alice")
+	for _, body := range []string{"one", "two", "three"} {
+		doRequest(t, h, http.MethodPost, "/v1/notes", user.ApiKey, map[string]string{"note": body})
+	}
+
+	cfg.NoteWrites.buf.Start(context.Background())
+	if err := cfg.NoteWrites.buf.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop = %v", err)
+	}
+	if notes := listNotes(t, h, user.ApiKey); len(notes) != 3 {
+		t.Errorf("listed %d notes after shutdown, want 3", len(notes))
+	}
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/notes", user.ApiKey, map[string]string{"note": "late"})
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("POST after shutdown = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestNoteWriteStatusLookupError(t *testing.T) {
+	busy := &busyNoteStore{noteID: uuid.NewString()}
+	h, _ := newBufferedRouter(t, time.Hour, func(cfg *apiConfig) {
+		busy.Store = cfg.DB
+		cfg.DB = busy
+	})
+	user := createTestUser(t, h, "alice")
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes/writes/"+busy.noteID, user.ApiKey, nil)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status URL with a busy lookup = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
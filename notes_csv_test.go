@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotesListCSV(t *testing.T) {
+	// A page size below the note count makes the export span several
+	// store queries, and the timeout must not buffer it.
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.MaxPageSize = 2
+		cfg.RequestTimeout = time.Minute
+	})
+	user := createTestUser(t, h, "alice")
+	for _, body := range []string{
+		"plain",
+		"one, two, three",
+		`she said "hi"`,
+		"line one\nline two",
+		`"quoted, with comma"`,
+	} {
+		createTestNote(t, h, user.ApiKey, body)
+	}
+	want := [][]string{{"id", "created_at", "updated_at", "body"}}
+	for _, note := range listNotes(t, h, user.ApiKey) {
+		want = append(want, []string{note.ID, note.CreatedAt.Format(time.RFC3339), note.UpdatedAt.Format(time.RFC3339), note.Note})
+	}
+
+	for _, tt := range []struct {
+		name   string
+		path   string
+		accept string
+	}{
+		{"format param", "/v1/notes?format=csv", ""},
+		{"accept header", "/v1/notes", "application/json;q=0.5, text/csv"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/csv") {
+				t.Errorf("Content-Type = %q, want text/csv", got)
+			}
+			if !rec.Flushed {
+				t.Error("CSV export was never flushed, want it streamed")
+			}
+			if !strings.Contains(rec.Body.String(), `"she said ""hi"""`) {
+				t.Errorf("body quotes aren't doubled in:\n%s", rec.Body)
+			}
+
+			got, err := csv.NewReader(rec.Body).ReadAll()
+			if err != nil {
+				t.Fatalf("parsing CSV: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("CSV rows = %q, want %q", got, want)
+			}
+		})
+	}
+
+	if rec := doRequest(t, h, http.MethodGet, "/v1/notes?format=csv&limit=1", user.ApiKey, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("CSV with limit status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type pagination struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// parsePagination reads the optional limit and offset query parameters.
+// Omitted values fall back to the defaults and limits above the configured
+// maximum are clamped to it. Values that aren't integers, a non-positive
+// limit, a negative offset, or either given twice are rejected.
+func (cfg *apiConfig) parsePagination(r *http.Request) (pagination, error) {
+	page := pagination{Limit: cfg.DefaultPageSize}
+	query := r.URL.Query()
+	v, err := queryValue(query, "limit")
+	if err != nil {
+		return pagination{}, err
+	}
+	if v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return pagination{}, fmt.Errorf("limit must be a positive integer, got %q", v)
+		}
+		page.Limit = min(limit, cfg.MaxPageSize)
+	}
+	if v, err = queryValue(query, "offset"); err != nil {
+		return pagination{}, err
+	}
+	if v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return pagination{}, fmt.Errorf("offset must be a non-negative integer, got %q", v)
+		}
+		page.Offset = offset
+	}
+	return page, nil
+}
+
+// setPaginationHeaders reports the limit and offset actually applied, also
+// in the response meta when the response is enveloped.
+func setPaginationHeaders(w http.ResponseWriter, page pagination) {
+	w.Header().Set("X-Pagination-Limit", strconv.Itoa(page.Limit))
+	w.Header().Set("X-Pagination-Offset", strconv.Itoa(page.Offset))
+	setResponseMeta(w, "pagination", page)
+}
+
+// setLinkHeader adds RFC 8288 (formerly RFC 5988) first, prev, next and last
+// links for a list of total items. The links keep the request's other query
+// parameters and only rewrite limit and offset.
+func setLinkHeader(w http.ResponseWriter, r *http.Request, page pagination, total int64) {
+	link := func(offset int, rel string) string {
+		query := r.URL.Query()
+		query.Set("limit", strconv.Itoa(page.Limit))
+		query.Set("offset", strconv.Itoa(offset))
+		u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+		return fmt.Sprintf("<%s>; rel=%q", u.String(), rel)
+	}
+
+	last := 0
+	if total > 0 {
+		last = int(total-1) / page.Limit * page.Limit
+	}
+	links := []string{link(0, "first")}
+	if page.Offset > 0 {
+		links = append(links, link(max(min(page.Offset-page.Limit, last), 0), "prev"))
+	}
+	if int64(page.Offset+page.Limit) < total {
+		links = append(links, link(page.Offset+page.Limit, "next"))
+	}
+	links = append(links, link(last, "last"))
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
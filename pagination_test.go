@@ -0,0 +1,156 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/testutil"
+)
+
+func TestNotesListPagination(t *testing.T) {
+	h, db := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.DefaultPageSize = 2
+		cfg.MaxPageSize = 3
+	})
+	user := testutil.SeedUser(t, db, "alice")
+	testutil.SeedNotes(t, db, user.ID, 5)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantLimit string
+		wantNotes []string
+	}{
+		{"configured default", "", "2", []string{"note 4", "note 3"}},
+		{"within max", "?limit=3", "3", []string{"note 4", "note 3", "note 2"}},
+		{"clamped to max", "?limit=50", "3", []string{"note 4", "note 3", "note 2"}},
+		{"with offset", "?limit=2&offset=3", "2", []string{"note 1", "note 0"}},
+		{"offset past end", "?offset=10", "2", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, h, http.MethodGet, "/v1/notes"+tt.query, user.ApiKey, nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			if got := rec.Header().Get("X-Pagination-Limit"); got != tt.wantLimit {
+				t.Errorf("X-Pagination-Limit = %q, want %q", got, tt.wantLimit)
+			}
+			notes := decodeResponse[[]Note](t, rec)
+			if len(notes) != len(tt.wantNotes) {
+				t.Fatalf("got %d notes, want %d", len(notes), len(tt.wantNotes))
+			}
+			for i, note := range notes {
+				if note.Note != tt.wantNotes[i] {
+					t.Errorf("notes[%d] = %q, want %q", i, note.Note, tt.wantNotes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNotesListRejectsInvalidPagination(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+
+	tests := []struct {
+		query     string
+		wantParam string
+	}{
+		{"?limit=ten", "limit"},
+		{"?limit=-1", "limit"},
+		{"?limit=0", "limit"},
+		{"?offset=abc", "offset"},
+		{"?offset=-5", "offset"},
+		{"?limit=5&offset=1.5", "offset"},
+		{"?limit=10&limit=20", "limit"},
+		{"?limit=10&limit=10", "limit"},
+		{"?offset=0&offset=5", "offset"},
+	}
+	for _, tt := range tests {
+		rec := doRequest(t, h, http.MethodGet, "/v1/notes"+tt.query, user.ApiKey, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET /v1/notes%s status = %d, want %d", tt.query, rec.Code, http.StatusBadRequest)
+			continue
+		}
+		if msg := decodeResponse[map[string]string](t, rec)["error"]; !strings.HasPrefix(msg, tt.wantParam+" ") {
+			t.Errorf("GET /v1/notes%s error = %q, want it to name %s", tt.query, msg, tt.wantParam)
+		}
+	}
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes", user.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /v1/notes without pagination status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+// parseLinks maps each rel in a Link header to its URL.
+func parseLinks(t *testing.T, header string) map[string]string {
+	t.Helper()
+	links := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		rel, found := strings.CutPrefix(strings.TrimSpace(params), "rel=")
+		if !ok || !found || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			t.Fatalf("malformed link %q in %q", part, header)
+		}
+		links[strings.Trim(rel, `"`)] = strings.Trim(target, "<>")
+	}
+	return links
+}
+
+func TestNotesListLinkHeader(t *testing.T) {
+	h, db := newTestRouter(t)
+	user := testutil.SeedUser(t, db, "alice")
+	testutil.SeedNotes(t, db, user.ID, 5)
+
+	var got []string
+	path := "/v1/notes?limit=2&hasTags=false"
+	for pages := 0; path != ""; pages++ {
+		if pages > 5 {
+			t.Fatal("next links never ran out")
+		}
+		rec := doRequest(t, h, http.MethodGet, path, user.ApiKey, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want %d", path, rec.Code, http.StatusOK)
+		}
+		for _, note := range decodeResponse[[]Note](t, rec) {
+			got = append(got, note.Note)
+		}
+
+		links := parseLinks(t, rec.Header().Get("Link"))
+		if links["first"] != "/v1/notes?hasTags=false&limit=2&offset=0" {
+			t.Errorf("GET %s: first = %q", path, links["first"])
+		}
+		if links["last"] != "/v1/notes?hasTags=false&limit=2&offset=4" {
+			t.Errorf("GET %s: last = %q", path, links["last"])
+		}
+		if _, ok := links["prev"]; ok != (pages > 0) {
+			t.Errorf("GET %s: prev = %q on page %d", path, links["prev"], pages)
+		}
+		path = links["next"]
+	}
+
+	want := []string{"note 4", "note 3", "note 2", "note 1", "note 0"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("followed next through %q, want %q", got, want)
+	}
+}
+
+func TestSearchLinkHeaderCountsMatches(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	for _, body := range []string{"apple", "banana", "apricot", "cherry"} {
+		createTestNote(t, h, user.ApiKey, body)
+	}
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/notes/search?q=ap&limit=1", user.ApiKey, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	links := parseLinks(t, rec.Header().Get("Link"))
+	if links["next"] != "/v1/notes/search?limit=1&offset=1&q=ap" || links["last"] != "/v1/notes/search?limit=1&offset=1&q=ap" {
+		t.Errorf("links = %v, want next and last at offset 1", links)
+	}
+}
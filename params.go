@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// errRouteParamMissing means a handler asked for a URL parameter its route
+// doesn't declare, usually because the pattern was renamed.
+var errRouteParamMissing = errors.New("route has no such URL parameter")
+
+// urlParam returns the named URL parameter. chi.URLParam returns "" both for
+// an empty value and for a name the route never declared; urlParam answers
+// the first with a 400 and treats the second as the bug it is, logging the
+// route pattern and responding with a 500. Either way it returns false.
+func urlParam(w http.ResponseWriter, r *http.Request, name string) (string, bool) {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || !slices.Contains(rctx.URLParams.Keys, name) {
+		pattern := ""
+		if rctx != nil {
+			pattern = rctx.RoutePattern()
+		}
+		err := fmt.Errorf("%w: %s %q wants {%s}", errRouteParamMissing, r.Method, pattern, name)
+		log.Printf("BUG: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't read URL parameter", err)
+		return "", false
+	}
+	value := rctx.URLParam(name)
+	if value == "" {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Missing %s", name), nil)
+		return "", false
+	}
+	return value, true
+}
+
+// parseUUIDParam returns the named URL parameter in canonical UUID form.
+// If the parameter isn't a valid UUID it responds with a 400 and returns false.
+func parseUUIDParam(w http.ResponseWriter, r *http.Request, name string) (string, bool) {
+	raw, ok := urlParam(w, r, name)
+	if !ok {
+		return "", false
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s: must be a UUID", name), err)
+		return "", false
+	}
+	return id.String(), true
+}
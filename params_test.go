@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+)
+
+func TestURLParam(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	router := chi.NewRouter()
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if v, ok := urlParam(w, r, name); ok {
+				respondWithJSON(w, http.StatusOK, map[string]string{name: v})
+			}
+		}
+	}
+	router.Get("/things/{thingID}/name", handler("thingID"))
+	// The route was renamed but the handler still reads the old name.
+	router.Get("/widgets/{widgetID}/name", handler("thingID"))
+
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantLog  bool
+	}{
+		{"present", "/things/abc/name", http.StatusOK, false},
+		{"present but empty", "/things//name", http.StatusBadRequest, false},
+		{"not in route", "/widgets/abc/name", http.StatusInternalServerError, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			logged := strings.Contains(logs.String(), "BUG:") && strings.Contains(logs.String(), "/widgets/{widgetID}/name")
+			if logged != tt.wantLog {
+				t.Errorf("logged bug = %v, want %v; logs: %s", logged, tt.wantLog, logs.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+)
+
+const problemMediaType = "application/problem+json"
+
+// problemDetails is an RFC 7807 error body. Code, Fields and RetryAfter are
+// extension members carrying what errorResponse and the Retry-After header
+// would otherwise convey.
+type problemDetails struct {
+	Type       string          `json:"type"`
+	Title      string          `json:"title"`
+	Status     int             `json:"status"`
+	Detail     string          `json:"detail,omitempty"`
+	Instance   string          `json:"instance,omitempty"`
+	Code       string          `json:"code,omitempty"`
+	Fields     validate.Errors `json:"fields,omitempty"`
+	RetryAfter int             `json:"retry_after,omitempty"`
+}
+
+// problemWriter marks a request whose error responses are written as
+// problem details rather than errorResponse.
+type problemWriter struct {
+	http.ResponseWriter
+	instance string
+}
+
+// problem converts e, sent with status, into problem details. Errors carry
+// no documented type URI, so Type is "about:blank" and Title the status
+// text, as RFC 7807 prescribes for that case.
+func (w *problemWriter) problem(status int, e errorResponse) problemDetails {
+	p := problemDetails{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   e.Error,
+		Instance: w.instance,
+		Code:     e.Code,
+		Fields:   e.Fields,
+	}
+	if secs, err := strconv.Atoi(w.Header().Get("Retry-After")); err == nil {
+		p.RetryAfter = secs
+	}
+	return p
+}
+
+func (w *problemWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *problemWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// problemWriterFor finds the problemWriter under w, looking through the
+// envelope and any other writer that unwraps. http.TimeoutHandler's writer
+// doesn't, so middlewareTimeout re-wraps it, see withResponseModes.
+func problemWriterFor(w http.ResponseWriter) (*problemWriter, bool) {
+	for {
+		switch v := w.(type) {
+		case *problemWriter:
+			return v, true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
+
+// middlewareProblem switches error responses to application/problem+json
+// when ProblemDetails is set or the client lists that media type in Accept.
+// The instance member is the request ID as a urn:uuid URI, so a report can
+// be matched to the X-Request-ID in the logs. Problem details replace the
+// envelope for errors; successful responses are unaffected.
+func (cfg *apiConfig) middlewareProblem(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !wantsProblem(r, cfg.ProblemDetails) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		pw := &problemWriter{ResponseWriter: w}
+		if id := requestIDFromContext(r.Context()); id != "" {
+			pw.instance = "urn:uuid:" + id
+		}
+		next.ServeHTTP(pw, r)
+	})
+}
+
+func wantsProblem(r *http.Request, fallback bool) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(accept)
+		if err == nil && mediaType == problemMediaType {
+			return true
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/ratelimit"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+func TestProblemDetails(t *testing.T) {
+	get := func(t *testing.T, h http.Handler, path, accept string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("plain by default", func(t *testing.T) {
+		h, _ := newTestRouter(t)
+		rec := get(t, h, "/v1/notes", "")
+		if got := rec.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+		if body := decodeResponse[errorResponse](t, rec); body.Error == "" {
+			t.Errorf("body = %+v, want an error message", body)
+		}
+	})
+
+	for _, tt := range []struct {
+		name   string
+		accept string
+		opts   []func(*apiConfig)
+	}{
+		{"requested via Accept", "application/problem+json, application/json;q=0.5", nil},
+		{"enabled in config", "", []func(*apiConfig){func(cfg *apiConfig) { cfg.ProblemDetails = true }}},
+		{"replaces the envelope", "application/problem+json", []func(*apiConfig){func(cfg *apiConfig) { cfg.ResponseEnvelope = true }}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestRouter(t, tt.opts...)
+			rec := get(t, h, "/v1/notes", tt.accept)
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+			if got := rec.Header().Get("Content-Type"); got != problemMediaType {
+				t.Errorf("Content-Type = %q, want %q", got, problemMediaType)
+			}
+			body := decodeResponse[map[string]any](t, rec)
+			want := map[string]any{
+				"type":     "about:blank",
+				"title":    "Unauthorized",
+				"status":   float64(http.StatusUnauthorized),
+				"detail":   "Couldn't find api key",
+				"instance": "urn:uuid:" + rec.Header().Get("X-Request-ID"),
+			}
+			for k, v := range want {
+				if body[k] != v {
+					t.Errorf("%s = %v, want %v", k, body[k], v)
+				}
+			}
+			if _, ok := body["data"]; ok {
+				t.Errorf("body = %v, want no envelope", body)
+			}
+		})
+	}
+
+	t.Run("extension members", func(t *testing.T) {
+		h, _ := newTestRouter(t)
+		user := createTestUser(t, h, "alice")
+		req := httptest.NewRequest(http.MethodPost, "/v1/notes", nil)
+		req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", problemMediaType)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		body := decodeResponse[problemDetails](t, rec)
+		if body.Status != http.StatusBadRequest || body.Code != errCodeBodyRequired {
+			t.Errorf("problem = %+v, want status 400 and code %q", body, errCodeBodyRequired)
+		}
+	})
+
+	t.Run("retry after", func(t *testing.T) {
+		h, _ := newTestRouter(t, func(cfg *apiConfig) {
+			cfg.RateLimiter = ratelimit.New(1, time.Minute)
+		})
+		user := createTestUser(t, h, "alice")
+		var rec *httptest.ResponseRecorder
+		for range 2 {
+			req := httptest.NewRequest(http.MethodGet, "/v1/notes", nil)
+			req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+			req.Header.Set("Accept", problemMediaType)
+			rec = httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+		}
+		if rec.Code != http.StatusTooManyRequests {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+		}
+		body := decodeResponse[problemDetails](t, rec)
+		if body.RetryAfter <= 0 || strconv.Itoa(body.RetryAfter) != rec.Header().Get("Retry-After") {
+			t.Errorf("retry_after = %d, want Retry-After %q", body.RetryAfter, rec.Header().Get("Retry-After"))
+		}
+	})
+
+	t.Run("successes untouched", func(t *testing.T) {
+		h, _ := newTestRouter(t)
+		rec := get(t, h, "/v1/healthz", problemMediaType)
+		if got := rec.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+	})
+}
+
+func TestProblemDetailsRequestTimeout(t *testing.T) {
+	for _, timeout := range []time.Duration{0, 30 * time.Second} {
+		t.Run(timeout.String(), func(t *testing.T) {
+			h, _ := newTestRouter(t, func(cfg *apiConfig) {
+				cfg.RequestTimeout = timeout
+			})
+			req := httptest.NewRequest(http.MethodGet, "/v1/notes", nil)
+			req.Header.Set("Accept", problemMediaType)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if got := rec.Header().Get("Content-Type"); got != problemMediaType {
+				t.Errorf("Content-Type = %q, want %q", got, problemMediaType)
+			}
+			if body := decodeResponse[problemDetails](t, rec); body.Status != http.StatusUnauthorized {
+				t.Errorf("problem = %+v, want status 401", body)
+			}
+		})
+	}
+
+	// The timeout's own 503 is written past the handler, so it stays plain.
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.RequestTimeout = 10 * time.Millisecond
+		cfg.DB = store.WithTimeout(slowNotesStore{cfg.DB}, time.Minute)
+	})
+	user := createTestUser(t, h, "alice")
+	req := httptest.NewRequest(http.MethodGet, "/v1/notes", nil)
+	req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+	req.Header.Set("Accept", problemMediaType)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("timed out request = %d %s, want a plain JSON 503", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
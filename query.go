@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// queryValue returns the value of the query parameter name, or "" if it's
+// absent. Parameters that pick a page or window must be unambiguous, so
+// one given more than once, as in ?limit=10&limit=20, is an error rather
+// than whichever value url.Values.Get happens to return.
+func queryValue(query url.Values, name string) (string, error) {
+	values := query[name]
+	if len(values) > 1 {
+		return "", fmt.Errorf("%s must be given at most once, got %d values", name, len(values))
+	}
+	if len(values) == 0 {
+		return "", nil
+	}
+	return values[0], nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDuplicateQueryParamsRejected(t *testing.T) {
+	h, _ := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+
+	note := createTestNote(t, h, user.ApiKey, "hello")
+	batch := map[string]any{"notes": []map[string]string{{"note": "one"}}}
+
+	tests := []struct {
+		method    string
+		path      string
+		body      any
+		wantParam string
+	}{
+		{http.MethodGet, "/v1/notes?createdAfter=2024-01-01T00:00:00Z&createdAfter=2025-01-01T00:00:00Z", nil, "createdAfter"},
+		{http.MethodGet, "/v1/notes?createdBefore=2024-01-01T00:00:00Z&createdBefore=2024-01-01T00:00:00Z", nil, "createdBefore"},
+		{http.MethodGet, "/v1/notes?hasTags=true&hasTags=false", nil, "hasTags"},
+		{http.MethodGet, "/v1/notes?wait=1s&wait=2s", nil, "wait"},
+		{http.MethodGet, "/v1/notes?tz=UTC&tz=Europe/Paris", nil, "tz"},
+		{http.MethodGet, "/v1/notes?fields=id&fields=note", nil, "fields"},
+		{http.MethodGet, "/v1/notes?format=csv&format=json", nil, "format"},
+		{http.MethodGet, "/v1/notes/" + note.ID + "?render=html&render=html", nil, "render"},
+		{http.MethodGet, "/v1/notes/search?q=a&limit=1&limit=2", nil, "limit"},
+		{http.MethodGet, "/v1/notes/search?q=a&q=b", nil, "q"},
+		{http.MethodGet, "/v1/notes/search/count?q=a&q=b", nil, "q"},
+		{http.MethodGet, "/v1/notes/search?q=a&scroll=true&limit=5&limit=6", nil, "limit"},
+		{http.MethodGet, "/v1/notes/search?q=a&q=b&scroll=true", nil, "q"},
+		{http.MethodGet, "/v1/notes/search?q=a&scroll=true&scroll=true", nil, "scroll"},
+		{http.MethodGet, "/v1/notes/search?q=a&scrollToken=x&scrollToken=y", nil, "scrollToken"},
+		{http.MethodPost, "/v1/notes/batch?partial=true&partial=false", batch, "partial"},
+	}
+	for _, tt := range tests {
+		rec := doRequest(t, h, tt.method, tt.path, user.ApiKey, tt.body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, rec.Code, http.StatusBadRequest)
+			continue
+		}
+		if msg := decodeResponse[map[string]string](t, rec)["error"]; !strings.HasPrefix(msg, tt.wantParam+" must be given at most once") {
+			t.Errorf("%s %s error = %q, want it to reject repeated %s", tt.method, tt.path, msg, tt.wantParam)
+		}
+	}
+}
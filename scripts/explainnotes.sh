@@ -0,0 +1,31 @@
+#!/bin/bash
+
+# Builds a scratch database from the up migrations and checks that the
+# per-user note list queries are served by an index with no sort step.
+# Needs the sqlite3 CLI.
+
+set -e
+
+db=$(mktemp)
+trap 'rm -f "$db"' EXIT
+
+for f in sql/schema/*.sql; do
+    sed -n '/+goose Up/,/+goose Down/p' "$f" | grep -v goose | sqlite3 "$db"
+done
+
+status=0
+while read -r query; do
+    plan=$(sqlite3 "$db" "EXPLAIN QUERY PLAN $query")
+    echo "$query"
+    echo "$plan"
+    if ! grep -q 'USING INDEX notes_user_id' <<<"$plan" || grep -q 'TEMP B-TREE' <<<"$plan"; then
+        echo "FAIL: not served by a notes_user_id index in order"
+        status=1
+    fi
+done <<'EOF'
+SELECT * FROM notes WHERE user_id = 'u' ORDER BY created_at DESC, rowid DESC LIMIT 10;
+SELECT * FROM notes WHERE user_id = 'u' AND note LIKE '%a%' ESCAPE '\' ORDER BY created_at DESC, rowid DESC LIMIT 10 OFFSET 0;
+SELECT * FROM notes WHERE user_id = 'u' AND (created_at < 'x' OR (created_at = 'x' AND rowid < 5)) ORDER BY created_at DESC, rowid DESC LIMIT 10;
+SELECT * FROM notes WHERE user_id = 'u' ORDER BY is_pinned DESC, created_at DESC, rowid DESC LIMIT 10;
+EOF
+exit $status
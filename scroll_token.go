@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// scrollTokenTTL bounds how long a search scroll can be resumed, so clients
+// don't hold cursors into data that has long since changed.
+const scrollTokenTTL = 15 * time.Minute
+
+var errInvalidScrollToken = errors.New("invalid or expired scroll token")
+
+// scrollToken is the keyset position after the last note of a scroll page.
+// Tokens aren't signed: a forged one can only move the caller's own cursor.
+// Query ties the token to the search it was issued for.
+type scrollToken struct {
+	CreatedAt string `json:"c"`
+	Rowid     int64  `json:"r"`
+	Query     string `json:"q"`
+	Expires   int64  `json:"e"`
+}
+
+func encodeScrollToken(tok scrollToken) string {
+	data, _ := json.Marshal(tok)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeScrollToken parses s and checks that it belongs to query and hasn't
+// expired.
+func decodeScrollToken(s, query string, now time.Time) (scrollToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return scrollToken{}, errInvalidScrollToken
+	}
+	var tok scrollToken
+	if err := json.Unmarshal(data, &tok); err != nil || tok.CreatedAt == "" || tok.Query != query {
+		return scrollToken{}, errInvalidScrollToken
+	}
+	if now.Unix() >= tok.Expires {
+		return scrollToken{}, errInvalidScrollToken
+	}
+	return tok, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+// selfTestNote is the body of the probe note selfTest writes.
+const selfTestNote = "startup self-test"
+
+// selfTest runs the write path end to end before the server accepts
+// traffic: it creates a probe user and a note for it, reads the note back,
+// then deletes the user and checks that the note went with it. A ping
+// passes on a database the app can't write to, or whose schema is behind;
+// this doesn't. The probe user is throwaway rather than a standing system
+// account, so no extra API key is ever left in the database.
+func (cfg *apiConfig) selfTest(ctx context.Context) (err error) {
+	userID, err := cfg.newID()
+	if err != nil {
+		return err
+	}
+	noteID, err := cfg.newID()
+	if err != nil {
+		return err
+	}
+	apiKey, err := cfg.newAPIKey()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	name := "self-test-" + userID
+	err = cfg.DB.CreateUser(ctx, database.CreateUserParams{
+		ID:             userID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Name:           name,
+		ApiKey:         apiKey,
+		NameNormalized: normalizeUserName(name),
+	})
+	if err != nil {
+		return fmt.Errorf("creating probe user: %w", err)
+	}
+	deleted := false
+	defer func() {
+		if deleted {
+			return
+		}
+		if delErr := cfg.deleteProbeUser(ctx, userID); delErr != nil {
+			err = errors.Join(err, fmt.Errorf("deleting probe user %s: %w", userID, delErr))
+		}
+	}()
+
+	err = cfg.DB.CreateNote(ctx, database.CreateNoteParams{
+		ID:        noteID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Note:      selfTestNote,
+		UserID:    userID,
+		Metadata:  "{}",
+	})
+	if err != nil {
+		return fmt.Errorf("creating probe note: %w", err)
+	}
+	note, err := cfg.DB.GetNote(ctx, noteID)
+	if err != nil {
+		return fmt.Errorf("reading probe note: %w", err)
+	}
+	if note.Note != selfTestNote || note.UserID != userID {
+		return fmt.Errorf("probe note read back as %q for user %s", note.Note, note.UserID)
+	}
+
+	deleted = true
+	if err := cfg.deleteProbeUser(ctx, userID); err != nil {
+		return fmt.Errorf("deleting probe user %s: %w", userID, err)
+	}
+	if _, err := cfg.DB.GetNote(ctx, noteID); !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("probe note %s survived its user's deletion: %v", noteID, err)
+	}
+	return nil
+}
+
+// deleteProbeUser deletes the probe user and its note the way DELETE
+// /v1/users/me does.
+func (cfg *apiConfig) deleteProbeUser(ctx context.Context, userID string) error {
+	return cfg.DB.InTx(ctx, func(tx store.Store) error { return deleteAccount(ctx, tx, userID) })
+}
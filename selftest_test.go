@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+	"github.com/bootdotdev/learn-cicd-starter/internal/testutil"
+)
+
+// probeRecordingStore remembers the last user and note created through it.
+type probeRecordingStore struct {
+	store.Store
+	userID string
+	noteID string
+}
+
+func (s *probeRecordingStore) CreateUser(ctx context.Context, arg database.CreateUserParams) error {
+	s.userID = arg.ID
+	return s.Store.CreateUser(ctx, arg)
+}
+
+func (s *probeRecordingStore) CreateNote(ctx context.Context, arg database.CreateNoteParams) error {
+	s.noteID = arg.ID
+	return s.Store.CreateNote(ctx, arg)
+}
+
+func TestSelfTest(t *testing.T) {
+	db := &probeRecordingStore{Store: testutil.NewStore(t)}
+	cfg := &apiConfig{DB: db}
+	if err := cfg.selfTest(context.Background()); err != nil {
+		t.Fatalf("selfTest = %v", err)
+	}
+	if db.userID == "" || db.noteID == "" {
+		t.Fatalf("selfTest created user %q and note %q, want both", db.userID, db.noteID)
+	}
+	if _, err := db.GetUserByID(context.Background(), db.userID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("probe user lookup = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := db.GetNote(context.Background(), db.noteID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("probe note lookup = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestSelfTestFailureCleansUp(t *testing.T) {
+	db := &probeRecordingStore{Store: &flakyCreateStore{Store: testutil.NewStore(t), calls: 1}}
+	cfg := &apiConfig{DB: db}
+	if err := cfg.selfTest(context.Background()); err == nil {
+		t.Fatal("selfTest succeeded with a failing CreateNote, want an error")
+	}
+	if _, err := db.GetUserByID(context.Background(), db.userID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("probe user lookup after failure = %v, want sql.ErrNoRows", err)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// serverTimeouts bounds each phase of a connection so slow clients can't
+// hold it open indefinitely.
+type serverTimeouts struct {
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+}
+
+func serverTimeoutsFromEnv() serverTimeouts {
+	return serverTimeouts{
+		ReadHeader: envDuration("READ_HEADER_TIMEOUT", 10*time.Second),
+		Read:       envDuration("READ_TIMEOUT", 30*time.Second),
+		Write:      envDuration("WRITE_TIMEOUT", 60*time.Second),
+		Idle:       envDuration("IDLE_TIMEOUT", 120*time.Second),
+	}
+}
+
+// withWriteTimeout overrides the server's WriteTimeout for one route,
+// either way, counting from when the handler starts. A timeout of zero
+// removes the deadline. It needs every ResponseWriter wrapper above it to
+// support Unwrap; behind http.TimeoutHandler it can't reach the connection
+// and the server's timeout stays, which is harmless as that buffers the
+// response anyway.
+func withWriteTimeout(timeout time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var deadline time.Time
+		if timeout > 0 {
+			deadline = time.Now().Add(timeout)
+		}
+		err := http.NewResponseController(w).SetWriteDeadline(deadline)
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			log.Printf("Error setting write deadline for %s: %s", r.URL.Path, err)
+		}
+		next(w, r)
+	}
+}
+
+// withNoteListWriteTimeout is withWriteTimeout for the CSV exports and long
+// polls of the note list only. Plain pages keep the server's WriteTimeout.
+func withNoteListWriteTimeout(timeout time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	long := withWriteTimeout(timeout, next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if wantsCSV(r) || r.URL.Query().Has("wait") {
+			long(w, r)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func newServer(addr string, handler http.Handler, timeouts serverTimeouts, maxHeaderBytes int) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: timeouts.ReadHeader,
+		ReadTimeout:       timeouts.Read,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+		MaxHeaderBytes:    maxHeaderBytes,
+	}
+}
+
+// serverTLS names the certificate and key the server terminates TLS with.
+// Both are empty when TLS is left to a proxy in front.
+type serverTLS struct {
+	CertFile string
+	KeyFile  string
+}
+
+func serverTLSFromEnv() (serverTLS, error) {
+	files := serverTLS{
+		CertFile: envString("TLS_CERT_FILE", ""),
+		KeyFile:  envString("TLS_KEY_FILE", ""),
+	}
+	if (files.CertFile == "") != (files.KeyFile == "") {
+		return serverTLS{}, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	return files, nil
+}
+
+func (t serverTLS) enabled() bool {
+	return t.CertFile != ""
+}
+
+// newTLSConfig refuses anything older than TLS 1.2 and, for 1.2, offers
+// only forward-secret AEAD suites. TLS 1.3 suites aren't configurable and
+// are all sound.
+func newTLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+	}
+}
+
+// listenAndServe serves plain HTTP, or HTTPS when files is enabled.
+func listenAndServe(srv *http.Server, files serverTLS) error {
+	if !files.enabled() {
+		return srv.ListenAndServe()
+	}
+	srv.TLSConfig = newTLSConfig()
+	return srv.ListenAndServeTLS(files.CertFile, files.KeyFile)
+}
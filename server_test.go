@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/store"
+)
+
+func TestNewServerTimeouts(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		srv := newServer(":8080", http.NotFoundHandler(), serverTimeoutsFromEnv(), 1<<10)
+		assertServerTimeouts(t, srv, 10*time.Second, 30*time.Second, 60*time.Second, 120*time.Second)
+		if srv.MaxHeaderBytes != 1<<10 {
+			t.Errorf("MaxHeaderBytes = %d, want %d", srv.MaxHeaderBytes, 1<<10)
+		}
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		t.Setenv("READ_HEADER_TIMEOUT", "2s")
+		t.Setenv("READ_TIMEOUT", "5s")
+		t.Setenv("WRITE_TIMEOUT", "0")
+		t.Setenv("IDLE_TIMEOUT", "1m")
+		srv := newServer(":8080", http.NotFoundHandler(), serverTimeoutsFromEnv(), 1<<10)
+		assertServerTimeouts(t, srv, 2*time.Second, 5*time.Second, 0, time.Minute)
+	})
+}
+
+func assertServerTimeouts(t *testing.T, srv *http.Server, readHeader, read, write, idle time.Duration) {
+	t.Helper()
+	if srv.ReadHeaderTimeout != readHeader {
+		t.Errorf("ReadHeaderTimeout = %s, want %s", srv.ReadHeaderTimeout, readHeader)
+	}
+	if srv.ReadTimeout != read {
+		t.Errorf("ReadTimeout = %s, want %s", srv.ReadTimeout, read)
+	}
+	if srv.WriteTimeout != write {
+		t.Errorf("WriteTimeout = %s, want %s", srv.WriteTimeout, write)
+	}
+	if srv.IdleTimeout != idle {
+		t.Errorf("IdleTimeout = %s, want %s", srv.IdleTimeout, idle)
+	}
+}
+
+func TestServerTLSMinVersion(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t)
+	t.Setenv("TLS_CERT_FILE", certFile)
+	t.Setenv("TLS_KEY_FILE", keyFile)
+	files, err := serverTLSFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newServer(ln.Addr().String(), http.NotFoundHandler(), serverTimeoutsFromEnv(), 1<<10)
+	srv.ErrorLog = log.New(io.Discard, "", 0)
+	srv.TLSConfig = newTLSConfig()
+	go srv.ServeTLS(ln, files.CertFile, files.KeyFile)
+	t.Cleanup(func() { srv.Close() })
+
+	dial := func(version uint16) error {
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+			InsecureSkipVerify: true,
+			MinVersion:         version,
+			MaxVersion:         version,
+		})
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+	if err := dial(tls.VersionTLS11); err == nil {
+		t.Error("TLS 1.1 handshake succeeded, want it rejected")
+	}
+	for _, version := range []uint16{tls.VersionTLS12, tls.VersionTLS13} {
+		if err := dial(version); err != nil {
+			t.Errorf("%s handshake failed: %v", tls.VersionName(version), err)
+		}
+	}
+}
+
+func TestServerTLSFromEnvNeedsBothFiles(t *testing.T) {
+	t.Setenv("TLS_CERT_FILE", "cert.pem")
+	t.Setenv("TLS_KEY_FILE", "")
+	if _, err := serverTLSFromEnv(); err == nil {
+		t.Error("serverTLSFromEnv accepted a certificate without a key")
+	}
+}
+
+// writeSelfSignedCert writes a throwaway certificate for 127.0.0.1 and its
+// key as PEM files.
+func writeSelfSignedCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// slowListStore delays every note list query.
+type slowListStore struct {
+	store.Store
+	delay time.Duration
+}
+
+func (s *slowListStore) GetNotesForUser(ctx context.Context, arg database.GetNotesForUserParams) ([]database.Note, error) {
+	time.Sleep(s.delay)
+	return s.Store.GetNotesForUser(ctx, arg)
+}
+
+func TestWithWriteTimeoutNoteList(t *testing.T) {
+	h, _ := newTestRouter(t, func(cfg *apiConfig) {
+		cfg.DB = &slowListStore{Store: cfg.DB, delay: 60 * time.Millisecond}
+		cfg.MaxPageSize = 1
+		cfg.ExportWriteTimeout = time.Minute
+	})
+	srv := httptest.NewUnstartedServer(h)
+	srv.Config = newServer("", h, serverTimeouts{Write: 50 * time.Millisecond}, 0)
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.Start()
+	t.Cleanup(srv.Close)
+	user := createTestUser(t, h, "alice")
+	for range 4 {
+		createTestNote(t, h, user.ApiKey, "exported")
+	}
+
+	get := func(path string) (string, error) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	// The export's five page queries take six times the server's timeout.
+	body, err := get("/v1/notes?format=csv")
+	if err != nil {
+		t.Fatalf("CSV export cut off: %v", err)
+	}
+	if rows := strings.Count(body, "exported"); rows != 4 {
+		t.Errorf("CSV export has %d rows, want 4:\n%s", rows, body)
+	}
+
+	// A plain page keeps the server's timeout, which its one query outlasts.
+	if _, err := get("/v1/notes"); err == nil {
+		t.Error("slow plain page outlived WriteTimeout, want it cut off")
+	}
+}
@@ -0,0 +1,12 @@
+-- name: CreateFollow :execrows
+INSERT OR IGNORE INTO follows (follower_id, followee_id, created_at)
+VALUES (?, ?, ?);
+--
+
+-- name: DeleteFollow :execrows
+DELETE FROM follows WHERE follower_id = ? AND followee_id = ?;
+--
+
+-- name: DeleteFollowsForUser :exec
+DELETE FROM follows WHERE follower_id = ? OR followee_id = ?;
+--
@@ -0,0 +1,16 @@
+-- name: CreateNoteRevision :exec
+INSERT INTO note_revisions (id, note_id, created_at, note)
+VALUES (?, ?, ?, ?);
+--
+
+-- name: GetNoteRevisions :many
+SELECT * FROM note_revisions WHERE note_id = ? ORDER BY created_at DESC, rowid DESC;
+--
+
+-- name: DeleteNoteRevisions :exec
+DELETE FROM note_revisions WHERE note_id = ?;
+--
+
+-- name: DeleteNoteRevisionsForUser :exec
+DELETE FROM note_revisions WHERE note_id IN (SELECT id FROM notes WHERE user_id = ?);
+--
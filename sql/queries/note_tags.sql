@@ -0,0 +1,37 @@
+-- name: AddNoteTag :execrows
+INSERT OR IGNORE INTO note_tags (note_id, tag)
+VALUES (?, ?);
+--
+
+-- name: GetTagCountsForUser :many
+SELECT note_tags.tag, COUNT(*) AS note_count
+FROM note_tags
+JOIN notes ON notes.id = note_tags.note_id
+WHERE notes.user_id = ?
+GROUP BY note_tags.tag
+ORDER BY note_count DESC, note_tags.tag ASC;
+--
+
+-- name: GetTopTagsForUser :many
+SELECT note_tags.tag, COUNT(*) AS note_count
+FROM note_tags
+JOIN notes ON notes.id = note_tags.note_id
+WHERE notes.user_id = ?
+GROUP BY note_tags.tag
+ORDER BY note_count DESC, note_tags.tag ASC
+LIMIT ?;
+--
+
+-- name: GetTagsForNote :many
+SELECT tag FROM note_tags
+WHERE note_id = ?
+ORDER BY tag ASC;
+--
+
+-- name: DeleteNoteTags :exec
+DELETE FROM note_tags WHERE note_id = ?;
+--
+
+-- name: DeleteNoteTagsForUser :exec
+DELETE FROM note_tags WHERE note_id IN (SELECT id FROM notes WHERE user_id = ?);
+--
@@ -1,6 +1,6 @@
 -- name: CreateNote :exec
-INSERT INTO notes (id, created_at, updated_at, note, user_id)
-VALUES (?, ?, ?, ?, ?);
+INSERT INTO notes (id, created_at, updated_at, note, user_id, public, metadata)
+VALUES (?, ?, ?, ?, ?, ?, ?);
 --
 
 -- name: GetNote :one
@@ -8,5 +8,112 @@ SELECT * FROM notes WHERE id = ?;
 --
 
 -- name: GetNotesForUser :many
-SELECT * FROM notes WHERE user_id = ?;
+SELECT * FROM notes
+WHERE user_id = ?
+  AND is_pinned IN (TRUE, FALSE)
+  AND created_at >= sqlc.arg(created_after)
+  AND created_at <= sqlc.arg(created_before)
+  AND (CAST(sqlc.narg(has_tags) AS BOOLEAN) IS NULL
+       OR EXISTS (SELECT 1 FROM note_tags WHERE note_tags.note_id = notes.id) = CAST(sqlc.narg(has_tags) AS BOOLEAN))
+ORDER BY is_pinned DESC, created_at DESC, rowid DESC
+LIMIT ? OFFSET ?;
+--
+
+-- name: GetRecentNotesForUser :many
+SELECT * FROM notes
+WHERE user_id = ?
+ORDER BY created_at DESC, rowid DESC
+LIMIT ?;
+--
+
+-- name: CountNotesForUser :one
+SELECT COUNT(*) FROM notes WHERE user_id = ?;
+--
+
+-- name: CountNotesForUserSince :one
+SELECT COUNT(*) FROM notes WHERE user_id = ? AND created_at >= ?;
+--
+
+-- name: UpdateNote :exec
+UPDATE notes SET note = ?, updated_at = ? WHERE id = ?;
+--
+
+-- name: GetFeedForUser :many
+SELECT notes.* FROM notes
+JOIN follows ON follows.followee_id = notes.user_id
+WHERE follows.follower_id = ? AND notes.public = TRUE
+ORDER BY notes.created_at DESC, notes.rowid DESC
+LIMIT ? OFFSET ?;
+--
+
+-- name: SetNotePublic :exec
+UPDATE notes SET public = ?, updated_at = ? WHERE id = ?;
+--
+
+-- name: SearchNotesForUser :many
+SELECT * FROM notes
+WHERE user_id = ? AND note LIKE sqlc.arg(pattern) ESCAPE '\'
+ORDER BY created_at DESC, rowid DESC
+LIMIT ? OFFSET ?;
+--
+
+-- name: CountNotesMatchingForUser :one
+SELECT COUNT(*) FROM notes
+WHERE user_id = ? AND note LIKE sqlc.arg(pattern) ESCAPE '\';
+--
+
+-- name: CountNotesForUserFiltered :one
+SELECT COUNT(*) FROM notes
+WHERE user_id = ?
+  AND created_at >= sqlc.arg(created_after)
+  AND created_at <= sqlc.arg(created_before)
+  AND (CAST(sqlc.narg(has_tags) AS BOOLEAN) IS NULL
+       OR EXISTS (SELECT 1 FROM note_tags WHERE note_tags.note_id = notes.id) = CAST(sqlc.narg(has_tags) AS BOOLEAN));
+--
+
+-- name: SearchNotesForUserAfter :many
+SELECT rowid, id, created_at, updated_at, note, user_id, public, metadata, is_pinned FROM notes
+WHERE user_id = ? AND note LIKE sqlc.arg(pattern) ESCAPE '\'
+  AND (created_at < sqlc.arg(after_created_at)
+       OR (created_at = sqlc.arg(after_created_at) AND rowid < sqlc.arg(after_rowid)))
+ORDER BY created_at DESC, rowid DESC
+LIMIT ?;
+--
+
+-- name: SetNoteMetadata :exec
+UPDATE notes SET metadata = ?, updated_at = ? WHERE id = ?;
+--
+
+-- name: SetNotePinned :exec
+UPDATE notes SET is_pinned = ?, updated_at = ? WHERE id = ?;
+--
+
+-- name: GetLastNoteUpdateForUser :one
+SELECT CAST(COALESCE(MAX(updated_at), '') AS TEXT) AS last_updated_at FROM notes WHERE user_id = ?;
+--
+
+-- name: GetNoteVersionsForUser :many
+SELECT id, updated_at FROM notes WHERE user_id = ? ORDER BY id;
+--
+
+-- name: GetActivityForUser :many
+SELECT CAST('created' AS TEXT) AS type, id AS note_id, created_at AS occurred_at FROM notes
+WHERE user_id = sqlc.arg(user_id)
+UNION ALL
+SELECT CAST('updated' AS TEXT) AS type, id AS note_id, updated_at AS occurred_at FROM notes
+WHERE user_id = sqlc.arg(user_id) AND updated_at != created_at
+ORDER BY occurred_at DESC, type DESC, note_id DESC
+LIMIT ? OFFSET ?;
+--
+
+-- name: GetNoteIDsForUser :many
+SELECT id, updated_at FROM notes WHERE user_id = ? ORDER BY id LIMIT ? OFFSET ?;
+--
+
+-- name: DeleteNote :execrows
+DELETE FROM notes WHERE id = ? AND user_id = ?;
+--
+
+-- name: DeleteNotesForUser :exec
+DELETE FROM notes WHERE user_id = ?;
 --
@@ -1,10 +1,11 @@
 -- name: CreateUser :exec
-INSERT INTO users (id, created_at, updated_at, name, api_key)
+INSERT INTO users (id, created_at, updated_at, name, api_key, name_normalized)
 VALUES (
     ?,
     ?,
     ?,
     ?,
+    ?,
     ?
 );
 --
@@ -12,3 +13,30 @@ VALUES (
 -- name: GetUser :one
 SELECT * FROM users WHERE api_key = ?;
 --
+
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = ?;
+--
+
+-- name: UpdateUserName :exec
+UPDATE users SET name = ?, name_normalized = ?, updated_at = ? WHERE id = ?;
+--
+
+-- name: UpdateUserAPIKey :exec
+UPDATE users SET api_key = ?, updated_at = ? WHERE id = ?;
+--
+
+-- name: SearchUsersByNamePrefix :many
+SELECT * FROM users
+WHERE name LIKE sqlc.arg(pattern) ESCAPE '\'
+ORDER BY name ASC, id ASC
+LIMIT ? OFFSET ?;
+--
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE id = ?;
+--
+
+-- name: DeleteUserRenames :exec
+DELETE FROM user_renames WHERE user_id = ?;
+--
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE note_revisions (
+    id TEXT PRIMARY KEY,
+    note_id TEXT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    created_at TEXT NOT NULL,
+    note TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE note_revisions;
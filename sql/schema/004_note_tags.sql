@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE note_tags (
+    note_id TEXT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (note_id, tag)
+);
+
+-- +goose Down
+DROP TABLE note_tags;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Serves the per-user list, search and count queries. SQLite appends the
+-- rowid to every index entry, so scanning this index backwards yields
+-- "created_at DESC, rowid DESC" exactly and the ORDER BY needs no sort step.
+-- Declaring created_at DESC would flip the rowid tie-breaker instead, and
+-- EXPLAIN QUERY PLAN then adds "USE TEMP B-TREE FOR LAST TERM OF ORDER BY".
+-- scripts/explainnotes.sh checks the plans.
+CREATE INDEX notes_user_id_created_at ON notes (user_id, created_at);
+
+-- +goose Down
+DROP INDEX notes_user_id_created_at;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE notes ADD COLUMN is_pinned BOOLEAN NOT NULL DEFAULT FALSE;
+-- Scanned backwards, like notes_user_id_created_at, this yields the list
+-- order "is_pinned DESC, created_at DESC, rowid DESC" without a sort step.
+-- A created_at range can only use it past is_pinned if that is constrained,
+-- so GetNotesForUser lists both values; otherwise SQLite picks the
+-- created_at index and sorts in a temp B-tree.
+CREATE INDEX notes_user_id_pinned_created_at ON notes (user_id, is_pinned, created_at);
+
+-- +goose Down
+DROP INDEX notes_user_id_pinned_created_at;
+ALTER TABLE notes DROP COLUMN is_pinned;
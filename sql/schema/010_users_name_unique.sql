@@ -0,0 +1,39 @@
+-- +goose Up
+-- Lets concurrent sign-ups race on the insert itself: the loser gets a
+-- constraint error that the handler turns into a 409, with no SELECT first.
+-- Names were never unique before, so the first account to register a name
+-- keeps it and any later ones get their ID appended, which can't collide.
+-- Each rename is recorded in user_renames, for operators to review and
+-- for the down migration to undo.
+CREATE TABLE user_renames (
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    old_name TEXT NOT NULL,
+    new_name TEXT NOT NULL,
+    migration TEXT NOT NULL
+);
+INSERT INTO user_renames (user_id, old_name, new_name, migration)
+SELECT id, name, name || '-' || id, '010' FROM users
+WHERE EXISTS (
+    SELECT 1 FROM users AS earlier
+    WHERE earlier.name = users.name AND earlier.rowid < users.rowid
+);
+UPDATE users SET name = (
+    SELECT new_name FROM user_renames
+    WHERE user_renames.user_id = users.id AND migration = '010'
+)
+WHERE id IN (SELECT user_id FROM user_renames WHERE migration = '010');
+CREATE UNIQUE INDEX users_name ON users (name);
+
+-- +goose Down
+-- Accounts renamed since keep the name they chose.
+DROP INDEX users_name;
+UPDATE users SET name = (
+    SELECT old_name FROM user_renames
+    WHERE user_renames.user_id = users.id AND migration = '010'
+)
+WHERE EXISTS (
+    SELECT 1 FROM user_renames
+    WHERE user_renames.user_id = users.id AND migration = '010'
+      AND user_renames.new_name = users.name
+);
+DROP TABLE user_renames;
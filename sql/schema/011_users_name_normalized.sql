@@ -0,0 +1,38 @@
+-- +goose Up
+-- Names are unique ignoring case, but shown as typed. lower() only folds
+-- ASCII letters, and normalizeUserName folds exactly those, so names
+-- backfilled here and names checked at runtime agree. Names that differ
+-- only by case passed users_name, so as in 010 the first account keeps its
+-- name, later ones get their ID appended, and each rename is recorded in
+-- user_renames.
+ALTER TABLE users ADD COLUMN name_normalized TEXT NOT NULL DEFAULT '';
+INSERT INTO user_renames (user_id, old_name, new_name, migration)
+SELECT id, name, name || '-' || id, '011' FROM users
+WHERE EXISTS (
+    SELECT 1 FROM users AS earlier
+    WHERE lower(earlier.name) = lower(users.name) AND earlier.rowid < users.rowid
+);
+UPDATE users SET name = (
+    SELECT new_name FROM user_renames
+    WHERE user_renames.user_id = users.id AND migration = '011'
+)
+WHERE id IN (SELECT user_id FROM user_renames WHERE migration = '011');
+UPDATE users SET name_normalized = lower(name);
+CREATE UNIQUE INDEX users_name_normalized ON users (name_normalized);
+DROP INDEX users_name;
+
+-- +goose Down
+-- Accounts renamed since keep the name they chose.
+UPDATE users SET name = (
+    SELECT old_name FROM user_renames
+    WHERE user_renames.user_id = users.id AND migration = '011'
+)
+WHERE EXISTS (
+    SELECT 1 FROM user_renames
+    WHERE user_renames.user_id = users.id AND migration = '011'
+      AND user_renames.new_name = users.name
+);
+DELETE FROM user_renames WHERE migration = '011';
+CREATE UNIQUE INDEX users_name ON users (name);
+DROP INDEX users_name_normalized;
+ALTER TABLE users DROP COLUMN name_normalized;
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// parseTimezone reads the optional tz query parameter, an IANA zone name
+// such as "Europe/Berlin", that read endpoints render timestamps in. It
+// defaults to UTC.
+func parseTimezone(r *http.Request) (*time.Location, error) {
+	name, err := queryValue(r.URL.Query(), "tz")
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return time.UTC, nil
+	}
+	// LoadLocation maps "Local" to the server's own zone, which clients
+	// can't know.
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, fmt.Errorf("tz must be an IANA time zone name, got %q", name)
+	}
+	return loc, nil
+}
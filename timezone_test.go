@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func TestTimezoneParam(t *testing.T) {
+	h, db := newTestRouter(t)
+	user := createTestUser(t, h, "alice")
+	noteID := "7f1c1d2e-3b4a-4c5d-8e9f-0a1b2c3d4e5f"
+	err := db.CreateNote(context.Background(), database.CreateNoteParams{
+		ID:        noteID,
+		CreatedAt: "2024-03-10T12:00:00Z",
+		UpdatedAt: "2024-03-10T12:00:00Z",
+		Note:      "hello",
+		UserID:    user.ID,
+		Metadata:  emptyNoteMetadata,
+	})
+	if err != nil {
+		t.Fatalf("seeding note: %v", err)
+	}
+
+	paths := []string{
+		"/v1/notes",
+		"/v1/notes/" + noteID,
+		"/v1/notes/recent",
+		"/v1/notes/search?q=hello",
+	}
+	tests := []struct {
+		name     string
+		tz       string
+		wantCode int
+		want     string
+	}{
+		{"default", "", http.StatusOK, `"created_at":"2024-03-10T12:00:00Z"`},
+		{"valid", "Asia/Kolkata", http.StatusOK, `"created_at":"2024-03-10T17:30:00+05:30"`},
+		{"invalid", "Mars/Olympus_Mons", http.StatusBadRequest, "tz must be an IANA time zone name"},
+		{"server local", "Local", http.StatusBadRequest, "tz must be an IANA time zone name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, path := range paths {
+				if tt.tz != "" {
+					sep := "?"
+					if strings.Contains(path, "?") {
+						sep = "&"
+					}
+					path += sep + "tz=" + tt.tz
+				}
+				rec := doRequest(t, h, http.MethodGet, path, user.ApiKey, nil)
+				if rec.Code != tt.wantCode {
+					t.Fatalf("GET %s status = %d, want %d: %s", path, rec.Code, tt.wantCode, rec.Body)
+				}
+				if !strings.Contains(rec.Body.String(), tt.want) {
+					t.Errorf("GET %s body = %s, want it to contain %s", path, rec.Body, tt.want)
+				}
+			}
+		})
+	}
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/users?tz=Asia/Kolkata", user.ApiKey, nil)
+	got := decodeResponse[User](t, rec)
+	if _, offset := got.CreatedAt.Zone(); offset != 5*3600+30*60 {
+		t.Errorf("user created_at = %v, want it at +05:30", got.CreatedAt)
+	}
+}